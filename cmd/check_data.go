@@ -17,8 +17,10 @@ package cmd
 import (
 	"context"
 	"log"
-	"time"
+	"net/http"
 
+	"github.com/coinbase/rosetta-cli/internal/concurrency"
+	"github.com/coinbase/rosetta-cli/internal/recorder"
 	"github.com/coinbase/rosetta-cli/internal/tester"
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
@@ -49,11 +51,31 @@ only at the current block). If your node does not support this functionality
 set historical balance disabled to true. This will make reconciliation much
 less efficient but it will still work.
 
+If your node requires an API key header, a bearer token, or client certificates,
+populate the connection config with static headers, the environment variable or
+file containing the bearer token, a CA bundle, and a client certificate and key.
+These settings apply to every request made to the online url (including by
+utils:proxy) but not to the reference url of check:diff.
+
+If your node is behind an API gateway that throttles requests, populate the
+rate limit config with the requests per second (and burst) to allow. Every
+request is limited, including balance lookups during reconciliation. When the
+node responds with 429 Too Many Requests, all requests are paused (for the
+duration in the Retry-After header or an exponential backoff) and the throttled
+request is retried. Time spent waiting for the rate limit or a pause is not
+counted against the HTTP timeout.
+
 If check fails due to an INACTIVE reconciliation error (balance changed without
 any corresponding operation), the cli will automatically try to find the block
 missing an operation. If historical balance disabled is true, this automatic
 debugging tool does not work.
 
+If check fails due to a reconciliation error, the cli will write a directory
+to the data directory (in failures/) containing the failing block and its
+parent, the computed and node balances, recent balance changes for the
+account, the configuration used (with connection header values redacted), and
+any related lines from the stream logs.
+
 To debug an INACTIVE account reconciliation error without historical balance lookup,
 set the interesting accunts to the path of a JSON file containing
 accounts that will be actively checked for balance changes at each block. This
@@ -64,7 +86,101 @@ If your blockchain has a genesis allocation of funds and you set
 historical balance disabled to true, you must provide an
 absolute path to a JSON file containing initial balances with the
 bootstrap balance config. You can look at the examples folder for an example
-of what one of these files looks like.`,
+of what one of these files looks like.
+
+To catch inflation bugs in accounts that are never reconciled, populate the
+supply check config. The total supply of each currency (the sum of all computed
+balances) can be compared to a fixed value after every block, to values at
+specific blocks listed in a file, or restricted to only change in the mint/burn
+operation types. Supply is only tracked when the supply check config is
+populated and supply checks are only accurate when syncing from genesis
+without exempt accounts.
+
+To report the fees paid on a network, populate the fee operation types. The
+fees paid in each block and transaction (the decrease in balances caused by
+successful fee operations) are written to fees.txt in the data directory and
+the total fees paid in each currency are logged with the other stats.
+
+To catch a missing counter-party operation in the transaction where it occurs,
+set conservation check enabled to true. The successful balance-changing
+operations in each transaction must then sum to zero for each currency,
+excluding operations with a fee operation type or a mint/burn operation type.
+The genesis block is not checked. The same mint/burn operation types are the
+only operation types allowed to change supply when the supply check config is
+populated.
+
+The timestamp of each block (other than genesis) must be in milliseconds, must
+not be further ahead of the local clock than the max timestamp skew, and must
+not be less than the timestamp of its parent unless timestamp decrease allowed
+is set to true. Populate the min and max block interval to also check the time
+between each block and its parent (in milliseconds).
+
+To stop tuning the block and transaction concurrency by hand, populate the
+adaptive concurrency config. The block and transaction concurrency are then only
+the initial concurrency. After each window of requests, the concurrency is halved
+if too many requests fail (or are throttled), decreased if the latency of requests
+increased (compared to a baseline that slowly follows lasting changes in
+latency), and increased if requests had to wait to be made (so it stops growing
+when processing and committing blocks, not fetching them, is the bottleneck).
+Time spent waiting to be made is not counted against the HTTP timeout. The
+current concurrency and its limits are logged with the other stats.
+
+Operations in canonical blocks are counted by type and status (as returned in
+/network/options) and by whether or not they change a balance. To catch
+operation types and statuses that are never exercised, populate the operation
+coverage depth with a number of blocks. After syncing that many blocks, any
+type or status that has not been seen is logged as a warning.
+
+The related operations of each transaction must exist in the same transaction
+and must not reference themselves or form a cycle. Populate the paired operation
+types to also require that each operation of those types is related to exactly
+one other operation of the same type (like the debit and credit of a transfer).
+
+To catch metadata fields that disappear or change type, populate the metadata
+schemas config with paths to JSON Schema files for block metadata, transaction
+metadata, and the metadata of each operation type. Check will fail with every
+violation in the first block containing metadata that does not match its schema
+(missing metadata is validated as an empty object).
+
+To check the Mempool API, populate the mempool check config. The cli will
+poll /mempool, fetch each new mempool transaction, and validate it with the same
+rules as block transactions (block metadata schemas are not applied). Check
+will fail if any is invalid. Polls that cannot fetch the mempool (or a mempool
+transaction) are skipped, and check will fail if 10 consecutive polls fail.
+Transactions that leave the mempool must appear in a synced block before the cli
+syncs the inclusion window of blocks past the network tip (when the transaction
+left). Otherwise, they are reported as dropped.
+
+To detect a node that stops following the network, populate the liveness check
+config. The cli will poll /network/status and log a warning if the network tip
+does not advance within the stall timeout (in seconds) or goes backwards further
+than the max reorg depth. Set halt on failure to true to fail the check instead.
+The sync lag (the network tip index minus the synced head index) is logged with
+the other stats.
+
+To catch malformed addresses, populate the address format config with an address
+pattern, an optional checksum algorithm (eip55 or bech32), and the allowed shape
+of sub-accounts. Check will fail if the account of any operation (and so of any
+balance change) does not match this format.
+
+If the accounting model in the construction config is utxo, the cli tracks
+each coin created and spent in operation metadata ("utxo_created" and
+"utxo_spent"). Check will fail if a coin is created twice, spent twice, or
+spent before it was created. When syncing from genesis with balance tracking
+enabled (and no exempt accounts or bootstrap balances), check will also fail
+if the sum of an account's unspent coins does not equal its computed balance
+after a coin of the account is created or spent (accounts allowed to have a
+negative balance are not checked).
+
+To reproduce a run without access to a node, populate the --record flag
+with a path to write every response from the node to a gzipped fixture. Passing
+the same path to the --replay flag will serve all responses from the fixture
+instead of making requests to the online url. Requests that were not recorded
+are only retried once when replaying, so you should replay a fixture with the same
+configuration, --start, and --end used to record it (starting with an empty
+data directory). Inactive reconciliation looks up accounts at random, so it may
+request balances that were not recorded. If this occurs, set reconciliation
+disabled to true when recording and replaying.`,
 		Run: runCheckDataCmd,
 	}
 
@@ -73,6 +189,14 @@ of what one of these files looks like.`,
 
 	// EndIndex is the block index to stop syncing.
 	EndIndex int64
+
+	// RecordPath is the path to write a fixture of
+	// all node responses.
+	RecordPath string
+
+	// ReplayPath is the path of a fixture to serve
+	// node responses from.
+	ReplayPath string
 )
 
 func init() {
@@ -88,18 +212,109 @@ func init() {
 		-1,
 		"block index to stop syncing",
 	)
+	checkDataCmd.Flags().StringVar(
+		&RecordPath,
+		"record",
+		"",
+		"path to record all node responses to",
+	)
+	checkDataCmd.Flags().StringVar(
+		&ReplayPath,
+		"replay",
+		"",
+		"path of recorded node responses to replay",
+	)
+}
+
+// fetcherTransport returns the transport and fetcher options
+// needed to record or replay node responses (if either is
+// enabled). Otherwise, the node transport is returned.
+func fetcherTransport() (http.RoundTripper, []fetcher.Option) {
+	if len(RecordPath) > 0 && len(ReplayPath) > 0 {
+		log.Fatal("cannot record and replay node responses at the same time")
+	}
+
+	switch {
+	case len(RecordPath) > 0:
+		r, err := recorder.NewRecorder(RecordPath, nodeTransport())
+		if err != nil {
+			log.Fatalf("%s: unable to create recorder", err.Error())
+		}
+
+		log.Printf("recording node responses to %s\n", RecordPath)
+		return r, nil
+	case len(ReplayPath) > 0:
+		r, err := recorder.NewReplayer(ReplayPath)
+		if err != nil {
+			log.Fatalf("%s: unable to create replayer", err.Error())
+		}
+
+		log.Printf("replaying %d node responses from %s\n", r.Entries(), ReplayPath)
+
+		// Recorded responses never change, so there is
+		// no reason to retry a request more than once (a
+		// max retries of 0 retries until the retry elapsed
+		// time is exceeded).
+		return r, []fetcher.Option{fetcher.WithMaxRetries(1)}
+	default:
+		return nodeTransport(), nil
+	}
+}
+
+// adaptiveConcurrency returns the transport, fetcher options, and
+// concurrency limits needed to adjust the block and transaction
+// concurrency (if adaptive concurrency is enabled). The fetcher is
+// allowed the max concurrency and the returned transport (wrapping
+// transport) limits the concurrency of requests.
+func adaptiveConcurrency(
+	transport http.RoundTripper,
+) (http.RoundTripper, []fetcher.Option, []*concurrency.Limit) {
+	adaptive := Config.Data.AdaptiveConcurrency
+	if adaptive == nil {
+		return transport, []fetcher.Option{
+			fetcher.WithBlockConcurrency(Config.Data.BlockConcurrency),
+			fetcher.WithTransactionConcurrency(Config.Data.TransactionConcurrency),
+		}, nil
+	}
+
+	blockLimit := concurrency.NewLimit(
+		"Blocks",
+		Config.Data.BlockConcurrency,
+		adaptive.MinBlockConcurrency,
+		adaptive.MaxBlockConcurrency,
+	)
+	transactionLimit := concurrency.NewLimit(
+		"Transactions",
+		Config.Data.TransactionConcurrency,
+		adaptive.MinTransactionConcurrency,
+		adaptive.MaxTransactionConcurrency,
+	)
+
+	limitedTransport := concurrency.NewTransport(
+		map[string]*concurrency.Limit{
+			"/block":             blockLimit,
+			"/block/transaction": transactionLimit,
+		},
+		transport,
+	)
+	opts := []fetcher.Option{
+		fetcher.WithBlockConcurrency(adaptive.MaxBlockConcurrency),
+		fetcher.WithTransactionConcurrency(adaptive.MaxTransactionConcurrency),
+	}
+
+	return limitedTransport, opts, []*concurrency.Limit{blockLimit, transactionLimit}
 }
 
 func runCheckDataCmd(cmd *cobra.Command, args []string) {
 	ensureDataDirectoryExists()
 	ctx, cancel := context.WithCancel(context.Background())
 
-	fetcher := fetcher.New(
+	transport, fetcherOpts := fetcherTransport()
+	transport, concurrencyOpts, concurrencyLimits := adaptiveConcurrency(transport)
+	fetcher := newFetcher(
 		Config.OnlineURL,
-		fetcher.WithBlockConcurrency(Config.Data.BlockConcurrency),
-		fetcher.WithTransactionConcurrency(Config.Data.TransactionConcurrency),
-		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
+		transport,
+		append(fetcherOpts, concurrencyOpts...)...,
 	)
 
 	_, _, err := fetcher.InitializeAsserter(ctx)
@@ -121,6 +336,7 @@ func runCheckDataCmd(cmd *cobra.Command, args []string) {
 		networkStatus.GenesisBlockIdentifier,
 		nil, // only populated when doing recursive search
 		&SignalReceived,
+		concurrencyLimits,
 	)
 
 	defer dataTester.CloseDatabase(ctx)
@@ -139,6 +355,18 @@ func runCheckDataCmd(cmd *cobra.Command, args []string) {
 		return dataTester.StartSyncing(ctx, StartIndex, EndIndex)
 	})
 
+	g.Go(func() error {
+		return dataTester.StartPruning(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartMempoolChecker(ctx)
+	})
+
+	g.Go(func() error {
+		return dataTester.StartLivenessChecker(ctx)
+	})
+
 	sigListeners := []context.CancelFunc{cancel}
 	go handleSignals(sigListeners)
 
@@ -0,0 +1,148 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/coinbase/rosetta-cli/internal/tester"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkDiffCmd = &cobra.Command{
+		Use:   "check:diff <reference url>",
+		Short: "Compare a Rosetta Data API Implementation to a reference implementation",
+		Long: `When re-implementing a Rosetta API (or upgrading a node), it is useful
+to confirm the new implementation returns the same data as an existing one.
+This command fetches each block from the online url in the configuration file
+and from the provided reference url and compares the blocks, transactions,
+operations, and the balance changes computed from each block.
+
+Comparison starts at the genesis block unless the --start flag is populated and
+continues until the --end flag is reached (or forever if it is not populated),
+waiting for both implementations to reach each block. To avoid comparing blocks
+that may still be orphaned, populate the --confirmation-depth flag with the
+number of blocks to stay behind the lowest tip of both implementations. The
+connection config (headers, bearer token, and TLS certificates) is only used for
+requests to the online url and never for requests to the reference url.
+
+When the implementations diverge, the first divergence is printed as a list of
+differences (the path of each differing field with the value returned by the
+reference implementation as expected and the value returned by the online url
+as actual) and the command exits with a non-zero status.`,
+		Run:  runCheckDiffCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	// ConfirmationDepth is the number of blocks
+	// to stay behind the lowest tip of both
+	// implementations.
+	ConfirmationDepth int64
+)
+
+func init() {
+	checkDiffCmd.Flags().Int64Var(
+		&StartIndex,
+		"start",
+		-1,
+		"block index to start comparing",
+	)
+	checkDiffCmd.Flags().Int64Var(
+		&EndIndex,
+		"end",
+		-1,
+		"block index to stop comparing",
+	)
+	checkDiffCmd.Flags().Int64Var(
+		&ConfirmationDepth,
+		"confirmation-depth",
+		0,
+		"number of blocks to stay behind the lowest tip of both implementations",
+	)
+}
+
+// newDiffFetcher returns a *fetcher.Fetcher with an initialized
+// asserter for a url that supports Config.Network. Requests are
+// made with transport.
+func newDiffFetcher(
+	ctx context.Context,
+	url string,
+	transport http.RoundTripper,
+) *fetcher.Fetcher {
+	f := newFetcher(
+		url,
+		transport,
+		fetcher.WithTransactionConcurrency(Config.Data.TransactionConcurrency),
+	)
+
+	_, _, err := f.InitializeAsserter(ctx)
+	if err != nil {
+		log.Fatalf("%s: unable to initialize asserter for %s", err.Error(), url)
+	}
+
+	_, err = utils.CheckNetworkSupported(ctx, Config.Network, f)
+	if err != nil {
+		log.Fatalf("%s: unable to confirm network is supported by %s", err.Error(), url)
+	}
+
+	return f
+}
+
+func runCheckDiffCmd(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	diffTester := tester.InitializeDiff(
+		Config.Network,
+		newDiffFetcher(ctx, Config.OnlineURL, nodeTransport()),
+
+		// The connection settings (like bearer tokens) are only
+		// for the online url and are never sent to the reference.
+		newDiffFetcher(ctx, args[0], timeoutTransport(nil)),
+		ConfirmationDepth,
+	)
+
+	sigListeners := []context.CancelFunc{cancel}
+	go handleSignals(sigListeners)
+
+	err := diffTester.Start(ctx, StartIndex, EndIndex)
+	if SignalReceived {
+		color.Red("Check halted")
+		os.Exit(1)
+	}
+
+	if errors.Is(err, tester.ErrDivergence) {
+		color.Red("Check failed: implementations diverged")
+		fmt.Println(types.PrettyPrintStruct(diffTester.Divergence))
+		os.Exit(1)
+	}
+
+	if err != nil {
+		color.Red("Check failed: %s", err.Error())
+		os.Exit(1)
+	}
+
+	color.Green("Check succeeded")
+}
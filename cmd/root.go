@@ -18,14 +18,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/connection"
+	"github.com/coinbase/rosetta-cli/internal/ratelimit"
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
+	"github.com/coinbase/rosetta-sdk-go/client"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -87,14 +92,19 @@ default values.`,
 	// Check commands
 	rootCmd.AddCommand(checkDataCmd)
 	rootCmd.AddCommand(checkConstructionCmd)
+	rootCmd.AddCommand(checkDiffCmd)
 
 	// View Commands
 	rootCmd.AddCommand(viewBlockCmd)
 	rootCmd.AddCommand(viewAccountCmd)
+	rootCmd.AddCommand(viewAccountHistoryCmd)
 	rootCmd.AddCommand(viewNetworkCmd)
 
 	// Utils
 	rootCmd.AddCommand(utilsAsserterConfigurationCmd)
+	rootCmd.AddCommand(utilsMockServerCmd)
+	rootCmd.AddCommand(utilsProxyCmd)
+	rootCmd.AddCommand(utilsVerifyStorageCmd)
 }
 
 func initConfig() {
@@ -122,6 +132,82 @@ func ensureDataDirectoryExists() {
 	}
 }
 
+// connectionTransport returns the transport used to make
+// requests to the online url with the configured connection
+// settings (or nil if there are none).
+func connectionTransport() http.RoundTripper {
+	if Config.Connection == nil {
+		return nil
+	}
+
+	transport, err := connection.NewTransport(Config.Connection)
+	if err != nil {
+		log.Fatalf("%s: unable to initialize connection", err.Error())
+	}
+
+	return transport
+}
+
+// timeoutTransport returns transport (or http.DefaultTransport
+// if nil) with each request limited by the HTTP timeout.
+func timeoutTransport(transport http.RoundTripper) http.RoundTripper {
+	return connection.NewTimeoutTransport(
+		time.Duration(Config.HTTPTimeout)*time.Second,
+		transport,
+	)
+}
+
+// nodeTransport returns the transport used to make requests
+// to the online url with the configured connection settings
+// (if any). Each request is limited by the HTTP timeout.
+func nodeTransport() http.RoundTripper {
+	return timeoutTransport(connectionTransport())
+}
+
+// newFetcher returns a *fetcher.Fetcher for url that makes
+// requests with transport (or nodeTransport if nil), limited
+// by the configured rate limit. The retry elapsed time is
+// always overridden.
+//
+// The HTTP timeout is enforced by nodeTransport (instead of
+// the timeout of the http.Client) so that time spent waiting
+// for the rate limit or a throttled request to be retried is
+// not counted against it.
+func newFetcher(
+	url string,
+	transport http.RoundTripper,
+	opts ...fetcher.Option,
+) *fetcher.Fetcher {
+	if transport == nil {
+		transport = nodeTransport()
+	}
+
+	if Config.RateLimit != nil {
+		transport = ratelimit.NewTransport(
+			Config.RateLimit.RequestsPerSecond,
+			Config.RateLimit.Burst,
+			transport,
+		)
+	}
+
+	fetcherOpts := []fetcher.Option{
+		fetcher.WithClient(client.NewAPIClient(
+			client.NewConfiguration(
+				url,
+				fetcher.DefaultUserAgent,
+				&http.Client{Transport: transport},
+			),
+		)),
+	}
+	fetcherOpts = append(fetcherOpts, opts...)
+	fetcherOpts = append(
+		fetcherOpts,
+		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
+	)
+
+	return fetcher.New(url, fetcherOpts...)
+}
+
 // handleSignals handles OS signals so we can ensure we close database
 // correctly. We call multiple sigListeners because we
 // may need to cancel more than 1 context.
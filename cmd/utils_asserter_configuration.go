@@ -17,11 +17,9 @@ package cmd
 import (
 	"context"
 	"log"
-	"time"
 
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/spf13/cobra"
 )
 
@@ -46,19 +44,15 @@ func runCreateConfigurationCmd(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 
 	// Create a new fetcher
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
-	)
+	f := newFetcher(Config.OnlineURL, nil)
 
 	// Initialize the fetcher's asserter
-	_, _, err := newFetcher.InitializeAsserter(ctx)
+	_, _, err := f.InitializeAsserter(ctx)
 	if err != nil {
 		log.Fatalf("%s: failed to initialize asserter", err.Error())
 	}
 
-	configuration, err := newFetcher.Asserter.ClientConfiguration()
+	configuration, err := f.Asserter.ClientConfiguration()
 	if err != nil {
 		log.Fatalf("%s: unable to generate spec", err.Error())
 	}
@@ -0,0 +1,92 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/coinbase/rosetta-cli/internal/mockserver"
+
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsMockServerCmd = &cobra.Command{
+		Use:   "utils:mock-server",
+		Short: "Serve a scripted chain over the Rosetta Data API",
+		Long: `When developing against the rosetta-cli (or learning what each check:data
+failure looks like), it is useful to have a Rosetta Data API implementation
+that behaves in a predictable way. This command serves the blocks in a fixture
+file on /network/list, /network/status, /network/options, /block,
+/block/transaction, and /account/balance.
+
+The tip of the chain starts at the genesis block and advances by one block
+each time /network/status is called. The fixture can also specify reorgs (blocks
+that are served with a different hash until the tip passes them), missing
+operations (operations omitted from /block that still change balances), and
+balance discrepancies (amounts added to an account balance starting at some
+block). You can look at the examples folder for an example of what a fixture
+looks like.
+
+To use this command, simply provide an absolute path to the fixture as the
+argument and populate the online url in your configuration file with the
+address the mock server is listening on.`,
+		Run:  runUtilsMockServerCmd,
+		Args: cobra.ExactArgs(1),
+	}
+
+	// MockServerPort is the port the mock server
+	// listens on.
+	MockServerPort uint
+)
+
+func init() {
+	utilsMockServerCmd.Flags().UintVar(
+		&MockServerPort,
+		"port",
+		8080,
+		"port to serve the Rosetta Data API on",
+	)
+}
+
+func runUtilsMockServerCmd(cmd *cobra.Command, args []string) {
+	fixture, err := mockserver.LoadFixture(args[0])
+	if err != nil {
+		log.Fatalf("%s: unable to load fixture", err.Error())
+	}
+
+	mockServer, err := mockserver.NewServer(fixture)
+	if err != nil {
+		log.Fatalf("%s: unable to create mock server", err.Error())
+	}
+
+	router, err := mockServer.Handler()
+	if err != nil {
+		log.Fatalf("%s: unable to create router", err.Error())
+	}
+
+	log.Printf(
+		"serving %d blocks on port %d\n",
+		len(fixture.Blocks),
+		MockServerPort,
+	)
+	log.Fatal(http.ListenAndServe(
+		fmt.Sprintf(":%d", MockServerPort),
+		server.CorsMiddleware(server.LoggerMiddleware(router)),
+	))
+}
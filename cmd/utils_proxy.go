@@ -0,0 +1,153 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/proxy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsProxyCmd = &cobra.Command{
+		Use:   "utils:proxy",
+		Short: "Inject network faults between the rosetta-cli and a node",
+		Long: `Rosetta implementations (and the retry settings used by the rosetta-cli)
+should tolerate a flaky network. This command starts a proxy in front of the
+online url in the configuration file that forwards all requests to the node
+and injects faults at the configured rates (between 0 and 1):
+
+* latency: the request is delayed before it is forwarded
+* errors: a 5xx error is returned without forwarding the request
+* timeouts: the request is held until the client gives up (or the
+  timeout elapses)
+* truncation: only half of the response body is returned
+* reordering: the response is held until another response is returned (or
+  the reorder window elapses), so concurrent requests are answered out of order
+
+To test the rosetta-cli with injected faults, run check:data with a
+configuration file where the online url is the address of the proxy. Faults
+are injected pseudo-randomly, so populating the --seed flag with the same value
+will inject faults into the same requests if they are made in the same order.
+
+Requests are forwarded to the node with the connection config in the
+configuration file (headers, bearer token, and TLS certificates).`,
+		Run: runUtilsProxyCmd,
+	}
+
+	// ProxyPort is the port the proxy listens on.
+	ProxyPort uint
+
+	// ProxySeed is the seed used to inject faults.
+	ProxySeed int64
+
+	// ProxyFaults are the faults injected by the proxy.
+	ProxyFaults = &proxy.Faults{}
+)
+
+func init() {
+	utilsProxyCmd.Flags().UintVar(
+		&ProxyPort,
+		"port",
+		8081,
+		"port to listen on",
+	)
+	utilsProxyCmd.Flags().Int64Var(
+		&ProxySeed,
+		"seed",
+		-1,
+		"seed used to inject faults (the current time is used if -1)",
+	)
+	utilsProxyCmd.Flags().Float64Var(
+		&ProxyFaults.LatencyRate,
+		"latency-rate",
+		0,
+		"rate at which latency is added to requests",
+	)
+	utilsProxyCmd.Flags().DurationVar(
+		&ProxyFaults.Latency,
+		"latency",
+		time.Second,
+		"latency to add to requests",
+	)
+	utilsProxyCmd.Flags().Float64Var(
+		&ProxyFaults.ErrorRate,
+		"error-rate",
+		0,
+		"rate at which 5xx errors are returned",
+	)
+	utilsProxyCmd.Flags().Float64Var(
+		&ProxyFaults.TimeoutRate,
+		"timeout-rate",
+		0,
+		"rate at which requests time out",
+	)
+	utilsProxyCmd.Flags().DurationVar(
+		&ProxyFaults.Timeout,
+		"timeout",
+		time.Minute,
+		"time to hold a request that times out",
+	)
+	utilsProxyCmd.Flags().Float64Var(
+		&ProxyFaults.TruncateRate,
+		"truncate-rate",
+		0,
+		"rate at which response bodies are truncated",
+	)
+	utilsProxyCmd.Flags().Float64Var(
+		&ProxyFaults.ReorderRate,
+		"reorder-rate",
+		0,
+		"rate at which responses are reordered",
+	)
+	utilsProxyCmd.Flags().DurationVar(
+		&ProxyFaults.ReorderWindow,
+		"reorder-window",
+		time.Second,
+		"maximum time to hold a reordered response",
+	)
+}
+
+func runUtilsProxyCmd(cmd *cobra.Command, args []string) {
+	if err := ProxyFaults.Validate(); err != nil {
+		log.Fatalf("%s: invalid faults", err.Error())
+	}
+
+	target, err := url.Parse(Config.OnlineURL)
+	if err != nil {
+		log.Fatalf("%s: unable to parse online url", err.Error())
+	}
+
+	if ProxySeed == -1 {
+		ProxySeed = time.Now().UnixNano()
+	}
+
+	log.Printf(
+		"proxying requests on port %d to %s (seed: %d)\n",
+		ProxyPort,
+		Config.OnlineURL,
+		ProxySeed,
+	)
+	log.Fatal(http.ListenAndServe(
+		fmt.Sprintf(":%d", ProxyPort),
+		proxy.New(target, ProxyFaults, ProxySeed, connectionTransport()),
+	))
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/coinbase/rosetta-cli/internal/tester"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsVerifyStorageCmd = &cobra.Command{
+		Use:   "utils:verify-storage",
+		Short: "Verify the consistency of the data directory",
+		Long: `After check:data is interrupted (especially by a crash), it is useful
+to know whether its data directory can be trusted before syncing resumes. This
+command walks the blocks stored for the network in the configuration file from
+the head block back to the oldest stored block (genesis if syncing started at
+genesis) and checks that:
+
+* each block is stored under its own identifier and its parent is stored
+* the block hash and transaction hash indexes match the stored blocks
+* the block, transaction, and operation counters match the stored blocks
+* every balance recomputed from the stored blocks (starting from any bootstrap
+  balances and skipping operations of exempt accounts) matches the stored balance
+
+Transactions and operations in orphaned or pruned blocks are never subtracted
+from their counters, so those counters are only checked if no blocks were
+orphaned or pruned. Rewinding the data directory with the --start flag of
+check:data does not update counters either. Balances are only recomputed if the
+genesis block is stored and no blocks were pruned.
+
+The asserter used to recompute balances is initialized from the online url
+unless the --asserter-configuration flag is populated with the path of a file
+created by utils:asserter-configuration (so that a data directory can be
+verified without access to a node). check:data must not be running on the same
+data directory.
+
+All problems found are printed and the command exits with a non-zero status
+if there were any.`,
+		Run: runUtilsVerifyStorageCmd,
+	}
+
+	// AsserterConfigurationFile is the path of an asserter
+	// configuration file created by utils:asserter-configuration.
+	AsserterConfigurationFile string
+)
+
+func init() {
+	utilsVerifyStorageCmd.Flags().StringVar(
+		&AsserterConfigurationFile,
+		"asserter-configuration",
+		"",
+		"path of an asserter configuration file to use instead of the online url",
+	)
+}
+
+// verifyStorageAsserter returns the *asserter.Asserter used
+// to recompute balances.
+func verifyStorageAsserter(ctx context.Context) *asserter.Asserter {
+	if len(AsserterConfigurationFile) > 0 {
+		a, err := asserter.NewClientWithFile(AsserterConfigurationFile)
+		if err != nil {
+			log.Fatalf("%s: unable to load asserter configuration", err.Error())
+		}
+
+		return a
+	}
+
+	f := newFetcher(Config.OnlineURL, nil)
+
+	_, _, err := f.InitializeAsserter(ctx)
+	if err != nil {
+		log.Fatalf("%s: unable to initialize asserter", err.Error())
+	}
+
+	_, err = utils.CheckNetworkSupported(ctx, Config.Network, f)
+	if err != nil {
+		log.Fatalf("%s: unable to confirm network is supported", err.Error())
+	}
+
+	return f.Asserter
+}
+
+func runUtilsVerifyStorageCmd(cmd *cobra.Command, args []string) {
+	if len(Config.DataDirectory) == 0 {
+		log.Fatal("data directory must be populated to verify storage")
+	}
+
+	ctx := context.Background()
+
+	result, err := tester.VerifyData(ctx, Config, Config.Network, verifyStorageAsserter(ctx))
+	if err != nil {
+		color.Red("Verification failed: %s", err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Println(types.PrettyPrintStruct(result))
+
+	if len(result.Problems) > 0 {
+		color.Red("Verification failed: %d problems found", len(result.Problems))
+		os.Exit(1)
+	}
+
+	color.Green("Verification succeeded")
+}
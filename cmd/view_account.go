@@ -20,12 +20,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
+	"github.com/coinbase/rosetta-cli/internal/processor"
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/spf13/cobra"
 )
@@ -41,7 +40,10 @@ any account by providing a JSON representation of a types.AccountIdentifier
 
 For example, you could run view:account '{"address":"interesting address"}' 1000
 to lookup the balance of an interesting address at block 1000. Allowing the
-address to specified as JSON allows for querying by SubAccountIdentifier.`,
+address to specified as JSON allows for querying by SubAccountIdentifier.
+
+If the address format is populated in the data config, the account identifier
+must match it (the same format check:data applies to every account).`,
 		Run:  runViewAccountCmd,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -59,20 +61,23 @@ func runViewAccountCmd(cmd *cobra.Command, args []string) {
 		log.Fatal(fmt.Errorf("%w: invalid account identifier %+v", err, account))
 	}
 
+	if Config.Data.AddressFormat != nil {
+		checker := processor.NewAddressChecker(Config.Data.AddressFormat)
+		if err := checker.CheckAccount(account); err != nil {
+			log.Fatal(fmt.Errorf("%w: invalid account identifier %+v", err, account))
+		}
+	}
+
 	// Create a new fetcher
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
-	)
+	f := newFetcher(Config.OnlineURL, nil)
 
 	// Initialize the fetcher's asserter
-	_, _, err := newFetcher.InitializeAsserter(ctx)
+	_, _, err := f.InitializeAsserter(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	_, err = utils.CheckNetworkSupported(ctx, Config.Network, newFetcher)
+	_, err = utils.CheckNetworkSupported(ctx, Config.Network, f)
 	if err != nil {
 		log.Fatalf("%s: unable to confirm network is supported", err.Error())
 	}
@@ -87,7 +92,7 @@ func runViewAccountCmd(cmd *cobra.Command, args []string) {
 		lookupBlock = &types.PartialBlockIdentifier{Index: &index}
 	}
 
-	block, amounts, metadata, err := newFetcher.AccountBalanceRetry(
+	block, amounts, metadata, err := f.AccountBalanceRetry(
 		ctx,
 		Config.Network,
 		account,
@@ -0,0 +1,101 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/tester"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	viewAccountHistoryCmd = &cobra.Command{
+		Use:   "view:account-history",
+		Short: "View the balance history of an account",
+		Long: `While debugging, it is often useful to understand how an account
+reached a certain balance. This command prints every balance change (and the
+resulting balance) of an account computed during a previous run of check:data,
+without making any requests to the node.
+
+To use this command, balance history must have been enabled (by setting
+balance history enabled to true) and the data directory of the previous run
+must be populated in the configuration file. check:data must not be running
+on the same data directory.
+
+For example, you could run view:account-history '{"address":"interesting address"}'
+'{"symbol":"BTC","decimals":8}' to view the balance history of an interesting
+address. Allowing the account and currency to be specified as JSON allows for
+querying by SubAccountIdentifier.`,
+		Run:  runViewAccountHistoryCmd,
+		Args: cobra.ExactArgs(2),
+	}
+)
+
+func runViewAccountHistoryCmd(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+
+	account := &types.AccountIdentifier{}
+	if err := json.Unmarshal([]byte(args[0]), account); err != nil {
+		log.Fatal(fmt.Errorf("%w: unable to unmarshal account %s", err, args[0]))
+	}
+
+	if err := asserter.AccountIdentifier(account); err != nil {
+		log.Fatal(fmt.Errorf("%w: invalid account identifier %+v", err, account))
+	}
+
+	currency := &types.Currency{}
+	if err := json.Unmarshal([]byte(args[1]), currency); err != nil {
+		log.Fatal(fmt.Errorf("%w: unable to unmarshal currency %s", err, args[1]))
+	}
+
+	if len(Config.DataDirectory) == 0 {
+		log.Fatal("data directory must be populated to view account history")
+	}
+
+	dataPath, err := tester.DataPath(Config.DataDirectory, Config.Network)
+	if err != nil {
+		log.Fatalf("%s: cannot find data path", err.Error())
+	}
+
+	localStore, err := storage.NewBadgerStorage(ctx, dataPath)
+	if err != nil {
+		log.Fatalf("%s: unable to open database", err.Error())
+	}
+	defer localStore.Close(ctx)
+
+	balanceStorage := storage.NewBalanceStorage(localStore)
+	history, err := balanceStorage.GetBalanceHistory(ctx, account, currency)
+	if err != nil {
+		log.Fatalf("%s: unable to get balance history", err.Error())
+	}
+
+	if len(history) == 0 {
+		log.Printf(
+			"No balance history found for %s (is balance history enabled?)\n",
+			types.AccountString(account),
+		)
+		return
+	}
+
+	log.Printf("Balance History: %s\n", types.PrettyPrintStruct(history))
+}
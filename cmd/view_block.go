@@ -19,11 +19,9 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"time"
 
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/spf13/cobra"
@@ -55,23 +53,19 @@ func runViewBlockCmd(cmd *cobra.Command, args []string) {
 	}
 
 	// Create a new fetcher
-	newFetcher := fetcher.New(
-		Config.OnlineURL,
-		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
-	)
+	f := newFetcher(Config.OnlineURL, nil)
 
 	// Initialize the fetcher's asserter
 	//
 	// Behind the scenes this makes a call to get the
 	// network status and uses the response to inform
 	// the asserter what are valid responses.
-	_, _, err = newFetcher.InitializeAsserter(ctx)
+	_, _, err = f.InitializeAsserter(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	_, err = utils.CheckNetworkSupported(ctx, Config.Network, newFetcher)
+	_, err = utils.CheckNetworkSupported(ctx, Config.Network, f)
 	if err != nil {
 		log.Fatalf("%s: unable to confirm network is supported", err.Error())
 	}
@@ -85,7 +79,7 @@ func runViewBlockCmd(cmd *cobra.Command, args []string) {
 	// the client directly, you will need to implement a mechanism
 	// to fully populate the block by fetching all these
 	// transactions.
-	block, err := newFetcher.BlockRetry(
+	block, err := f.BlockRetry(
 		ctx,
 		Config.Network,
 		&types.PartialBlockIdentifier{
@@ -100,7 +94,7 @@ func runViewBlockCmd(cmd *cobra.Command, args []string) {
 
 	// Print out all balance changes in a given block. This does NOT exempt
 	// any operations/accounts from parsing.
-	p := parser.New(newFetcher.Asserter, func(*types.Operation) bool { return false })
+	p := parser.New(f.Asserter, func(*types.Operation) bool { return false })
 	changes, err := p.BalanceChanges(ctx, block, false)
 	if err != nil {
 		log.Fatal(fmt.Errorf("%w: unable to calculate balance changes", err))
@@ -17,9 +17,7 @@ package cmd
 import (
 	"context"
 	"log"
-	"time"
 
-	"github.com/coinbase/rosetta-sdk-go/fetcher"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -42,11 +40,7 @@ not formatted correctly.`,
 func runViewNetworkCmd(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 
-	f := fetcher.New(
-		Config.OnlineURL,
-		fetcher.WithRetryElapsedTime(ExtendedRetryElapsedTime),
-		fetcher.WithTimeout(time.Duration(Config.HTTPTimeout)*time.Second),
-	)
+	f := newFetcher(Config.OnlineURL, nil)
 
 	// Attempt to fetch network list
 	networkList, err := f.NetworkListRetry(ctx, nil)
@@ -15,14 +15,17 @@
 package configuration
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"regexp"
 
 	"github.com/coinbase/rosetta-cli/internal/scenario"
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/syncer"
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
@@ -38,6 +41,24 @@ const (
 	UtxoModel AccountingModel = "utxo"
 )
 
+// AddressChecksum is a type representing possible checksum
+// algorithms used to validate addresses.
+type AddressChecksum string
+
+const (
+	// EIP55Checksum is the mixed-case checksum
+	// used by Ethereum addresses.
+	EIP55Checksum AddressChecksum = "eip55"
+
+	// Bech32Checksum is the checksum used by
+	// bech32 addresses (BIP-173).
+	Bech32Checksum AddressChecksum = "bech32"
+)
+
+// RedactedValue replaces each secret value
+// in a redacted *Configuration.
+const RedactedValue = "<redacted>"
+
 // Default Configuration Values
 const (
 	DefaultURL                               = "http://localhost:8080"
@@ -48,6 +69,42 @@ const (
 	DefaultInactiveReconciliationFrequency   = 250
 	DefaultTimeout                           = 10
 
+	// DefaultMaxTimestampSkew is 2 hours (in milliseconds), the
+	// furthest in the future a Bitcoin block timestamp may be.
+	DefaultMaxTimestampSkew = 2 * 60 * 60 * 1000
+
+	// DefaultMempoolPollingFrequency is the number of
+	// seconds between requests to /mempool.
+	DefaultMempoolPollingFrequency = 10
+
+	// DefaultMempoolInclusionWindow is the number of blocks
+	// a transaction that leaves the mempool may take to
+	// appear in a synced block.
+	DefaultMempoolInclusionWindow = 10
+
+	// DefaultMinConcurrency is the lowest block or transaction
+	// concurrency allowed when concurrency is adaptive.
+	DefaultMinConcurrency = 1
+
+	// DefaultMaxConcurrency is the highest block or transaction
+	// concurrency allowed when concurrency is adaptive.
+	DefaultMaxConcurrency = 64
+
+	// DefaultRateLimitBurst is the number of requests
+	// that may be made at once when requests are rate
+	// limited.
+	DefaultRateLimitBurst = 1
+
+	// DefaultLivenessPollingFrequency is the number of
+	// seconds between requests to /network/status when
+	// checking liveness.
+	DefaultLivenessPollingFrequency = 10
+
+	// DefaultStallTimeout is the number of seconds the
+	// network tip may not advance before it is considered
+	// stalled.
+	DefaultStallTimeout = 600
+
 	// ETH Defaults
 	EthereumIDBlockchain    = "Ethereum"
 	EthereumIDNetwork       = "Ropsten"
@@ -168,6 +225,7 @@ func DefaultDataConfiguration() *DataConfiguration {
 		ActiveReconciliationConcurrency:   DefaultActiveReconciliationConcurrency,
 		InactiveReconciliationConcurrency: DefaultInactiveReconciliationConcurrency,
 		InactiveReconciliationFrequency:   DefaultInactiveReconciliationFrequency,
+		MaxTimestampSkew:                  DefaultMaxTimestampSkew,
 	}
 }
 
@@ -194,6 +252,13 @@ type DataConfiguration struct {
 	// default: 16
 	TransactionConcurrency uint64 `json:"transaction_concurrency"`
 
+	// AdaptiveConcurrency is the configuration used to automatically
+	// adjust the block and transaction concurrency while syncing. When
+	// populated, BlockConcurrency and TransactionConcurrency are only
+	// the initial concurrency. When nil, the concurrency is static.
+	// default: nil
+	AdaptiveConcurrency *AdaptiveConcurrencyConfiguration `json:"adaptive_concurrency,omitempty"`
+
 	// ActiveReconciliationConcurrency is the concurrency to use while fetching accounts
 	// during active reconciliation.
 	// default: 8
@@ -225,6 +290,18 @@ type DataConfiguration struct {
 	// default: false
 	LogReconciliations bool `json:"log_reconciliations"`
 
+	// LogReorgs is a boolean indicating whether to log all re-orgs (with their
+	// depth and the removed and replacing blocks).
+	// default: false
+	LogReorgs bool `json:"log_reorgs"`
+
+	// MaxReorgDepth is the deepest re-org (in blocks) that is considered valid.
+	// This should be set to the documented finality of the blockchain. If a
+	// deeper re-org occurs, check:data will fail. When 0, re-orgs of any depth
+	// are allowed.
+	// default: 0
+	MaxReorgDepth int64 `json:"max_reorg_depth"`
+
 	// IgnoreReconciliationError determines if block processing should halt on a reconciliation
 	// error. It can be beneficial to collect all reconciliation errors or silence
 	// reconciliation errors during development.
@@ -237,6 +314,21 @@ type DataConfiguration struct {
 	// default: ""
 	ExemptAccounts string `json:"exempt_accounts"`
 
+	// ExemptionRules are rules matching operations to exempt from balance
+	// tracking and reconciliation (in addition to ExemptAccounts). This is
+	// useful when accounts to exempt cannot be enumerated (like thousands of
+	// staking sub-accounts).
+	// default: []
+	ExemptionRules []*ExemptionRule `json:"exemption_rules,omitempty"`
+
+	// NegativeBalanceAccounts is a path to a file listing all accounts that
+	// are allowed to have a negative balance (like a virtual mint or issuance
+	// account). Unlike exempt accounts, these accounts are still tracked and
+	// reconciled and their balances are reported separately in supply stats.
+	// This file is structured like the exempt accounts file.
+	// default: ""
+	NegativeBalanceAccounts string `json:"negative_balance_accounts,omitempty"`
+
 	// BootstrapBalances is a path to a file used to bootstrap balances
 	// before starting syncing. If this value is populated after beginning syncing,
 	// it will be ignored.
@@ -271,6 +363,271 @@ type DataConfiguration struct {
 	// useful to just try to fetch all blocks before checking for balance
 	// consistency.
 	BalanceTrackingDisabled bool `json:"balance_tracking_disabled"`
+
+	// BalanceHistoryEnabled is a boolean that indicates every balance change
+	// of each account should be stored (in addition to the latest balance).
+	// This history can be queried locally with view:account-history and is
+	// useful for determining how an account reached some balance without
+	// re-syncing.
+	// default: false
+	BalanceHistoryEnabled bool `json:"balance_history_enabled"`
+
+	// PruningDepth is the number of most recent blocks to keep in full in
+	// storage. Older blocks are reduced to their identifiers in the background.
+	// Re-orgs deeper than this depth cannot be handled, so it must be at least
+	// as large as the syncer block cache. The spent coins of pruned blocks
+	// (on UTXO-based blockchains) are also removed. When 0, blocks are
+	// never pruned.
+	// default: 0
+	PruningDepth int64 `json:"pruning_depth"`
+
+	// PruneTransactionHashes is a boolean indicating that the transaction
+	// hashes of pruned blocks should also be removed from storage. This
+	// significantly reduces disk usage but duplicate transactions in pruned
+	// blocks will not be detected. This is ignored if PruningDepth is 0.
+	// default: false
+	PruneTransactionHashes bool `json:"prune_transaction_hashes"`
+
+	// SupplyCheck is the configuration used to check the total supply
+	// (the sum of all computed balances) of currencies while syncing. This
+	// catches inflation bugs even in accounts that are never reconciled. When
+	// nil, supply is not checked.
+	// default: nil
+	SupplyCheck *SupplyCheckConfiguration `json:"supply_check,omitempty"`
+
+	// TimestampDecreaseAllowed is a boolean indicating that the timestamp
+	// of a block may be less than the timestamp of its parent. Some
+	// blockchains (like Bitcoin) only require each timestamp to be
+	// greater than the median of recent timestamps.
+	// default: false
+	TimestampDecreaseAllowed bool `json:"timestamp_decrease_allowed"`
+
+	// MaxTimestampSkew is the number of milliseconds the timestamp of a
+	// block may be ahead of the local clock.
+	// default: 7200000
+	MaxTimestampSkew int64 `json:"max_timestamp_skew"`
+
+	// MinBlockInterval is the minimum number of milliseconds between the
+	// timestamp of a block and the timestamp of its parent. When 0, the
+	// minimum interval is not checked.
+	// default: 0
+	MinBlockInterval int64 `json:"min_block_interval,omitempty"`
+
+	// MaxBlockInterval is the maximum number of milliseconds between the
+	// timestamp of a block and the timestamp of its parent. When 0, the
+	// maximum interval is not checked.
+	// default: 0
+	MaxBlockInterval int64 `json:"max_block_interval,omitempty"`
+
+	// OperationCoverageDepth is the number of blocks after which
+	// any operation type or status returned in /network/options
+	// that has not been seen is logged as a warning. When 0, no
+	// warning is logged.
+	// default: 0
+	OperationCoverageDepth int64 `json:"operation_coverage_depth,omitempty"`
+
+	// PairedOperationTypes are operation types that must always come
+	// in linked pairs (like the debit and credit of a transfer). Each
+	// operation of one of these types must be related (in either
+	// direction) to exactly one other operation of the same type.
+	PairedOperationTypes []string `json:"paired_operation_types,omitempty"`
+
+	// MetadataSchemas is the configuration used to validate block,
+	// transaction, and operation metadata against JSON Schema files.
+	// When nil, metadata is not validated.
+	// default: nil
+	MetadataSchemas *MetadataSchemaConfiguration `json:"metadata_schemas,omitempty"`
+
+	// AddressFormat is the configuration used to validate the format
+	// of every account identifier in processed operations. When nil,
+	// addresses are not validated.
+	// default: nil
+	AddressFormat *AddressFormatConfiguration `json:"address_format,omitempty"`
+
+	// FeeOperationTypes are the operation types used to pay
+	// transaction fees. When populated, the fees paid in each
+	// transaction are written to the fee stream (fees.txt) and
+	// the total fees paid are logged.
+	FeeOperationTypes []string `json:"fee_operation_types,omitempty"`
+
+	// MintBurnOperationTypes are operation types that may create or
+	// destroy funds. They are excluded from the sum of balance changes
+	// in each transaction when ConservationCheckEnabled is true and are
+	// the only operation types allowed to change the total supply of a
+	// currency when SupplyCheck is populated.
+	MintBurnOperationTypes []string `json:"mint_burn_operation_types,omitempty"`
+
+	// ConservationCheckEnabled is a boolean indicating that the balance
+	// changes in each transaction (other than fee and mint/burn
+	// operations) must sum to zero for each currency.
+	// default: false
+	ConservationCheckEnabled bool `json:"conservation_check_enabled"`
+
+	// MempoolCheck is the configuration used to check the responses
+	// of /mempool and /mempool/transaction while syncing. When nil,
+	// the mempool is not checked.
+	// default: nil
+	MempoolCheck *MempoolCheckConfiguration `json:"mempool_check,omitempty"`
+
+	// LivenessCheck is the configuration used to check that the network
+	// tip returned by /network/status keeps advancing while syncing. When
+	// nil, liveness is not checked and sync lag is not logged.
+	// default: nil
+	LivenessCheck *LivenessCheckConfiguration `json:"liveness_check,omitempty"`
+}
+
+// AdaptiveConcurrencyConfiguration is the configuration used
+// to automatically adjust the block and transaction concurrency.
+// The concurrency is decreased when requests fail or their latency
+// increases and is increased when requests are waiting to be made.
+type AdaptiveConcurrencyConfiguration struct {
+	// MinBlockConcurrency is the lowest block concurrency.
+	// default: 1
+	MinBlockConcurrency uint64 `json:"min_block_concurrency"`
+
+	// MaxBlockConcurrency is the highest block concurrency.
+	// default: 64
+	MaxBlockConcurrency uint64 `json:"max_block_concurrency"`
+
+	// MinTransactionConcurrency is the lowest
+	// transaction concurrency.
+	// default: 1
+	MinTransactionConcurrency uint64 `json:"min_transaction_concurrency"`
+
+	// MaxTransactionConcurrency is the highest
+	// transaction concurrency.
+	// default: 64
+	MaxTransactionConcurrency uint64 `json:"max_transaction_concurrency"`
+}
+
+// LivenessCheckConfiguration is the configuration used
+// to check the liveness of the node.
+type LivenessCheckConfiguration struct {
+	// PollingFrequency is the number of seconds
+	// between requests to /network/status.
+	// default: 10
+	PollingFrequency int `json:"polling_frequency"`
+
+	// StallTimeout is the number of seconds the network tip may
+	// not advance before the node is considered stalled.
+	// default: 600
+	StallTimeout int `json:"stall_timeout"`
+
+	// HaltOnFailure is a boolean indicating whether check:data
+	// should fail when the network tip stalls or goes backwards
+	// further than the max reorg depth (or 20 blocks, when the max
+	// reorg depth is 0). Otherwise, a warning is logged.
+	// default: false
+	HaltOnFailure bool `json:"halt_on_failure"`
+}
+
+// MempoolCheckConfiguration is the configuration used
+// to check the mempool.
+type MempoolCheckConfiguration struct {
+	// PollingFrequency is the number of seconds
+	// between requests to /mempool.
+	// default: 10
+	PollingFrequency int `json:"polling_frequency"`
+
+	// InclusionWindow is the number of blocks after the network
+	// tip (when a transaction leaves the mempool) that must be
+	// synced before a transaction that has not appeared in any
+	// synced block is reported as dropped.
+	// default: 10
+	InclusionWindow int64 `json:"inclusion_window"`
+}
+
+// AddressFormatConfiguration describes the format of valid
+// account identifiers on a network.
+type AddressFormatConfiguration struct {
+	// AddressPattern is a regular expression that every
+	// address must match entirely (the pattern is anchored
+	// at both ends).
+	AddressPattern string `json:"address_pattern,omitempty"`
+
+	// Checksum is the checksum algorithm used to validate
+	// every address. When empty, no checksum is validated.
+	// EIP-55 addresses must be checksummed (addresses in a
+	// single case are rejected).
+	Checksum AddressChecksum `json:"checksum,omitempty"`
+
+	// Bech32Prefix is the human-readable part every bech32
+	// address must have (like "bc" or "cosmos"). This is
+	// only used when Checksum is bech32.
+	Bech32Prefix string `json:"bech32_prefix,omitempty"`
+
+	// SubAccountsDisallowed is a boolean indicating that
+	// account identifiers must not have a sub-account.
+	SubAccountsDisallowed bool `json:"sub_accounts_disallowed,omitempty"`
+
+	// SubAccountAddressPattern is a regular expression that every
+	// sub-account address must match entirely (the pattern is
+	// anchored at both ends).
+	SubAccountAddressPattern string `json:"sub_account_address_pattern,omitempty"`
+}
+
+// MetadataSchemaConfiguration maps metadata to the paths of the
+// JSON Schema files used to validate it. Missing metadata is
+// validated as an empty object.
+type MetadataSchemaConfiguration struct {
+	// Block is the path of the JSON Schema file used to
+	// validate the metadata of each block.
+	Block string `json:"block,omitempty"`
+
+	// Transaction is the path of the JSON Schema file used
+	// to validate the metadata of each transaction.
+	Transaction string `json:"transaction,omitempty"`
+
+	// Operations maps operation types to the path of the JSON
+	// Schema file used to validate the metadata of each
+	// operation of that type.
+	Operations map[string]string `json:"operations,omitempty"`
+}
+
+// ExemptionRule matches operations to exempt from balance tracking
+// and reconciliation. An operation matches a rule if it matches all
+// populated fields of the rule. Once an account (and currency) has
+// a successful exempt operation, it is no longer reconciled (even if
+// the rule only matches some of its operations).
+type ExemptionRule struct {
+	// AddressPattern is a regular expression that must match
+	// the entire address of the operation account.
+	AddressPattern string `json:"address_pattern,omitempty"`
+
+	// SubAccountAddressPattern is a regular expression that must
+	// match the entire address of the operation sub-account. Operations
+	// without a sub-account never match.
+	SubAccountAddressPattern string `json:"sub_account_address_pattern,omitempty"`
+
+	// SubAccountMetadata contains values that must all be present
+	// in the metadata of the operation sub-account.
+	SubAccountMetadata map[string]interface{} `json:"sub_account_metadata,omitempty"`
+
+	// CurrencySymbol is the symbol of the operation currency.
+	CurrencySymbol string `json:"currency_symbol,omitempty"`
+
+	// OperationType is the type of the operation.
+	OperationType string `json:"operation_type,omitempty"`
+
+	// OperationStatus is the status of the operation.
+	OperationStatus string `json:"operation_status,omitempty"`
+}
+
+// SupplyCheckConfiguration contains all configurations to check the total
+// supply of currencies during check:data. Supply is only accurate when syncing
+// from genesis (with any genesis allocations provided as bootstrap balances)
+// and without exempt accounts. Any combination of checks can be populated.
+// When MintBurnOperationTypes are populated in the DataConfiguration, the
+// balance changes in all other operations in each block must sum to zero.
+type SupplyCheckConfiguration struct {
+	// ExpectedSupply is the total supply of each listed currency
+	// that must be observed after every block.
+	ExpectedSupply []*types.Amount `json:"expected_supply,omitempty"`
+
+	// ExpectedSupplyFile is a path to a file listing the total supply
+	// of currencies after specific blocks. Look at the examples directory
+	// for an example of how to structure this file.
+	ExpectedSupplyFile string `json:"expected_supply_file,omitempty"`
 }
 
 // Configuration contains all configuration settings for running
@@ -292,10 +649,77 @@ type Configuration struct {
 	// HTTPTimeout is the timeout for HTTP requests in seconds.
 	HTTPTimeout uint64 `json:"http_timeout"`
 
+	// Connection is the configuration used to connect to the
+	// online url (like headers, bearer tokens, and TLS
+	// certificates). It applies to every command that makes
+	// requests to the online url (but not to the reference url
+	// of check:diff). When nil, no headers are added and the
+	// system certificates are used.
+	// default: nil
+	Connection *ConnectionConfiguration `json:"connection,omitempty"`
+
+	// RateLimit is the configuration used to limit the rate of
+	// all requests made to the online url (including balance
+	// lookups during reconciliation). When nil, requests are not
+	// limited and throttled responses are not retried.
+	// default: nil
+	RateLimit *RateLimitConfiguration `json:"rate_limit,omitempty"`
+
 	Construction *ConstructionConfiguration `json:"construction"`
 	Data         *DataConfiguration         `json:"data"`
 }
 
+// ConnectionConfiguration is the configuration used
+// to connect to a node.
+type ConnectionConfiguration struct {
+	// Headers are static HTTP headers added to every
+	// request (like an API key header).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// BearerTokenEnv is the name of an environment variable
+	// containing a token sent in the Authorization header of
+	// every request (as "Bearer <token>").
+	BearerTokenEnv string `json:"bearer_token_env,omitempty"`
+
+	// BearerTokenFile is the path of a file containing a token
+	// sent in the Authorization header of every request (as
+	// "Bearer <token>"). Only one of BearerTokenEnv and
+	// BearerTokenFile may be populated.
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+
+	// CABundle is the path of a PEM file of CA certificates
+	// trusted (in addition to the system certificates) when
+	// verifying the certificate of a node.
+	CABundle string `json:"ca_bundle,omitempty"`
+
+	// ClientCertificate is the path of a PEM client certificate
+	// presented to nodes that require mutual TLS. ClientKey must
+	// also be populated.
+	ClientCertificate string `json:"client_certificate,omitempty"`
+
+	// ClientKey is the path of the PEM private key
+	// of ClientCertificate.
+	ClientKey string `json:"client_key,omitempty"`
+}
+
+// RateLimitConfiguration is the configuration used to limit
+// the rate of requests. When the server responds with 429 Too
+// Many Requests, all requests are paused (for the duration in
+// the Retry-After header or an exponential backoff) and the
+// throttled request is retried.
+type RateLimitConfiguration struct {
+	// RequestsPerSecond is the average number of requests
+	// allowed per second. When 0, the rate of requests is not
+	// limited (but throttled responses are still retried).
+	// default: 0
+	RequestsPerSecond float64 `json:"requests_per_second"`
+
+	// Burst is the number of requests that may be
+	// made at once.
+	// default: 1
+	Burst int `json:"burst"`
+}
+
 func populateConstructionMissingFields(
 	constructionConfig *ConstructionConfiguration,
 ) *ConstructionConfiguration {
@@ -359,6 +783,49 @@ func populateDataMissingFields(dataConfig *DataConfiguration) *DataConfiguration
 		dataConfig.InactiveReconciliationFrequency = DefaultInactiveReconciliationFrequency
 	}
 
+	if dataConfig.MaxTimestampSkew == 0 {
+		dataConfig.MaxTimestampSkew = DefaultMaxTimestampSkew
+	}
+
+	if dataConfig.AdaptiveConcurrency != nil {
+		adaptive := dataConfig.AdaptiveConcurrency
+		if adaptive.MinBlockConcurrency == 0 {
+			adaptive.MinBlockConcurrency = DefaultMinConcurrency
+		}
+
+		if adaptive.MaxBlockConcurrency == 0 {
+			adaptive.MaxBlockConcurrency = DefaultMaxConcurrency
+		}
+
+		if adaptive.MinTransactionConcurrency == 0 {
+			adaptive.MinTransactionConcurrency = DefaultMinConcurrency
+		}
+
+		if adaptive.MaxTransactionConcurrency == 0 {
+			adaptive.MaxTransactionConcurrency = DefaultMaxConcurrency
+		}
+	}
+
+	if dataConfig.MempoolCheck != nil {
+		if dataConfig.MempoolCheck.PollingFrequency == 0 {
+			dataConfig.MempoolCheck.PollingFrequency = DefaultMempoolPollingFrequency
+		}
+
+		if dataConfig.MempoolCheck.InclusionWindow == 0 {
+			dataConfig.MempoolCheck.InclusionWindow = DefaultMempoolInclusionWindow
+		}
+	}
+
+	if dataConfig.LivenessCheck != nil {
+		if dataConfig.LivenessCheck.PollingFrequency == 0 {
+			dataConfig.LivenessCheck.PollingFrequency = DefaultLivenessPollingFrequency
+		}
+
+		if dataConfig.LivenessCheck.StallTimeout == 0 {
+			dataConfig.LivenessCheck.StallTimeout = DefaultStallTimeout
+		}
+	}
+
 	return dataConfig
 }
 
@@ -379,6 +846,10 @@ func populateMissingFields(config *Configuration) *Configuration {
 		config.HTTPTimeout = DefaultTimeout
 	}
 
+	if config.RateLimit != nil && config.RateLimit.Burst == 0 {
+		config.RateLimit.Burst = DefaultRateLimitBurst
+	}
+
 	config.Construction = populateConstructionMissingFields(config.Construction)
 	config.Data = populateDataMissingFields(config.Data)
 
@@ -425,18 +896,313 @@ func assertConstructionConfiguration(config *ConstructionConfiguration) error {
 	return nil
 }
 
+func assertExemptionRule(rule *ExemptionRule) error {
+	if len(rule.AddressPattern) == 0 &&
+		len(rule.SubAccountAddressPattern) == 0 &&
+		len(rule.SubAccountMetadata) == 0 &&
+		len(rule.CurrencySymbol) == 0 &&
+		len(rule.OperationType) == 0 &&
+		len(rule.OperationStatus) == 0 {
+		return errors.New("exemption rule matches all operations")
+	}
+
+	if _, err := regexp.Compile(rule.AddressPattern); err != nil {
+		return fmt.Errorf("%w: invalid address pattern", err)
+	}
+
+	if _, err := regexp.Compile(rule.SubAccountAddressPattern); err != nil {
+		return fmt.Errorf("%w: invalid sub-account address pattern", err)
+	}
+
+	return nil
+}
+
+func assertAddressFormatConfiguration(config *AddressFormatConfiguration) error {
+	if _, err := regexp.Compile(config.AddressPattern); err != nil {
+		return fmt.Errorf("%w: invalid address pattern", err)
+	}
+
+	switch config.Checksum {
+	case "", EIP55Checksum, Bech32Checksum:
+	default:
+		return fmt.Errorf("address checksum %s not supported", config.Checksum)
+	}
+
+	if len(config.Bech32Prefix) > 0 && config.Checksum != Bech32Checksum {
+		return errors.New("bech32 prefix can only be populated with the bech32 checksum")
+	}
+
+	if _, err := regexp.Compile(config.SubAccountAddressPattern); err != nil {
+		return fmt.Errorf("%w: invalid sub-account address pattern", err)
+	}
+
+	if config.SubAccountsDisallowed && len(config.SubAccountAddressPattern) > 0 {
+		return errors.New("sub-account address pattern cannot be populated when sub-accounts are disallowed")
+	}
+
+	return nil
+}
+
+func assertMetadataSchemaConfiguration(config *MetadataSchemaConfiguration) error {
+	if len(config.Block) == 0 &&
+		len(config.Transaction) == 0 &&
+		len(config.Operations) == 0 {
+		return errors.New("no metadata schemas populated")
+	}
+
+	for opType, path := range config.Operations {
+		if len(opType) == 0 {
+			return errors.New("operation type must not be empty")
+		}
+
+		if len(path) == 0 {
+			return fmt.Errorf("schema path for operation type %s must not be empty", opType)
+		}
+	}
+
+	return nil
+}
+
+func assertSupplyCheckConfiguration(
+	config *SupplyCheckConfiguration,
+	mintBurnOperationTypes []string,
+) error {
+	if len(config.ExpectedSupply) == 0 &&
+		len(config.ExpectedSupplyFile) == 0 &&
+		len(mintBurnOperationTypes) == 0 {
+		return errors.New("no supply checks populated")
+	}
+
+	for _, amount := range config.ExpectedSupply {
+		if err := asserter.Amount(amount); err != nil {
+			return fmt.Errorf("%w: invalid expected supply", err)
+		}
+
+		if err := checkStringUint(amount.Value); err != nil {
+			return fmt.Errorf("%w: invalid expected supply", err)
+		}
+	}
+
+	return nil
+}
+
+func assertConnectionConfiguration(config *ConnectionConfiguration) error {
+	for key := range config.Headers {
+		if len(key) == 0 {
+			return errors.New("header name must not be empty")
+		}
+	}
+
+	if len(config.BearerTokenEnv) > 0 && len(config.BearerTokenFile) > 0 {
+		return errors.New("bearer token env and bearer token file must not both be populated")
+	}
+
+	if (len(config.ClientCertificate) > 0) != (len(config.ClientKey) > 0) {
+		return errors.New("client certificate and client key must be populated together")
+	}
+
+	return nil
+}
+
+func assertDataConfiguration(config *DataConfiguration) error {
+	if config.MaxReorgDepth < 0 {
+		return fmt.Errorf("max reorg depth %d must not be negative", config.MaxReorgDepth)
+	}
+
+	if config.PruningDepth < 0 {
+		return fmt.Errorf("pruning depth %d must not be negative", config.PruningDepth)
+	}
+
+	if config.PruningDepth > 0 && config.PruningDepth < syncer.PastBlockSize {
+		return fmt.Errorf(
+			"pruning depth %d must be at least %d",
+			config.PruningDepth,
+			syncer.PastBlockSize,
+		)
+	}
+
+	if config.AdaptiveConcurrency != nil {
+		adaptive := config.AdaptiveConcurrency
+		if adaptive.MinBlockConcurrency > adaptive.MaxBlockConcurrency {
+			return fmt.Errorf(
+				"min block concurrency %d must not be greater than max block concurrency %d",
+				adaptive.MinBlockConcurrency,
+				adaptive.MaxBlockConcurrency,
+			)
+		}
+
+		if adaptive.MinTransactionConcurrency > adaptive.MaxTransactionConcurrency {
+			return fmt.Errorf(
+				"min transaction concurrency %d must not be greater than max transaction concurrency %d",
+				adaptive.MinTransactionConcurrency,
+				adaptive.MaxTransactionConcurrency,
+			)
+		}
+	}
+
+	if config.MaxTimestampSkew < 0 {
+		return fmt.Errorf("max timestamp skew %d must not be negative", config.MaxTimestampSkew)
+	}
+
+	if config.MinBlockInterval < 0 {
+		return fmt.Errorf("min block interval %d must not be negative", config.MinBlockInterval)
+	}
+
+	if config.MaxBlockInterval < 0 {
+		return fmt.Errorf("max block interval %d must not be negative", config.MaxBlockInterval)
+	}
+
+	if config.MaxBlockInterval > 0 && config.MaxBlockInterval < config.MinBlockInterval {
+		return fmt.Errorf(
+			"max block interval %d must not be less than min block interval %d",
+			config.MaxBlockInterval,
+			config.MinBlockInterval,
+		)
+	}
+
+	if config.OperationCoverageDepth < 0 {
+		return fmt.Errorf(
+			"operation coverage depth %d must not be negative",
+			config.OperationCoverageDepth,
+		)
+	}
+
+	for _, opType := range config.PairedOperationTypes {
+		if len(opType) == 0 {
+			return errors.New("paired operation type must not be empty")
+		}
+	}
+
+	if config.AddressFormat != nil {
+		if err := assertAddressFormatConfiguration(config.AddressFormat); err != nil {
+			return fmt.Errorf("%w: invalid address format", err)
+		}
+	}
+
+	for _, opType := range config.FeeOperationTypes {
+		if len(opType) == 0 {
+			return errors.New("fee operation type must not be empty")
+		}
+	}
+
+	if config.MempoolCheck != nil {
+		if config.MempoolCheck.PollingFrequency < 0 {
+			return fmt.Errorf(
+				"mempool polling frequency %d must not be negative",
+				config.MempoolCheck.PollingFrequency,
+			)
+		}
+
+		if config.MempoolCheck.InclusionWindow < 0 {
+			return fmt.Errorf(
+				"mempool inclusion window %d must not be negative",
+				config.MempoolCheck.InclusionWindow,
+			)
+		}
+	}
+
+	if config.LivenessCheck != nil {
+		if config.LivenessCheck.PollingFrequency < 0 {
+			return fmt.Errorf(
+				"liveness polling frequency %d must not be negative",
+				config.LivenessCheck.PollingFrequency,
+			)
+		}
+
+		if config.LivenessCheck.StallTimeout < 0 {
+			return fmt.Errorf(
+				"stall timeout %d must not be negative",
+				config.LivenessCheck.StallTimeout,
+			)
+		}
+	}
+
+	if config.MetadataSchemas != nil {
+		if err := assertMetadataSchemaConfiguration(config.MetadataSchemas); err != nil {
+			return fmt.Errorf("%w: invalid metadata schemas", err)
+		}
+	}
+
+	for _, rule := range config.ExemptionRules {
+		if err := assertExemptionRule(rule); err != nil {
+			return fmt.Errorf("%w: invalid exemption rule", err)
+		}
+	}
+
+	if config.SupplyCheck != nil {
+		if config.BalanceTrackingDisabled {
+			return errors.New("supply cannot be checked when balance tracking is disabled")
+		}
+
+		err := assertSupplyCheckConfiguration(
+			config.SupplyCheck,
+			config.MintBurnOperationTypes,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: invalid supply check configuration", err)
+		}
+	}
+
+	return nil
+}
+
 func assertConfiguration(config *Configuration) error {
 	if err := asserter.NetworkIdentifier(config.Network); err != nil {
 		return fmt.Errorf("%w: invalid network identifier", err)
 	}
 
+	if config.Connection != nil {
+		if err := assertConnectionConfiguration(config.Connection); err != nil {
+			return fmt.Errorf("%w: invalid connection configuration", err)
+		}
+	}
+
+	if config.RateLimit != nil {
+		if config.RateLimit.RequestsPerSecond < 0 {
+			return fmt.Errorf(
+				"requests per second %f must not be negative",
+				config.RateLimit.RequestsPerSecond,
+			)
+		}
+
+		if config.RateLimit.Burst < 0 {
+			return fmt.Errorf("burst %d must not be negative", config.RateLimit.Burst)
+		}
+	}
+
 	if err := assertConstructionConfiguration(config.Construction); err != nil {
 		return fmt.Errorf("%w: invalid construction configuration", err)
 	}
 
+	if err := assertDataConfiguration(config.Data); err != nil {
+		return fmt.Errorf("%w: invalid data configuration", err)
+	}
+
 	return nil
 }
 
+// Redacted returns a copy of the *Configuration with
+// all secret values (like the values of connection headers)
+// replaced with RedactedValue, so that it can be written to
+// disk or shared. Paths and environment variable names
+// (like BearerTokenFile) are not secret.
+func (c *Configuration) Redacted() *Configuration {
+	redacted := *c
+	if c.Connection != nil {
+		connection := *c.Connection
+		if c.Connection.Headers != nil {
+			connection.Headers = make(map[string]string, len(c.Connection.Headers))
+			for key := range c.Connection.Headers {
+				connection.Headers[key] = RedactedValue
+			}
+		}
+
+		redacted.Connection = &connection
+	}
+
+	return &redacted
+}
+
 // LoadConfiguration returns a parsed and asserted Configuration for running
 // tests.
 func LoadConfiguration(filePath string) (*Configuration, error) {
@@ -33,6 +33,19 @@ var (
 		},
 		OnlineURL:   "http://hasudhasjkdk",
 		HTTPTimeout: 21,
+		Connection: &ConnectionConfiguration{
+			Headers: map[string]string{
+				"X-Api-Key": "key",
+			},
+			BearerTokenEnv:    "NODE_TOKEN",
+			CABundle:          "ca.pem",
+			ClientCertificate: "client.pem",
+			ClientKey:         "client-key.pem",
+		},
+		RateLimit: &RateLimitConfiguration{
+			RequestsPerSecond: 2.5,
+			Burst:             5,
+		},
 		Construction: &ConstructionConfiguration{
 			OfflineURL: "https://ashdjaksdkjshdk",
 			Currency: &types.Currency{
@@ -53,6 +66,59 @@ var (
 			InactiveReconciliationFrequency:   3,
 			ReconciliationDisabled:            true,
 			HistoricalBalanceDisabled:         true,
+			TimestampDecreaseAllowed:          true,
+			MaxTimestampSkew:                  1000,
+			MinBlockInterval:                  10,
+			MaxBlockInterval:                  100000,
+			OperationCoverageDepth:            1000,
+			PairedOperationTypes:              []string{"transfer"},
+			AddressFormat: &AddressFormatConfiguration{
+				AddressPattern:           "^0x",
+				Checksum:                 EIP55Checksum,
+				SubAccountAddressPattern: "^stake$",
+			},
+			FeeOperationTypes: []string{"fee"},
+			AdaptiveConcurrency: &AdaptiveConcurrencyConfiguration{
+				MinBlockConcurrency:       2,
+				MaxBlockConcurrency:       32,
+				MinTransactionConcurrency: 4,
+				MaxTransactionConcurrency: 128,
+			},
+			MempoolCheck: &MempoolCheckConfiguration{
+				PollingFrequency: 5,
+				InclusionWindow:  100,
+			},
+			LivenessCheck: &LivenessCheckConfiguration{
+				PollingFrequency: 5,
+				StallTimeout:     120,
+				HaltOnFailure:    true,
+			},
+			MintBurnOperationTypes:   []string{"mint", "burn"},
+			ConservationCheckEnabled: true,
+			MetadataSchemas: &MetadataSchemaConfiguration{
+				Block: "block_schema.json",
+				Operations: map[string]string{
+					"transfer": "transfer_schema.json",
+				},
+			},
+			ExemptionRules: []*ExemptionRule{
+				{
+					SubAccountAddressPattern: "^stake-",
+					CurrencySymbol:           "FIRE",
+				},
+				{
+					OperationType:   "reward",
+					OperationStatus: "success",
+				},
+			},
+			SupplyCheck: &SupplyCheckConfiguration{
+				ExpectedSupply: []*types.Amount{
+					{
+						Value:    "100",
+						Currency: EthereumCurrency,
+					},
+				},
+			},
 		},
 	}
 	invalidNetwork = &Configuration{
@@ -60,6 +126,22 @@ var (
 			Blockchain: "?",
 		},
 	}
+	multipleBearerTokens = &Configuration{
+		Connection: &ConnectionConfiguration{
+			BearerTokenEnv:  "NODE_TOKEN",
+			BearerTokenFile: "token.txt",
+		},
+	}
+	missingClientKey = &Configuration{
+		Connection: &ConnectionConfiguration{
+			ClientCertificate: "client.pem",
+		},
+	}
+	invalidRateLimit = &Configuration{
+		RateLimit: &RateLimitConfiguration{
+			RequestsPerSecond: -1,
+		},
+	}
 	invalidCurrency = &Configuration{
 		Construction: &ConstructionConfiguration{
 			Currency: &types.Currency{
@@ -87,6 +169,128 @@ var (
 			MaximumFee: "hello",
 		},
 	}
+	invalidPruningDepth = &Configuration{
+		Data: &DataConfiguration{
+			PruningDepth: 2,
+		},
+	}
+	invalidBlockInterval = &Configuration{
+		Data: &DataConfiguration{
+			MinBlockInterval: 1000,
+			MaxBlockInterval: 10,
+		},
+	}
+	invalidOperationCoverageDepth = &Configuration{
+		Data: &DataConfiguration{
+			OperationCoverageDepth: -1,
+		},
+	}
+	emptyPairedOperationType = &Configuration{
+		Data: &DataConfiguration{
+			PairedOperationTypes: []string{""},
+		},
+	}
+	invalidAddressChecksum = &Configuration{
+		Data: &DataConfiguration{
+			AddressFormat: &AddressFormatConfiguration{
+				Checksum: "sha256",
+			},
+		},
+	}
+	invalidBech32Prefix = &Configuration{
+		Data: &DataConfiguration{
+			AddressFormat: &AddressFormatConfiguration{
+				Checksum:     EIP55Checksum,
+				Bech32Prefix: "bc",
+			},
+		},
+	}
+	invalidSubAccountFormat = &Configuration{
+		Data: &DataConfiguration{
+			AddressFormat: &AddressFormatConfiguration{
+				SubAccountsDisallowed:    true,
+				SubAccountAddressPattern: "^stake$",
+			},
+		},
+	}
+	emptyFeeOperationType = &Configuration{
+		Data: &DataConfiguration{
+			FeeOperationTypes: []string{""},
+		},
+	}
+	invalidAdaptiveConcurrency = &Configuration{
+		Data: &DataConfiguration{
+			AdaptiveConcurrency: &AdaptiveConcurrencyConfiguration{
+				MinBlockConcurrency: 100,
+			},
+		},
+	}
+	invalidMempoolCheck = &Configuration{
+		Data: &DataConfiguration{
+			MempoolCheck: &MempoolCheckConfiguration{
+				InclusionWindow: -1,
+			},
+		},
+	}
+	invalidLivenessCheck = &Configuration{
+		Data: &DataConfiguration{
+			LivenessCheck: &LivenessCheckConfiguration{
+				StallTimeout: -1,
+			},
+		},
+	}
+	emptyMetadataSchemas = &Configuration{
+		Data: &DataConfiguration{
+			MetadataSchemas: &MetadataSchemaConfiguration{},
+		},
+	}
+	emptyOperationSchema = &Configuration{
+		Data: &DataConfiguration{
+			MetadataSchemas: &MetadataSchemaConfiguration{
+				Operations: map[string]string{
+					"transfer": "",
+				},
+			},
+		},
+	}
+	emptyExemptionRule = &Configuration{
+		Data: &DataConfiguration{
+			ExemptionRules: []*ExemptionRule{{}},
+		},
+	}
+	invalidExemptionPattern = &Configuration{
+		Data: &DataConfiguration{
+			ExemptionRules: []*ExemptionRule{
+				{
+					AddressPattern: "stake(",
+				},
+			},
+		},
+	}
+	emptySupplyCheck = &Configuration{
+		Data: &DataConfiguration{
+			SupplyCheck: &SupplyCheckConfiguration{},
+		},
+	}
+	invalidExpectedSupply = &Configuration{
+		Data: &DataConfiguration{
+			SupplyCheck: &SupplyCheckConfiguration{
+				ExpectedSupply: []*types.Amount{
+					{
+						Value:    "-100",
+						Currency: EthereumCurrency,
+					},
+				},
+			},
+		},
+	}
+	supplyCheckWithoutBalances = &Configuration{
+		Data: &DataConfiguration{
+			BalanceTrackingDisabled: true,
+			MintBurnOperationTypes:  []string{"mint"},
+			SupplyCheck:             &SupplyCheckConfiguration{},
+		},
+	}
 )
 
 func TestLoadConfiguration(t *testing.T) {
@@ -108,6 +312,18 @@ func TestLoadConfiguration(t *testing.T) {
 			provided: invalidNetwork,
 			err:      true,
 		},
+		"multiple bearer tokens": {
+			provided: multipleBearerTokens,
+			err:      true,
+		},
+		"missing client key": {
+			provided: missingClientKey,
+			err:      true,
+		},
+		"invalid rate limit": {
+			provided: invalidRateLimit,
+			err:      true,
+		},
 		"invalid currency": {
 			provided: invalidCurrency,
 			err:      true,
@@ -128,6 +344,78 @@ func TestLoadConfiguration(t *testing.T) {
 			provided: invalidMaximumFee,
 			err:      true,
 		},
+		"invalid pruning depth": {
+			provided: invalidPruningDepth,
+			err:      true,
+		},
+		"invalid block interval": {
+			provided: invalidBlockInterval,
+			err:      true,
+		},
+		"invalid operation coverage depth": {
+			provided: invalidOperationCoverageDepth,
+			err:      true,
+		},
+		"empty paired operation type": {
+			provided: emptyPairedOperationType,
+			err:      true,
+		},
+		"invalid address checksum": {
+			provided: invalidAddressChecksum,
+			err:      true,
+		},
+		"invalid bech32 prefix": {
+			provided: invalidBech32Prefix,
+			err:      true,
+		},
+		"invalid sub-account format": {
+			provided: invalidSubAccountFormat,
+			err:      true,
+		},
+		"empty fee operation type": {
+			provided: emptyFeeOperationType,
+			err:      true,
+		},
+		"invalid adaptive concurrency": {
+			provided: invalidAdaptiveConcurrency,
+			err:      true,
+		},
+		"invalid mempool check": {
+			provided: invalidMempoolCheck,
+			err:      true,
+		},
+		"invalid liveness check": {
+			provided: invalidLivenessCheck,
+			err:      true,
+		},
+		"empty metadata schemas": {
+			provided: emptyMetadataSchemas,
+			err:      true,
+		},
+		"empty operation schema": {
+			provided: emptyOperationSchema,
+			err:      true,
+		},
+		"empty exemption rule": {
+			provided: emptyExemptionRule,
+			err:      true,
+		},
+		"invalid exemption pattern": {
+			provided: invalidExemptionPattern,
+			err:      true,
+		},
+		"empty supply check": {
+			provided: emptySupplyCheck,
+			err:      true,
+		},
+		"invalid expected supply": {
+			provided: invalidExpectedSupply,
+			err:      true,
+		},
+		"supply check without balance tracking": {
+			provided: supplyCheckWithoutBalances,
+			err:      true,
+		},
 	}
 
 	for name, test := range tests {
@@ -153,3 +441,20 @@ func TestLoadConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestRedacted(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Connection = &ConnectionConfiguration{
+		Headers:        map[string]string{"x-api-key": "secret"},
+		BearerTokenEnv: "NODE_TOKEN",
+	}
+
+	redacted := config.Redacted()
+	assert.Equal(t, map[string]string{"x-api-key": RedactedValue}, redacted.Connection.Headers)
+	assert.Equal(t, "NODE_TOKEN", redacted.Connection.BearerTokenEnv)
+	assert.Equal(t, config.Data, redacted.Data)
+
+	// The original configuration is not modified.
+	assert.Equal(t, "secret", config.Connection.Headers["x-api-key"])
+	assert.Nil(t, DefaultConfiguration().Redacted().Connection)
+}
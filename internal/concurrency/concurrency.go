@@ -0,0 +1,254 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxErrorRate is the fraction of failed requests in a
+	// window above which the limit is halved.
+	maxErrorRate = 0.1
+
+	// latencyTolerance is how many times the baseline latency
+	// the average latency of a window may be before the limit
+	// is decreased.
+	latencyTolerance = 2
+
+	// baselineWeight is the weight of the average latency of a
+	// window when it is higher than the baseline latency. The
+	// baseline immediately drops to a lower average latency but
+	// only slowly rises to a higher one, so that it follows
+	// lasting changes in latency (like a node under more load)
+	// without following short latency spikes.
+	baselineWeight = 0.1
+)
+
+// Limit is a concurrency limit that is adjusted after each
+// window of requests (as many requests as the current limit).
+// The limit is halved when too many requests fail, decreased
+// when the average latency rises too far above the baseline
+// latency (a decaying minimum of the average latency of each
+// window), and increased when requests had to wait for a slot
+// (so the limit does not grow when callers, like a syncer
+// committing blocks to storage, are the bottleneck).
+type Limit struct {
+	name    string
+	min     uint64
+	max     uint64
+	current uint64
+
+	mutex    sync.Mutex
+	inFlight uint64
+	released chan struct{}
+
+	requests uint64
+	failures uint64
+	latency  time.Duration
+	waited   bool
+	baseline time.Duration
+}
+
+// NewLimit returns a new *Limit that starts at initial
+// (bounded by min and max). The limit is always at least 1.
+func NewLimit(name string, initial uint64, min uint64, max uint64) *Limit {
+	if min == 0 {
+		min = 1
+	}
+
+	if initial < min {
+		initial = min
+	}
+
+	if initial > max {
+		initial = max
+	}
+
+	return &Limit{
+		name:     name,
+		min:      min,
+		max:      max,
+		current:  initial,
+		released: make(chan struct{}),
+	}
+}
+
+// Name returns the name of the Limit.
+func (l *Limit) Name() string {
+	return l.name
+}
+
+// Bounds returns the minimum and maximum
+// value of the Limit.
+func (l *Limit) Bounds() (uint64, uint64) {
+	return l.min, l.max
+}
+
+// Current returns the current value of the Limit.
+func (l *Limit) Current() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.current
+}
+
+// Acquire blocks until fewer requests than the current
+// limit are in flight or the context is canceled.
+func (l *Limit) Acquire(ctx context.Context) error {
+	for {
+		l.mutex.Lock()
+		if l.inFlight < l.current {
+			l.inFlight++
+			l.mutex.Unlock()
+			return nil
+		}
+
+		l.waited = true
+		released := l.released
+		l.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// Release records the latency and result of a request
+// acquired with Acquire and adjusts the limit at the end
+// of each window.
+func (l *Limit) Release(latency time.Duration, failed bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.inFlight--
+	l.requests++
+	l.latency += latency
+	if failed {
+		l.failures++
+	}
+
+	if l.requests >= l.current {
+		l.adjust()
+	}
+
+	// Wake all waiting requests (the
+	// limit may have increased).
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// adjust updates the limit using the stats of the
+// current window and starts a new window.
+func (l *Limit) adjust() {
+	avgLatency := l.latency / time.Duration(l.requests)
+	tooManyFailures := float64(l.failures) > maxErrorRate*float64(l.requests)
+
+	switch {
+	case tooManyFailures:
+		l.current /= 2
+	case l.baseline > 0 && avgLatency > latencyTolerance*l.baseline:
+		l.current--
+	case l.waited:
+		l.current++
+	}
+
+	// The latency of a window with too many failures is
+	// not representative (failed requests may return
+	// early or time out), so it does not update the
+	// baseline.
+	if !tooManyFailures {
+		switch {
+		case l.baseline == 0 || avgLatency < l.baseline:
+			l.baseline = avgLatency
+		default:
+			l.baseline += time.Duration(baselineWeight * float64(avgLatency-l.baseline))
+		}
+	}
+
+	if l.current < l.min {
+		l.current = l.min
+	}
+
+	if l.current > l.max {
+		l.current = l.max
+	}
+
+	l.requests = 0
+	l.failures = 0
+	l.latency = 0
+	l.waited = false
+}
+
+// Transport is an http.RoundTripper that limits the number
+// of concurrent requests to each endpoint with a Limit.
+type Transport struct {
+	transport http.RoundTripper
+	limits    map[string]*Limit
+}
+
+// NewTransport returns a new *Transport that limits requests
+// to each endpoint (the suffix of the request path) in limits.
+// Requests to other endpoints are not limited. If transport is
+// nil, http.DefaultTransport is used to make requests.
+func NewTransport(limits map[string]*Limit, transport http.RoundTripper) *Transport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &Transport{
+		transport: transport,
+		limits:    limits,
+	}
+}
+
+// RoundTrip waits for a slot in the Limit of the request
+// endpoint (if any) and makes the request using the
+// underlying transport. Requests that fail, return a server
+// error, or are throttled are recorded as failures.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var limit *Limit
+	for endpoint, endpointLimit := range t.limits {
+		if strings.HasSuffix(req.URL.Path, endpoint) {
+			limit = endpointLimit
+			break
+		}
+	}
+
+	if limit == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	if err := limit.Acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	limit.Release(
+		time.Since(start),
+		err != nil ||
+			resp.StatusCode >= http.StatusInternalServerError ||
+			resp.StatusCode == http.StatusTooManyRequests,
+	)
+
+	return resp, err
+}
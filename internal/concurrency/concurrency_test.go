@@ -0,0 +1,234 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/connection"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// window releases a full window of requests with
+// the provided latency and number of failures.
+func window(t *testing.T, l *Limit, latency time.Duration, failures uint64, waited bool) {
+	requests := l.Current()
+	for i := uint64(0); i < requests; i++ {
+		assert.NoError(t, l.Acquire(context.Background()))
+	}
+
+	l.mutex.Lock()
+	l.waited = waited
+	l.mutex.Unlock()
+
+	for i := uint64(0); i < requests; i++ {
+		l.Release(latency, i < failures)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	t.Run("bounds", func(t *testing.T) {
+		l := NewLimit("Blocks", 100, 0, 10)
+		assert.Equal(t, "Blocks", l.Name())
+		assert.Equal(t, uint64(10), l.Current())
+
+		min, max := l.Bounds()
+		assert.Equal(t, uint64(1), min)
+		assert.Equal(t, uint64(10), max)
+	})
+
+	t.Run("increase when waiting", func(t *testing.T) {
+		l := NewLimit("Blocks", 4, 1, 5)
+		window(t, l, time.Millisecond, 0, false)
+		assert.Equal(t, uint64(4), l.Current())
+
+		window(t, l, time.Millisecond, 0, true)
+		assert.Equal(t, uint64(5), l.Current())
+
+		window(t, l, time.Millisecond, 0, true)
+		assert.Equal(t, uint64(5), l.Current())
+	})
+
+	t.Run("decrease when latency increases", func(t *testing.T) {
+		l := NewLimit("Blocks", 4, 1, 8)
+		window(t, l, 10*time.Millisecond, 0, true)
+		assert.Equal(t, uint64(5), l.Current())
+
+		window(t, l, 15*time.Millisecond, 0, true)
+		assert.Equal(t, uint64(6), l.Current())
+
+		window(t, l, 30*time.Millisecond, 0, true)
+		assert.Equal(t, uint64(5), l.Current())
+	})
+
+	t.Run("baseline follows lasting latency increase", func(t *testing.T) {
+		l := NewLimit("Blocks", 8, 1, 8)
+		window(t, l, 10*time.Millisecond, 0, true)
+		assert.Equal(t, uint64(8), l.Current())
+
+		// The limit decreases until the baseline
+		// rises enough to include the new latency
+		// and then increases again.
+		for _, expected := range []uint64{7, 6, 5, 6, 7} {
+			window(t, l, 30*time.Millisecond, 0, true)
+			assert.Equal(t, expected, l.Current())
+		}
+	})
+
+	t.Run("baseline drops to lower latency", func(t *testing.T) {
+		l := NewLimit("Blocks", 4, 1, 8)
+		window(t, l, 30*time.Millisecond, 0, true)
+		window(t, l, 10*time.Millisecond, 0, true)
+		assert.Equal(t, 10*time.Millisecond, l.baseline)
+	})
+
+	t.Run("baseline with few failures", func(t *testing.T) {
+		// Windows with failures (but not too many) set
+		// the baseline, so the limit is not decreased
+		// because there is no baseline.
+		l := NewLimit("Blocks", 10, 1, 20)
+		window(t, l, 10*time.Millisecond, 1, true)
+		assert.Equal(t, uint64(11), l.Current())
+		assert.Equal(t, 10*time.Millisecond, l.baseline)
+
+		window(t, l, 10*time.Millisecond, 1, true)
+		assert.Equal(t, uint64(12), l.Current())
+	})
+
+	t.Run("halve on failures", func(t *testing.T) {
+		l := NewLimit("Transactions", 8, 3, 8)
+		window(t, l, time.Millisecond, 1, true)
+		assert.Equal(t, uint64(4), l.Current())
+
+		window(t, l, time.Millisecond, 1, true)
+		assert.Equal(t, uint64(3), l.Current())
+	})
+
+	t.Run("wait for slot", func(t *testing.T) {
+		l := NewLimit("Blocks", 1, 1, 1)
+		assert.NoError(t, l.Acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, l.Acquire(ctx))
+
+		acquired := make(chan struct{})
+		go func() {
+			assert.NoError(t, l.Acquire(context.Background()))
+			close(acquired)
+		}()
+
+		l.Release(time.Millisecond, false)
+		<-acquired
+	})
+}
+
+func TestTransport(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+
+		if r.URL.Path == "/network/status" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	blockLimit := NewLimit("Blocks", 2, 1, 2)
+	client := &http.Client{Transport: NewTransport(
+		map[string]*Limit{"/block": blockLimit},
+		nil,
+	)}
+
+	post := func(endpoint string) {
+		resp, err := client.Post(server.URL+endpoint, "application/json", bytes.NewBufferString("{}"))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	t.Run("limit endpoint", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				post("/block")
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, maxInFlight)
+		assert.Equal(t, uint64(2), blockLimit.Current())
+	})
+
+	t.Run("other endpoints are not limited", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			post("/network/status")
+		}
+
+		assert.Equal(t, uint64(2), blockLimit.Current())
+	})
+}
+
+func TestTransportTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// Time spent waiting for a slot is not counted
+	// against the timeout of each request.
+	client := &http.Client{Transport: NewTransport(
+		map[string]*Limit{"/block": NewLimit("Blocks", 1, 1, 1)},
+		connection.NewTimeoutTransport(100*time.Millisecond, nil),
+	)}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Post(server.URL+"/block", "application/json", bytes.NewBufferString("{}"))
+			assert.NoError(t, err)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+)
+
+var (
+	// ErrBearerTokenMissing is returned when the environment
+	// variable or file containing the bearer token is empty.
+	ErrBearerTokenMissing = errors.New("bearer token is empty")
+
+	// ErrCABundleInvalid is returned when no certificates
+	// could be parsed from the CA bundle.
+	ErrCABundleInvalid = errors.New("no certificates found in CA bundle")
+)
+
+// Transport is an http.RoundTripper that adds the configured
+// headers (and bearer token) to every request and makes
+// requests with the configured TLS settings.
+type Transport struct {
+	transport http.RoundTripper
+	headers   http.Header
+}
+
+// NewTransport returns a new *Transport for a
+// *configuration.ConnectionConfiguration. The bearer
+// token, CA bundle, and client certificate are loaded
+// when the *Transport is created.
+func NewTransport(config *configuration.ConnectionConfiguration) (*Transport, error) {
+	headers := http.Header{}
+	for key, value := range config.Headers {
+		headers.Set(key, value)
+	}
+
+	token, err := loadBearerToken(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load bearer token", err)
+	}
+
+	if len(token) > 0 {
+		headers.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	tlsConfig, err := loadTLSConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load TLS configuration", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Transport{
+		transport: transport,
+		headers:   headers,
+	}, nil
+}
+
+// RoundTrip adds the configured headers to a copy of
+// the request and makes it with the configured TLS
+// settings.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+// loadBearerToken returns the bearer token in the configured
+// environment variable or file (or an empty string if no
+// bearer token is configured).
+func loadBearerToken(config *configuration.ConnectionConfiguration) (string, error) {
+	var token string
+	switch {
+	case len(config.BearerTokenEnv) > 0:
+		token = os.Getenv(config.BearerTokenEnv)
+		if len(token) == 0 {
+			return "", fmt.Errorf(
+				"%w: environment variable %s is not set",
+				ErrBearerTokenMissing,
+				config.BearerTokenEnv,
+			)
+		}
+	case len(config.BearerTokenFile) > 0:
+		contents, err := ioutil.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("%w: unable to read %s", err, config.BearerTokenFile)
+		}
+
+		token = strings.TrimSpace(string(contents))
+		if len(token) == 0 {
+			return "", fmt.Errorf(
+				"%w: %s is empty",
+				ErrBearerTokenMissing,
+				config.BearerTokenFile,
+			)
+		}
+	}
+
+	return token, nil
+}
+
+// loadTLSConfig returns the *tls.Config with the configured
+// CA bundle (added to the system certificates) and client
+// certificate (if any).
+func loadTLSConfig(config *configuration.ConnectionConfiguration) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if len(config.CABundle) > 0 {
+		bundle, err := ioutil.ReadFile(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read %s", err, config.CABundle)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("%w: %s", ErrCABundleInvalid, config.CABundle)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.ClientCertificate) > 0 {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertificate, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to load client certificate %s and key %s",
+				err,
+				config.ClientCertificate,
+				config.ClientKey,
+			)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeClientCertificate writes a self-signed client
+// certificate and its key to dir.
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rosetta-cli"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certificatePath := path.Join(dir, "client.pem")
+	keyPath := path.Join(dir, "client-key.pem")
+	assert.NoError(t, ioutil.WriteFile(
+		certificatePath,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+		0600,
+	))
+	assert.NoError(t, ioutil.WriteFile(
+		keyPath,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey}),
+		0600,
+	))
+
+	return certificatePath, keyPath
+}
+
+func TestTransport(t *testing.T) {
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(
+			w,
+			"%s|%s|%d",
+			r.Header.Get("X-Api-Key"),
+			r.Header.Get("Authorization"),
+			len(r.TLS.PeerCertificates),
+		)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caBundle := path.Join(newDir, "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(
+		caBundle,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		0600,
+	))
+
+	tokenFile := path.Join(newDir, "token.txt")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+
+	clientCertificate, clientKey := writeClientCertificate(t, newDir)
+
+	os.Setenv("ROSETTA_CLI_TEST_TOKEN", "env-token")
+	defer os.Unsetenv("ROSETTA_CLI_TEST_TOKEN")
+
+	var tests = map[string]struct {
+		config *configuration.ConnectionConfiguration
+
+		response string
+		err      error
+	}{
+		"untrusted server": {
+			config: &configuration.ConnectionConfiguration{},
+		},
+		"headers and token from env": {
+			config: &configuration.ConnectionConfiguration{
+				Headers:        map[string]string{"x-api-key": "key"},
+				BearerTokenEnv: "ROSETTA_CLI_TEST_TOKEN",
+				CABundle:       caBundle,
+			},
+			response: "key|Bearer env-token|0",
+		},
+		"token from file": {
+			config: &configuration.ConnectionConfiguration{
+				BearerTokenFile: tokenFile,
+				CABundle:        caBundle,
+			},
+			response: "|Bearer file-token|0",
+		},
+		"client certificate": {
+			config: &configuration.ConnectionConfiguration{
+				CABundle:          caBundle,
+				ClientCertificate: clientCertificate,
+				ClientKey:         clientKey,
+			},
+			response: "||1",
+		},
+		"missing token": {
+			config: &configuration.ConnectionConfiguration{
+				BearerTokenEnv: "ROSETTA_CLI_TEST_MISSING_TOKEN",
+			},
+			err: ErrBearerTokenMissing,
+		},
+		"invalid CA bundle": {
+			config: &configuration.ConnectionConfiguration{
+				CABundle: tokenFile,
+			},
+			err: ErrCABundleInvalid,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := NewTransport(test.config)
+			if test.err != nil {
+				assert.Nil(t, transport)
+				assert.True(t, errors.Is(err, test.err))
+				return
+			}
+			assert.NoError(t, err)
+
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(server.URL)
+			if len(test.response) == 0 {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, test.response, string(body))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// TimeoutTransport is an http.RoundTripper that limits how long
+// each request made with the underlying transport (including
+// reading the response body) may take. Unlike http.Client.Timeout,
+// time spent waiting in transports wrapping the TimeoutTransport
+// (like a rate limit or concurrency limit) is not counted against
+// the timeout, and each retried attempt gets the full timeout.
+type TimeoutTransport struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+// NewTimeoutTransport returns a new *TimeoutTransport. If
+// transport is nil, http.DefaultTransport is used to make
+// requests.
+func NewTimeoutTransport(timeout time.Duration, transport http.RoundTripper) *TimeoutTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &TimeoutTransport{
+		transport: transport,
+		timeout:   timeout,
+	}
+}
+
+// RoundTrip makes the request with a deadline. The deadline
+// is released when the response body is closed.
+func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody is an io.ReadCloser that cancels
+// the context of a request when it is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the response body and
+// cancels the context of the request.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTimeoutTransport(100*time.Millisecond, nil)}
+
+	t.Run("request within timeout", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/fast")
+		assert.NoError(t, err)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("request exceeds timeout", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/slow")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Difference is a value at some path (like
+// transactions[0].operations[1].amount.value) that
+// is not equal in two objects. If the value does not
+// exist in one of the objects, it is omitted.
+type Difference struct {
+	Path     string      `json:"path"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// Compare returns all differences between the JSON
+// representations of expected and actual. Arrays are
+// compared element by element.
+func Compare(expected interface{}, actual interface{}) ([]*Difference, error) {
+	normalizedExpected, err := normalize(expected)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to normalize expected value", err)
+	}
+
+	normalizedActual, err := normalize(actual)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to normalize actual value", err)
+	}
+
+	return compare("", normalizedExpected, normalizedActual), nil
+}
+
+// normalize converts a value to the generic
+// types used by encoding/json.
+func normalize(i interface{}) (interface{}, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+func compare(path string, expected interface{}, actual interface{}) []*Difference {
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	if expectedIsMap && actualIsMap {
+		return compareMaps(path, expectedMap, actualMap)
+	}
+
+	expectedSlice, expectedIsSlice := expected.([]interface{})
+	actualSlice, actualIsSlice := actual.([]interface{})
+	if expectedIsSlice && actualIsSlice {
+		return compareSlices(path, expectedSlice, actualSlice)
+	}
+
+	if reflect.DeepEqual(expected, actual) {
+		return nil
+	}
+
+	return []*Difference{
+		{
+			Path:     path,
+			Expected: expected,
+			Actual:   actual,
+		},
+	}
+}
+
+func compareMaps(
+	path string,
+	expected map[string]interface{},
+	actual map[string]interface{},
+) []*Difference {
+	keys := []string{}
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	differences := []*Difference{}
+	for _, key := range keys {
+		differences = append(
+			differences,
+			compare(joinPath(path, key), expected[key], actual[key])...,
+		)
+	}
+
+	return differences
+}
+
+func compareSlices(
+	path string,
+	expected []interface{},
+	actual []interface{},
+) []*Difference {
+	differences := []*Difference{}
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		var expectedValue, actualValue interface{}
+		if i < len(expected) {
+			expectedValue = expected[i]
+		}
+		if i < len(actual) {
+			actualValue = actual[i]
+		}
+
+		differences = append(
+			differences,
+			compare(fmt.Sprintf("%s[%d]", path, i), expectedValue, actualValue)...,
+		)
+	}
+
+	return differences
+}
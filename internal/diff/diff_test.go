@@ -0,0 +1,145 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBlock() *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 1",
+			Index: 1,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+		Timestamp: 1590000000000,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: "tx 1",
+				},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{
+							Index: 0,
+						},
+						Type:   "TRANSFER",
+						Status: "SUCCESS",
+						Account: &types.AccountIdentifier{
+							Address: "addr1",
+						},
+						Amount: &types.Amount{
+							Value: "100",
+							Currency: &types.Currency{
+								Symbol:   "BTC",
+								Decimals: 8,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var tests = map[string]struct {
+		modify func(*types.Block)
+
+		expected []*Difference
+	}{
+		"equal": {
+			modify:   func(b *types.Block) {},
+			expected: []*Difference{},
+		},
+		"different timestamp": {
+			modify: func(b *types.Block) {
+				b.Timestamp++
+			},
+			expected: []*Difference{
+				{
+					Path:     "timestamp",
+					Expected: float64(1590000000000),
+					Actual:   float64(1590000000001),
+				},
+			},
+		},
+		"different operation amount": {
+			modify: func(b *types.Block) {
+				b.Transactions[0].Operations[0].Amount.Value = "200"
+			},
+			expected: []*Difference{
+				{
+					Path:     "transactions[0].operations[0].amount.value",
+					Expected: "100",
+					Actual:   "200",
+				},
+			},
+		},
+		"extra operation": {
+			modify: func(b *types.Block) {
+				b.Transactions[0].Operations = append(
+					b.Transactions[0].Operations,
+					&types.Operation{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                "FEE",
+					},
+				)
+			},
+			expected: []*Difference{
+				{
+					Path: "transactions[0].operations[1]",
+					Actual: map[string]interface{}{
+						"operation_identifier": map[string]interface{}{
+							"index": float64(1),
+						},
+						"type":   "FEE",
+						"status": "",
+					},
+				},
+			},
+		},
+		"different status": {
+			modify: func(b *types.Block) {
+				b.Transactions[0].Operations[0].Status = ""
+			},
+			expected: []*Difference{
+				{
+					Path:     "transactions[0].operations[0].status",
+					Expected: "SUCCESS",
+					Actual:   "",
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := testBlock()
+			test.modify(actual)
+
+			differences, err := Compare(testBlock(), actual)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, differences)
+		})
+	}
+}
@@ -15,13 +15,19 @@
 package logger
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"os"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/coinbase/rosetta-cli/internal/concurrency"
 	"github.com/coinbase/rosetta-cli/internal/storage"
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
@@ -49,6 +55,14 @@ const (
 	reconcileSuccessStreamFile = "successful_reconciliations.txt"
 	reconcileFailureStreamFile = "failure_reconciliations.txt"
 
+	// orphanStreamFile contains the stream of processed
+	// re-orgs.
+	orphanStreamFile = "orphans.txt"
+
+	// feeStreamFile contains the stream of fees
+	// paid in processed blocks.
+	feeStreamFile = "fees.txt"
+
 	// addEvent is printed in a stream
 	// when an event is added.
 	addEvent = "Add"
@@ -56,6 +70,11 @@ const (
 	// removeEvent is printed in a stream
 	// when an event is orphaned.
 	removeEvent = "Remove"
+
+	// maxStreamLineLength is the maximum number of bytes
+	// of a stream line returned by StreamLines (longer
+	// lines are truncated).
+	maxStreamLineLength = 1024 * 1024
 )
 
 // Logger contains all logic to record validator output
@@ -66,8 +85,23 @@ type Logger struct {
 	logTransactions   bool
 	logBalanceChanges bool
 	logReconciliation bool
+	logReorgs         bool
+
+	operationTypes         []string
+	operationStatuses      []string
+	operationCoverageDepth int64
 
-	lastStatsMessage string
+	lastStatsMessage          string
+	lastOperationStatsMessage string
+	lastCoverageMessage       string
+	lastFeeStatsMessage       string
+	lastMempoolStatsMessage   string
+	lastConcurrencyMessage    string
+	lastSupplyStatsMessage    string
+	lastSyncStatsMessage      string
+
+	feeStorage        *storage.FeeStorage
+	concurrencyLimits []*concurrency.Limit
 
 	// CounterStorage is some initialized CounterStorage.
 	CounterStorage *storage.CounterStorage
@@ -81,6 +115,7 @@ func NewLogger(
 	logTransactions bool,
 	logBalanceChanges bool,
 	logReconciliation bool,
+	logReorgs bool,
 ) *Logger {
 	return &Logger{
 		CounterStorage:    counterStorage,
@@ -89,9 +124,38 @@ func NewLogger(
 		logTransactions:   logTransactions,
 		logBalanceChanges: logBalanceChanges,
 		logReconciliation: logReconciliation,
+		logReorgs:         logReorgs,
 	}
 }
 
+// TrackOperations configures the Logger to print a breakdown
+// of processed operations by type and status (usually those
+// returned in /network/options). If coverageDepth is positive,
+// any type or status not seen after coverageDepth blocks is
+// logged as a warning.
+func (l *Logger) TrackOperations(
+	operationTypes []string,
+	operationStatuses []string,
+	coverageDepth int64,
+) {
+	l.operationTypes = operationTypes
+	l.operationStatuses = operationStatuses
+	l.operationCoverageDepth = coverageDepth
+}
+
+// TrackFees configures the Logger to print the total
+// fees paid in each currency (stored in feeStorage).
+func (l *Logger) TrackFees(feeStorage *storage.FeeStorage) {
+	l.feeStorage = feeStorage
+}
+
+// TrackConcurrency configures the Logger to print the
+// current value and bounds of each adaptive concurrency
+// limit.
+func (l *Logger) TrackConcurrency(limits []*concurrency.Limit) {
+	l.concurrencyLimits = limits
+}
+
 // LogDataStats logs all data values in CounterStorage.
 func (l *Logger) LogDataStats(ctx context.Context) error {
 	blocks, err := l.CounterStorage.Get(ctx, storage.BlockCounter)
@@ -108,6 +172,16 @@ func (l *Logger) LogDataStats(ctx context.Context) error {
 		return fmt.Errorf("%w cannot get orphan counter", err)
 	}
 
+	reorgs, err := l.CounterStorage.Get(ctx, storage.ReorgCounter)
+	if err != nil {
+		return fmt.Errorf("%w cannot get reorg counter", err)
+	}
+
+	maxReorgDepth, err := l.CounterStorage.Get(ctx, storage.MaxReorgDepthCounter)
+	if err != nil {
+		return fmt.Errorf("%w cannot get max reorg depth counter", err)
+	}
+
 	txs, err := l.CounterStorage.Get(ctx, storage.TransactionCounter)
 	if err != nil {
 		return fmt.Errorf("%w cannot get transaction counter", err)
@@ -129,9 +203,11 @@ func (l *Logger) LogDataStats(ctx context.Context) error {
 	}
 
 	statsMessage := fmt.Sprintf(
-		"[STATS] Blocks: %s (Orphaned: %s) Transactions: %s Operations: %s Reconciliations: %s (Inactive: %s)",
+		"[STATS] Blocks: %s (Orphaned: %s Reorgs: %s Max Reorg Depth: %s) Transactions: %s Operations: %s Reconciliations: %s (Inactive: %s)",
 		blocks.String(),
 		orphans.String(),
+		reorgs.String(),
+		maxReorgDepth.String(),
 		txs.String(),
 		ops.String(),
 		new(big.Int).Add(activeReconciliations, inactiveReconciliations).String(),
@@ -146,6 +222,284 @@ func (l *Logger) LogDataStats(ctx context.Context) error {
 	l.lastStatsMessage = statsMessage
 	color.Cyan(statsMessage)
 
+	if err := l.logOperationStats(ctx, blocks); err != nil {
+		return err
+	}
+
+	if err := l.logFeeStats(ctx); err != nil {
+		return err
+	}
+
+	if err := l.logMempoolStats(ctx); err != nil {
+		return err
+	}
+
+	l.logConcurrencyStats()
+	return nil
+}
+
+// logConcurrencyStats logs the current value and bounds
+// of each adaptive concurrency limit (if any).
+func (l *Logger) logConcurrencyStats() {
+	if len(l.concurrencyLimits) == 0 {
+		return
+	}
+
+	limitStrings := make([]string, len(l.concurrencyLimits))
+	for i, limit := range l.concurrencyLimits {
+		min, max := limit.Bounds()
+		limitStrings[i] = fmt.Sprintf(
+			"%s: %d (Limits: %d-%d)",
+			limit.Name(),
+			limit.Current(),
+			min,
+			max,
+		)
+	}
+
+	statsMessage := fmt.Sprintf("[STATS] Concurrency: %s", strings.Join(limitStrings, ", "))
+
+	// Don't print out the same stats message twice.
+	if statsMessage == l.lastConcurrencyMessage {
+		return
+	}
+
+	l.lastConcurrencyMessage = statsMessage
+	color.Cyan(statsMessage)
+}
+
+// logMempoolStats logs the number of checked mempool
+// transactions (if the mempool is checked).
+func (l *Logger) logMempoolStats(ctx context.Context) error {
+	mempoolTxs, err := l.CounterStorage.Get(ctx, storage.MempoolTransactionCounter)
+	if err != nil {
+		return fmt.Errorf("%w cannot get mempool transactions counter", err)
+	}
+
+	if mempoolTxs.Sign() == 0 {
+		return nil
+	}
+
+	droppedTxs, err := l.CounterStorage.Get(ctx, storage.DroppedTransactionCounter)
+	if err != nil {
+		return fmt.Errorf("%w cannot get dropped transactions counter", err)
+	}
+
+	statsMessage := fmt.Sprintf(
+		"[STATS] Mempool Transactions: %s (Dropped: %s)",
+		mempoolTxs.String(),
+		droppedTxs.String(),
+	)
+
+	// Don't print out the same stats message twice.
+	if statsMessage == l.lastMempoolStatsMessage {
+		return nil
+	}
+
+	l.lastMempoolStatsMessage = statsMessage
+	color.Cyan(statsMessage)
+
+	return nil
+}
+
+// logFeeStats logs the total fees paid in each currency.
+func (l *Logger) logFeeStats(ctx context.Context) error {
+	if l.feeStorage == nil {
+		return nil
+	}
+
+	fees, err := l.feeStorage.GetAllFees(ctx)
+	if err != nil {
+		return fmt.Errorf("%w cannot get fees", err)
+	}
+
+	if len(fees) == 0 {
+		return nil
+	}
+
+	statsMessage := fmt.Sprintf("[STATS] Fees: %s", amountsString(fees))
+
+	// Don't print out the same stats message twice.
+	if statsMessage == l.lastFeeStatsMessage {
+		return nil
+	}
+
+	l.lastFeeStatsMessage = statsMessage
+	color.Cyan(statsMessage)
+
+	return nil
+}
+
+// amountsString returns a comma-separated string
+// of amounts (or 0 if there are none).
+func amountsString(amounts []*types.Amount) string {
+	if len(amounts) == 0 {
+		return "0"
+	}
+
+	amountStrings := make([]string, len(amounts))
+	for i, amount := range amounts {
+		amountStrings[i] = fmt.Sprintf("%s%s", amount.Value, amount.Currency.Symbol)
+	}
+
+	return strings.Join(amountStrings, ", ")
+}
+
+// countOperations returns a string of the count of each
+// provided key, the sum of all counts, and the keys that
+// have a count of 0.
+func (l *Logger) countOperations(
+	ctx context.Context,
+	keys []string,
+	counter func(string) string,
+) (string, *big.Int, []string, error) {
+	counts := make([]string, len(keys))
+	total := big.NewInt(0)
+	unseen := []string{}
+	for i, key := range keys {
+		count, err := l.CounterStorage.Get(ctx, counter(key))
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("%w cannot get %s counter", err, counter(key))
+		}
+
+		counts[i] = fmt.Sprintf("%s: %s", key, count.String())
+		total.Add(total, count)
+		if count.Sign() == 0 {
+			unseen = append(unseen, key)
+		}
+	}
+
+	return strings.Join(counts, ", "), total, unseen, nil
+}
+
+// logOperationStats logs the breakdown of operations in canonical
+// blocks by type, status, and whether they change balances.
+func (l *Logger) logOperationStats(ctx context.Context, blocks *big.Int) error {
+	balanceChanging, err := l.CounterStorage.Get(ctx, storage.BalanceChangingOperationCounter)
+	if err != nil {
+		return fmt.Errorf("%w cannot get balance changing operations counter", err)
+	}
+
+	typeCounts, _, unseenTypes, err := l.countOperations(
+		ctx,
+		l.operationTypes,
+		storage.OperationTypeCounter,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Every operation has one of the tracked statuses, so
+	// the sum of their counts is the number of operations
+	// in canonical blocks (unlike OperationCounter, which
+	// includes orphaned operations).
+	statusCounts, canonicalOps, unseenStatuses, err := l.countOperations(
+		ctx,
+		l.operationStatuses,
+		storage.OperationStatusCounter,
+	)
+	if err != nil {
+		return err
+	}
+
+	statsMessage := fmt.Sprintf(
+		"[STATS] Operation Types: [%s] Operation Statuses: [%s] Balance Changing: %s (Other: %s)",
+		typeCounts,
+		statusCounts,
+		balanceChanging.String(),
+		new(big.Int).Sub(canonicalOps, balanceChanging).String(),
+	)
+
+	// Don't print out the same stats message twice.
+	if statsMessage != l.lastOperationStatsMessage {
+		l.lastOperationStatsMessage = statsMessage
+		color.Cyan(statsMessage)
+	}
+
+	if l.operationCoverageDepth <= 0 || blocks.Int64() < l.operationCoverageDepth {
+		return nil
+	}
+
+	if len(unseenTypes) == 0 && len(unseenStatuses) == 0 {
+		return nil
+	}
+
+	coverageMessage := fmt.Sprintf(
+		"Operation types and statuses not seen after %d blocks: Types: [%s] Statuses: [%s]",
+		l.operationCoverageDepth,
+		strings.Join(unseenTypes, ", "),
+		strings.Join(unseenStatuses, ", "),
+	)
+
+	// Only warn again if the unseen types or statuses change.
+	if coverageMessage != l.lastCoverageMessage {
+		l.lastCoverageMessage = coverageMessage
+		color.Yellow(coverageMessage)
+	}
+
+	return nil
+}
+
+// LogSupplyStats logs the total supply of each currency (and the
+// balances of accounts allowed to go negative, if any).
+func (l *Logger) LogSupplyStats(ctx context.Context, supply []*storage.Supply) error {
+	if len(supply) == 0 {
+		return nil
+	}
+
+	supplyStrings := make([]string, len(supply))
+	for i, s := range supply {
+		supplyStrings[i] = fmt.Sprintf("%s%s", s.Value, s.Currency.Symbol)
+		if s.NegativeBalanceValue != "0" {
+			supplyStrings[i] = fmt.Sprintf(
+				"%s (Negative Balance Accounts: %s%s)",
+				supplyStrings[i],
+				s.NegativeBalanceValue,
+				s.Currency.Symbol,
+			)
+		}
+	}
+
+	statsMessage := fmt.Sprintf("[STATS] Supply: %s", strings.Join(supplyStrings, ", "))
+
+	// Don't print out the same stats message twice.
+	if statsMessage == l.lastSupplyStatsMessage {
+		return nil
+	}
+
+	l.lastSupplyStatsMessage = statsMessage
+	color.Cyan(statsMessage)
+
+	return nil
+}
+
+// LogSyncStats logs how far the head of the synced chain
+// is behind the network tip.
+func (l *Logger) LogSyncStats(
+	ctx context.Context,
+	head *types.BlockIdentifier,
+	tip *types.BlockIdentifier,
+) error {
+	lag := tip.Index - head.Index
+	if lag < 0 {
+		lag = 0
+	}
+
+	statsMessage := fmt.Sprintf(
+		"[STATS] Sync Lag: %d blocks (Head: %d Tip: %d)",
+		lag,
+		head.Index,
+		tip.Index,
+	)
+
+	// Don't print out the same stats message twice.
+	if statsMessage == l.lastSyncStatsMessage {
+		return nil
+	}
+
+	l.lastSyncStatsMessage = statsMessage
+	color.Cyan(statsMessage)
+
 	return nil
 }
 
@@ -219,6 +573,48 @@ func (l *Logger) RemoveBlockStream(
 	return nil
 }
 
+// ReorgStream writes a completed re-org to the end of the
+// orphanStreamFile output file.
+func (l *Logger) ReorgStream(
+	ctx context.Context,
+	removed []*types.BlockIdentifier,
+	replacement *types.BlockIdentifier,
+) error {
+	if !l.logReorgs {
+		return nil
+	}
+
+	f, err := os.OpenFile(
+		path.Join(l.logDir, orphanStreamFile),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer closeFile(f)
+
+	removedBlocks := make([]string, len(removed))
+	for i, block := range removed {
+		removedBlocks[i] = fmt.Sprintf("%d:%s", block.Index, block.Hash)
+	}
+
+	_, err = f.WriteString(fmt.Sprintf(
+		"Reorg Depth: %d Removed: %s Replaced By: %d:%s Time: %s\n",
+		len(removed),
+		strings.Join(removedBlocks, ","),
+		replacement.Index,
+		replacement.Hash,
+		time.Now().Format(time.RFC3339),
+	))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // TransactionStream writes the next processed block's transactions
 // to the end of the transactionStreamFile.
 func (l *Logger) TransactionStream(
@@ -287,6 +683,74 @@ func (l *Logger) TransactionStream(
 	return nil
 }
 
+// FeeStream writes the fees paid in a block (and in each of its
+// transactions) to the end of the feeStreamFile.
+func (l *Logger) FeeStream(
+	ctx context.Context,
+	block *types.BlockIdentifier,
+	fees []*storage.TransactionFee,
+	removed bool,
+) error {
+	f, err := os.OpenFile(
+		path.Join(l.logDir, feeStreamFile),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		os.FileMode(utils.DefaultFilePermissions),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer closeFile(f)
+
+	event := addEvent
+	if removed {
+		event = removeEvent
+	}
+
+	blockFees := map[string]*types.Amount{}
+	blockFeeList := []*types.Amount{}
+	for _, txFee := range fees {
+		for _, fee := range txFee.Fees {
+			key := types.Hash(fee.Currency)
+			total, ok := blockFees[key]
+			if !ok {
+				total = &types.Amount{Value: "0", Currency: fee.Currency}
+				blockFees[key] = total
+				blockFeeList = append(blockFeeList, total)
+			}
+
+			total.Value, err = types.AddValues(total.Value, fee.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = f.WriteString(fmt.Sprintf(
+		"%s Block %d:%s Fees: %s\n",
+		event,
+		block.Index,
+		block.Hash,
+		amountsString(blockFeeList),
+	))
+	if err != nil {
+		return err
+	}
+
+	for _, txFee := range fees {
+		_, err = f.WriteString(fmt.Sprintf(
+			"Transaction %s Fees: %s\n",
+			txFee.TransactionIdentifier.Hash,
+			amountsString(txFee.Fees),
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // BalanceStream writes a slice of storage.BalanceChanges
 // to the balanceStreamFile.
 func (l *Logger) BalanceStream(
@@ -438,6 +902,93 @@ func (l *Logger) ReconcileFailureStream(
 	return nil
 }
 
+// StreamLines returns all lines in each stream file that
+// contain at least one of the provided filters, keyed by the
+// name of the stream file. Stream files that have not been
+// created are skipped and lines longer than maxStreamLineLength
+// are truncated before they are filtered.
+func (l *Logger) StreamLines(filters []string) (map[string][]string, error) {
+	streams := []string{
+		blockStreamFile,
+		transactionStreamFile,
+		balanceStreamFile,
+		reconcileSuccessStreamFile,
+		reconcileFailureStreamFile,
+		orphanStreamFile,
+	}
+
+	matches := map[string][]string{}
+	for _, stream := range streams {
+		f, err := os.Open(path.Join(l.logDir, stream))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to open %s", err, stream)
+		}
+
+		lines := []string{}
+		reader := bufio.NewReader(f)
+		var readErr error
+		for {
+			line, err := readStreamLine(reader)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				readErr = err
+				break
+			}
+
+			for _, filter := range filters {
+				if len(filter) > 0 && strings.Contains(line, filter) {
+					lines = append(lines, line)
+					break
+				}
+			}
+		}
+
+		closeFile(f)
+		if readErr != nil {
+			return nil, fmt.Errorf("%w: unable to read %s", readErr, stream)
+		}
+
+		if len(lines) > 0 {
+			matches[stream] = lines
+		}
+	}
+
+	return matches, nil
+}
+
+// readStreamLine returns the next line of a stream
+// (truncated to maxStreamLineLength bytes) or io.EOF
+// if there are no more lines.
+func readStreamLine(reader *bufio.Reader) (string, error) {
+	line := []byte{}
+	for {
+		fragment, isPrefix, err := reader.ReadLine()
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if remaining := maxStreamLineLength - len(line); remaining > 0 {
+			if len(fragment) > remaining {
+				fragment = fragment[:remaining]
+			}
+
+			line = append(line, fragment...)
+		}
+
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
 // Helper function to close log file
 func closeFile(f *os.File) {
 	err := f.Close()
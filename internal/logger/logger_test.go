@@ -0,0 +1,142 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogOperationStats(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := storage.NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	counterStorage := storage.NewCounterStorage(database)
+	l := NewLogger(counterStorage, newDir, false, false, false, false, false)
+	l.TrackOperations([]string{"Transfer", "Fee"}, []string{"Success", "Failure"}, 10)
+
+	assert.NoError(t, counterStorage.UpdateBatch(ctx, map[string]*big.Int{
+		storage.OperationCounter:                  big.NewInt(5),
+		storage.BalanceChangingOperationCounter:   big.NewInt(1),
+		storage.OperationTypeCounter("Transfer"):  big.NewInt(2),
+		storage.OperationStatusCounter("Success"): big.NewInt(2),
+	}))
+
+	t.Run("before coverage depth", func(t *testing.T) {
+		assert.NoError(t, l.logOperationStats(ctx, big.NewInt(9)))
+		assert.Equal(
+			t,
+			"[STATS] Operation Types: [Transfer: 2, Fee: 0] Operation Statuses: [Success: 2, Failure: 0] Balance Changing: 1 (Other: 1)",
+			l.lastOperationStatsMessage,
+		)
+		assert.Equal(t, "", l.lastCoverageMessage)
+	})
+
+	t.Run("unseen types and statuses", func(t *testing.T) {
+		assert.NoError(t, l.logOperationStats(ctx, big.NewInt(10)))
+		assert.Equal(
+			t,
+			"Operation types and statuses not seen after 10 blocks: Types: [Fee] Statuses: [Failure]",
+			l.lastCoverageMessage,
+		)
+	})
+
+	t.Run("unseen status", func(t *testing.T) {
+		_, err := counterStorage.Update(ctx, storage.OperationTypeCounter("Fee"), big.NewInt(1))
+		assert.NoError(t, err)
+
+		assert.NoError(t, l.logOperationStats(ctx, big.NewInt(11)))
+		assert.Equal(
+			t,
+			"Operation types and statuses not seen after 10 blocks: Types: [] Statuses: [Failure]",
+			l.lastCoverageMessage,
+		)
+	})
+
+	t.Run("all seen", func(t *testing.T) {
+		_, err := counterStorage.Update(ctx, storage.OperationStatusCounter("Failure"), big.NewInt(1))
+		assert.NoError(t, err)
+
+		// The last warning is kept when every type
+		// and status has been seen.
+		assert.NoError(t, l.logOperationStats(ctx, big.NewInt(12)))
+		assert.Equal(
+			t,
+			"Operation types and statuses not seen after 10 blocks: Types: [] Statuses: [Failure]",
+			l.lastCoverageMessage,
+		)
+		assert.Equal(
+			t,
+			"[STATS] Operation Types: [Transfer: 2, Fee: 1] Operation Statuses: [Success: 2, Failure: 1] Balance Changing: 1 (Other: 2)",
+			l.lastOperationStatsMessage,
+		)
+	})
+
+	t.Run("coverage disabled", func(t *testing.T) {
+		disabled := NewLogger(counterStorage, newDir, false, false, false, false, false)
+		disabled.TrackOperations([]string{"Transfer", "Reward"}, []string{"Success"}, 0)
+
+		assert.NoError(t, disabled.logOperationStats(ctx, big.NewInt(100)))
+		assert.Equal(t, "", disabled.lastCoverageMessage)
+	})
+}
+
+func TestStreamLines(t *testing.T) {
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	// Lines longer than maxStreamLineLength are truncated
+	// instead of failing the whole read.
+	longLine := "Add addr1 " + strings.Repeat("a", maxStreamLineLength)
+	balances := strings.Join([]string{
+		"Add addr1 100",
+		longLine,
+		"Add addr2 50",
+		"Remove addr1 100",
+	}, "\n")
+	assert.NoError(t, ioutil.WriteFile(
+		path.Join(newDir, balanceStreamFile),
+		[]byte(balances),
+		utils.DefaultFilePermissions,
+	))
+
+	l := NewLogger(nil, newDir, false, false, false, false, false)
+	lines, err := l.StreamLines([]string{"addr1", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		balanceStreamFile: {
+			"Add addr1 100",
+			longLine[:maxStreamLineLength],
+			"Remove addr1 100",
+		},
+	}, lines)
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mockserver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var (
+	// DefaultOperationStatuses are the *types.OperationStatus
+	// supported by the mock server if none are provided
+	// in the fixture.
+	DefaultOperationStatuses = []*types.OperationStatus{
+		{
+			Status:     "SUCCESS",
+			Successful: true,
+		},
+		{
+			Status:     "FAILURE",
+			Successful: false,
+		},
+	}
+)
+
+// Reorg causes the blocks in [Index, Index+Depth) to be
+// served with different hashes until the chain tip
+// reaches Index+Depth. Orphaned blocks contain the same
+// transactions as the blocks that replace them.
+type Reorg struct {
+	Index int64 `json:"index"`
+	Depth int64 `json:"depth"`
+}
+
+// MissingOperation is an operation that is omitted from
+// /block and /block/transaction responses but is still
+// applied to balances returned by /account/balance.
+type MissingOperation struct {
+	BlockIndex      int64  `json:"block_index"`
+	TransactionHash string `json:"transaction_hash"`
+	OperationIndex  int64  `json:"operation_index"`
+}
+
+// BalanceDiscrepancy is an amount that is added to the balance
+// of an account returned by /account/balance at all blocks
+// with an index >= Index.
+type BalanceDiscrepancy struct {
+	Account  *types.AccountIdentifier `json:"account_identifier"`
+	Currency *types.Currency          `json:"currency"`
+	Index    int64                    `json:"index"`
+	Amount   string                   `json:"amount"`
+}
+
+// Fixture is the scripted chain served by a *Server.
+type Fixture struct {
+	Network *types.NetworkIdentifier `json:"network"`
+
+	// OperationStatuses are the statuses returned
+	// in /network/options.
+	//
+	// default: DefaultOperationStatuses
+	OperationStatuses []*types.OperationStatus `json:"operation_statuses,omitempty"`
+
+	// Blocks are the blocks of the canonical chain,
+	// starting at genesis.
+	Blocks []*types.Block `json:"blocks"`
+
+	Reorgs               []*Reorg              `json:"reorgs,omitempty"`
+	MissingOperations    []*MissingOperation   `json:"missing_operations,omitempty"`
+	BalanceDiscrepancies []*BalanceDiscrepancy `json:"balance_discrepancies,omitempty"`
+}
+
+// LoadFixture loads and validates a *Fixture
+// from a JSON file.
+func LoadFixture(filePath string) (*Fixture, error) {
+	var fixture Fixture
+	if err := utils.LoadAndParse(filePath, &fixture); err != nil {
+		return nil, fmt.Errorf("%w: unable to load fixture", err)
+	}
+
+	if err := fixture.validate(); err != nil {
+		return nil, fmt.Errorf("%w: invalid fixture", err)
+	}
+
+	return &fixture, nil
+}
+
+// validate ensures that all blocks in the
+// *Fixture form a single chain and that all
+// faults refer to blocks in that chain.
+func (f *Fixture) validate() error {
+	if err := asserter.NetworkIdentifier(f.Network); err != nil {
+		return err
+	}
+
+	if len(f.Blocks) == 0 {
+		return errors.New("no blocks provided")
+	}
+
+	if len(f.OperationStatuses) == 0 {
+		f.OperationStatuses = DefaultOperationStatuses
+	}
+
+	genesisIndex := f.Blocks[0].BlockIdentifier.Index
+	for i, block := range f.Blocks {
+		if err := asserter.BlockIdentifier(block.BlockIdentifier); err != nil {
+			return fmt.Errorf("%w: invalid block %d", err, i)
+		}
+
+		if block.BlockIdentifier.Index != genesisIndex+int64(i) {
+			return fmt.Errorf(
+				"block %s has index %d but expected %d",
+				block.BlockIdentifier.Hash,
+				block.BlockIdentifier.Index,
+				genesisIndex+int64(i),
+			)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		if types.Hash(block.ParentBlockIdentifier) != types.Hash(f.Blocks[i-1].BlockIdentifier) {
+			return fmt.Errorf(
+				"parent of block %d is not block %d",
+				block.BlockIdentifier.Index,
+				f.Blocks[i-1].BlockIdentifier.Index,
+			)
+		}
+	}
+
+	tipIndex := f.Blocks[len(f.Blocks)-1].BlockIdentifier.Index
+	reorged := map[int64]struct{}{}
+	for _, reorg := range f.Reorgs {
+		if reorg.Depth <= 0 {
+			return fmt.Errorf("reorg at %d must have a positive depth", reorg.Index)
+		}
+
+		// The genesis block cannot be orphaned and the
+		// orphaned blocks must eventually be replaced.
+		if reorg.Index <= genesisIndex || reorg.Index+reorg.Depth > tipIndex {
+			return fmt.Errorf(
+				"reorg at %d with depth %d must be in (%d, %d]",
+				reorg.Index,
+				reorg.Depth,
+				genesisIndex,
+				tipIndex-reorg.Depth,
+			)
+		}
+
+		// Orphaned blocks must be replaced before
+		// another reorg starts.
+		for i := reorg.Index; i <= reorg.Index+reorg.Depth; i++ {
+			if _, ok := reorged[i]; ok {
+				return fmt.Errorf("reorg at %d overlaps another reorg", reorg.Index)
+			}
+			reorged[i] = struct{}{}
+		}
+	}
+
+	for _, missing := range f.MissingOperations {
+		block, ok := f.block(missing.BlockIndex)
+		if !ok {
+			return fmt.Errorf("missing operation block %d not found", missing.BlockIndex)
+		}
+
+		if _, ok := findOperation(block, missing); !ok {
+			return fmt.Errorf(
+				"missing operation %d in transaction %s not found",
+				missing.OperationIndex,
+				missing.TransactionHash,
+			)
+		}
+	}
+
+	for _, discrepancy := range f.BalanceDiscrepancies {
+		if err := asserter.AccountIdentifier(discrepancy.Account); err != nil {
+			return fmt.Errorf("%w: invalid balance discrepancy account", err)
+		}
+
+		if err := asserter.Amount(&types.Amount{
+			Value:    discrepancy.Amount,
+			Currency: discrepancy.Currency,
+		}); err != nil {
+			return fmt.Errorf("%w: invalid balance discrepancy amount", err)
+		}
+	}
+
+	return nil
+}
+
+// block returns the canonical block at a given index.
+func (f *Fixture) block(index int64) (*types.Block, bool) {
+	i := index - f.Blocks[0].BlockIdentifier.Index
+	if i < 0 || i >= int64(len(f.Blocks)) {
+		return nil, false
+	}
+
+	return f.Blocks[i], true
+}
+
+// findOperation returns the *types.Operation referred
+// to by a *MissingOperation.
+func findOperation(block *types.Block, missing *MissingOperation) (*types.Operation, bool) {
+	for _, tx := range block.Transactions {
+		if tx.TransactionIdentifier.Hash != missing.TransactionHash {
+			continue
+		}
+
+		for _, op := range tx.Operations {
+			if op.OperationIdentifier.Index == missing.OperationIndex {
+				return op, true
+			}
+		}
+	}
+
+	return nil, false
+}
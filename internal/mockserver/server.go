@@ -0,0 +1,521 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mockserver
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// rosettaVersion is the Rosetta version
+	// returned in /network/options.
+	rosettaVersion = "1.4.0"
+
+	// nodeVersion is the node version returned
+	// in /network/options.
+	nodeVersion = "mock"
+
+	// orphanSuffix is appended to the hash of
+	// any orphaned block.
+	orphanSuffix = "-orphan"
+)
+
+var (
+	// ErrBlockNotFound is returned when a requested block
+	// does not exist or has not been reached by the tip.
+	ErrBlockNotFound = &types.Error{
+		Code:      1,
+		Message:   "Block not found",
+		Retriable: true,
+	}
+
+	// ErrTransactionNotFound is returned when a requested
+	// transaction is not in the requested block.
+	ErrTransactionNotFound = &types.Error{
+		Code:    2,
+		Message: "Transaction not found",
+	}
+
+	// Errors are all errors the mock server can return.
+	Errors = []*types.Error{
+		ErrBlockNotFound,
+		ErrTransactionNotFound,
+	}
+)
+
+// balanceChange is the balance of an account
+// after all operations in a block are applied.
+type balanceChange struct {
+	index   int64
+	balance *big.Int
+}
+
+// Server serves a *Fixture over the Rosetta Data API.
+//
+// The tip of the chain starts at genesis and advances by one
+// block each time /network/status is called, so that each block
+// in the fixture is observed as the tip (which is required
+// to observe a reorg).
+type Server struct {
+	fixture *Fixture
+
+	operationTypes []string
+	currencies     []*types.Currency
+
+	// orphans are the orphaned version of each block
+	// affected by a reorg. orphanedUntil is the tip index
+	// at which each orphaned block is replaced.
+	orphans       map[int64]*types.Block
+	orphanedUntil map[int64]int64
+
+	missingOperations map[string]struct{}
+	balances          map[string][]*balanceChange
+
+	tip   int64
+	mutex sync.Mutex
+}
+
+// NewServer returns a new *Server. The *Fixture should
+// be loaded with LoadFixture.
+func NewServer(fixture *Fixture) (*Server, error) {
+	s := &Server{
+		fixture:           fixture,
+		orphans:           map[int64]*types.Block{},
+		orphanedUntil:     map[int64]int64{},
+		missingOperations: map[string]struct{}{},
+		balances:          map[string][]*balanceChange{},
+		tip:               fixture.Blocks[0].BlockIdentifier.Index,
+	}
+
+	for _, reorg := range fixture.Reorgs {
+		parent, _ := fixture.block(reorg.Index - 1)
+		parentIdentifier := parent.BlockIdentifier
+		for i := reorg.Index; i < reorg.Index+reorg.Depth; i++ {
+			block, _ := fixture.block(i)
+			orphan := *block
+			orphan.BlockIdentifier = &types.BlockIdentifier{
+				Index: block.BlockIdentifier.Index,
+				Hash:  block.BlockIdentifier.Hash + orphanSuffix,
+			}
+			orphan.ParentBlockIdentifier = parentIdentifier
+
+			s.orphans[i] = &orphan
+			s.orphanedUntil[i] = reorg.Index + reorg.Depth
+			parentIdentifier = orphan.BlockIdentifier
+		}
+	}
+
+	for _, missing := range fixture.MissingOperations {
+		s.missingOperations[missingOperationKey(
+			missing.BlockIndex,
+			missing.TransactionHash,
+			missing.OperationIndex,
+		)] = struct{}{}
+	}
+
+	if err := s.computeBalances(); err != nil {
+		return nil, fmt.Errorf("%w: unable to compute balances", err)
+	}
+
+	return s, nil
+}
+
+func missingOperationKey(blockIndex int64, transactionHash string, operationIndex int64) string {
+	return fmt.Sprintf("%d:%s:%d", blockIndex, transactionHash, operationIndex)
+}
+
+func balanceKey(account *types.AccountIdentifier, currency *types.Currency) string {
+	return types.Hash(&reconciler.AccountCurrency{
+		Account:  account,
+		Currency: currency,
+	})
+}
+
+// computeBalances stores the balance of each account after
+// every block in which it changes. Missing operations are
+// still applied so that the balances returned by the server
+// do not match the operations it returns.
+func (s *Server) computeBalances() error {
+	successful := map[string]bool{}
+	for _, status := range s.fixture.OperationStatuses {
+		successful[status.Status] = status.Successful
+	}
+
+	operationTypes := map[string]struct{}{}
+	currencies := map[string]*types.Currency{}
+	for _, block := range s.fixture.Blocks {
+		for _, tx := range block.Transactions {
+			for _, op := range tx.Operations {
+				operationTypes[op.Type] = struct{}{}
+				if op.Amount == nil || !successful[op.Status] {
+					continue
+				}
+
+				currencies[types.Hash(op.Amount.Currency)] = op.Amount.Currency
+				if err := s.applyChange(
+					balanceKey(op.Account, op.Amount.Currency),
+					block.BlockIdentifier.Index,
+					op.Amount.Value,
+				); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, discrepancy := range s.fixture.BalanceDiscrepancies {
+		currencies[types.Hash(discrepancy.Currency)] = discrepancy.Currency
+	}
+
+	for opType := range operationTypes {
+		s.operationTypes = append(s.operationTypes, opType)
+	}
+	sort.Strings(s.operationTypes)
+
+	for _, currency := range currencies {
+		s.currencies = append(s.currencies, currency)
+	}
+	sort.Slice(s.currencies, func(i, j int) bool {
+		return s.currencies[i].Symbol < s.currencies[j].Symbol
+	})
+
+	return nil
+}
+
+// applyChange adds an amount to the balance of an
+// account at a given block index.
+func (s *Server) applyChange(key string, index int64, amount string) error {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("%s is not an integer", amount)
+	}
+
+	changes := s.balances[key]
+	if len(changes) == 0 {
+		s.balances[key] = []*balanceChange{{index: index, balance: value}}
+		return nil
+	}
+
+	last := changes[len(changes)-1]
+	if last.index == index {
+		last.balance = new(big.Int).Add(last.balance, value)
+		return nil
+	}
+
+	s.balances[key] = append(changes, &balanceChange{
+		index:   index,
+		balance: new(big.Int).Add(last.balance, value),
+	})
+
+	return nil
+}
+
+// balance returns the balance of an account
+// at a given block index.
+func (s *Server) balance(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	index int64,
+) *big.Int {
+	balance := new(big.Int)
+	changes := s.balances[balanceKey(account, currency)]
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].index > index
+	})
+	if i > 0 {
+		balance.Set(changes[i-1].balance)
+	}
+
+	for _, discrepancy := range s.fixture.BalanceDiscrepancies {
+		if discrepancy.Index > index ||
+			balanceKey(discrepancy.Account, discrepancy.Currency) != balanceKey(account, currency) {
+			continue
+		}
+
+		value, _ := new(big.Int).SetString(discrepancy.Amount, 10)
+		balance.Add(balance, value)
+	}
+
+	return balance
+}
+
+// visibleBlock returns the block currently served at an index
+// (which may be orphaned). The caller must hold the mutex.
+func (s *Server) visibleBlock(index int64) (*types.Block, bool) {
+	if index > s.tip {
+		return nil, false
+	}
+
+	if orphan, ok := s.orphans[index]; ok && s.tip < s.orphanedUntil[index] {
+		return orphan, true
+	}
+
+	return s.fixture.block(index)
+}
+
+// findBlock returns the block referred to by a
+// *types.PartialBlockIdentifier. Orphaned blocks can be
+// found by hash even after they are replaced. If the
+// identifier is nil, the tip is returned.
+func (s *Server) findBlock(identifier *types.PartialBlockIdentifier) (*types.Block, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if identifier == nil {
+		return s.visibleBlock(s.tip)
+	}
+
+	if identifier.Hash == nil {
+		return s.visibleBlock(*identifier.Index)
+	}
+
+	for index, orphan := range s.orphans {
+		if orphan.BlockIdentifier.Hash == *identifier.Hash && index <= s.tip {
+			return orphan, true
+		}
+	}
+
+	for _, block := range s.fixture.Blocks {
+		if block.BlockIdentifier.Hash != *identifier.Hash {
+			continue
+		}
+
+		if block.BlockIdentifier.Index > s.tip {
+			return nil, false
+		}
+
+		if identifier.Index != nil && *identifier.Index != block.BlockIdentifier.Index {
+			return nil, false
+		}
+
+		return block, true
+	}
+
+	return nil, false
+}
+
+// withoutMissingOperations returns a copy of a
+// *types.Transaction without any missing operations. The
+// remaining operations are re-indexed so that the
+// transaction is still valid.
+func (s *Server) withoutMissingOperations(
+	blockIndex int64,
+	tx *types.Transaction,
+) *types.Transaction {
+	newIndexes := map[int64]int64{}
+	operations := []*types.Operation{}
+	for _, op := range tx.Operations {
+		if _, ok := s.missingOperations[missingOperationKey(
+			blockIndex,
+			tx.TransactionIdentifier.Hash,
+			op.OperationIdentifier.Index,
+		)]; ok {
+			continue
+		}
+
+		newIndexes[op.OperationIdentifier.Index] = int64(len(operations))
+		operations = append(operations, op)
+	}
+
+	if len(operations) == len(tx.Operations) {
+		return tx
+	}
+
+	for i, op := range operations {
+		newOp := *op
+		newOp.OperationIdentifier = &types.OperationIdentifier{
+			Index:        newIndexes[op.OperationIdentifier.Index],
+			NetworkIndex: op.OperationIdentifier.NetworkIndex,
+		}
+
+		newOp.RelatedOperations = nil
+		for _, related := range op.RelatedOperations {
+			newIndex, ok := newIndexes[related.Index]
+			if !ok {
+				continue
+			}
+
+			newOp.RelatedOperations = append(
+				newOp.RelatedOperations,
+				&types.OperationIdentifier{Index: newIndex},
+			)
+		}
+
+		operations[i] = &newOp
+	}
+
+	newTx := *tx
+	newTx.Operations = operations
+	return &newTx
+}
+
+// Handler returns an http.Handler that serves the
+// Rosetta Data API.
+func (s *Server) Handler() (http.Handler, error) {
+	asserter, err := asserter.NewServer(
+		s.operationTypes,
+		true,
+		[]*types.NetworkIdentifier{s.fixture.Network},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create server asserter", err)
+	}
+
+	return server.NewRouter(
+		server.NewNetworkAPIController(s, asserter),
+		server.NewBlockAPIController(s, asserter),
+		server.NewAccountAPIController(s, asserter),
+	), nil
+}
+
+// NetworkList implements the /network/list endpoint.
+func (s *Server) NetworkList(
+	ctx context.Context,
+	request *types.MetadataRequest,
+) (*types.NetworkListResponse, *types.Error) {
+	return &types.NetworkListResponse{
+		NetworkIdentifiers: []*types.NetworkIdentifier{s.fixture.Network},
+	}, nil
+}
+
+// NetworkOptions implements the /network/options endpoint.
+func (s *Server) NetworkOptions(
+	ctx context.Context,
+	request *types.NetworkRequest,
+) (*types.NetworkOptionsResponse, *types.Error) {
+	return &types.NetworkOptionsResponse{
+		Version: &types.Version{
+			RosettaVersion: rosettaVersion,
+			NodeVersion:    nodeVersion,
+		},
+		Allow: &types.Allow{
+			OperationStatuses:       s.fixture.OperationStatuses,
+			OperationTypes:          s.operationTypes,
+			Errors:                  Errors,
+			HistoricalBalanceLookup: true,
+		},
+	}, nil
+}
+
+// NetworkStatus implements the /network/status endpoint. Each
+// call advances the tip by one block.
+func (s *Server) NetworkStatus(
+	ctx context.Context,
+	request *types.NetworkRequest,
+) (*types.NetworkStatusResponse, *types.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// We advance the tip before responding so that the
+	// returned tip is served until the next call.
+	if s.tip < s.fixture.Blocks[len(s.fixture.Blocks)-1].BlockIdentifier.Index {
+		s.tip++
+	}
+
+	tip, _ := s.visibleBlock(s.tip)
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: tip.BlockIdentifier,
+		CurrentBlockTimestamp:  tip.Timestamp,
+		GenesisBlockIdentifier: s.fixture.Blocks[0].BlockIdentifier,
+		Peers:                  []*types.Peer{},
+	}, nil
+}
+
+// Block implements the /block endpoint.
+func (s *Server) Block(
+	ctx context.Context,
+	request *types.BlockRequest,
+) (*types.BlockResponse, *types.Error) {
+	block, ok := s.findBlock(request.BlockIdentifier)
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+
+	newBlock := *block
+	newBlock.Transactions = make([]*types.Transaction, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		newBlock.Transactions[i] = s.withoutMissingOperations(block.BlockIdentifier.Index, tx)
+	}
+
+	return &types.BlockResponse{
+		Block: &newBlock,
+	}, nil
+}
+
+// BlockTransaction implements the /block/transaction endpoint.
+func (s *Server) BlockTransaction(
+	ctx context.Context,
+	request *types.BlockTransactionRequest,
+) (*types.BlockTransactionResponse, *types.Error) {
+	block, ok := s.findBlock(&types.PartialBlockIdentifier{
+		Index: &request.BlockIdentifier.Index,
+		Hash:  &request.BlockIdentifier.Hash,
+	})
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+
+	for _, tx := range block.Transactions {
+		if tx.TransactionIdentifier.Hash == request.TransactionIdentifier.Hash {
+			return &types.BlockTransactionResponse{
+				Transaction: s.withoutMissingOperations(block.BlockIdentifier.Index, tx),
+			}, nil
+		}
+	}
+
+	return nil, ErrTransactionNotFound
+}
+
+// AccountBalance implements the /account/balance endpoint. The
+// balance of every currency in the fixture is returned (even
+// if it is 0). Orphaned blocks contain the same transactions
+// as the blocks that replace them, so they have the same
+// balances.
+func (s *Server) AccountBalance(
+	ctx context.Context,
+	request *types.AccountBalanceRequest,
+) (*types.AccountBalanceResponse, *types.Error) {
+	block, ok := s.findBlock(request.BlockIdentifier)
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+
+	balances := make([]*types.Amount, len(s.currencies))
+	for i, currency := range s.currencies {
+		balances[i] = &types.Amount{
+			Value: s.balance(
+				request.AccountIdentifier,
+				currency,
+				block.BlockIdentifier.Index,
+			).String(),
+			Currency: currency,
+		}
+	}
+
+	return &types.AccountBalanceResponse{
+		BlockIdentifier: block.BlockIdentifier,
+		Balances:        balances,
+	}, nil
+}
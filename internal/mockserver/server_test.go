@@ -0,0 +1,316 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mockserver
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	network = &types.NetworkIdentifier{
+		Blockchain: "Mock",
+		Network:    "Testnet",
+	}
+
+	currency = &types.Currency{
+		Symbol:   "MOCK",
+		Decimals: 8,
+	}
+)
+
+func transfer(from string, to string, value int64) []*types.Operation {
+	return []*types.Operation{
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 0},
+			Type:                "TRANSFER",
+			Status:              "SUCCESS",
+			Account:             &types.AccountIdentifier{Address: from},
+			Amount: &types.Amount{
+				Value:    fmt.Sprintf("-%d", value),
+				Currency: currency,
+			},
+		},
+		{
+			OperationIdentifier: &types.OperationIdentifier{Index: 1},
+			RelatedOperations: []*types.OperationIdentifier{
+				{Index: 0},
+			},
+			Type:    "TRANSFER",
+			Status:  "SUCCESS",
+			Account: &types.AccountIdentifier{Address: to},
+			Amount: &types.Amount{
+				Value:    fmt.Sprintf("%d", value),
+				Currency: currency,
+			},
+		},
+	}
+}
+
+func testFixture(blocks int64) *Fixture {
+	fixture := &Fixture{Network: network}
+	for i := int64(0); i < blocks; i++ {
+		identifier := &types.BlockIdentifier{Index: i, Hash: fmt.Sprintf("block %d", i)}
+		parent := identifier
+		if i > 0 {
+			parent = fixture.Blocks[i-1].BlockIdentifier
+		}
+
+		operations := []*types.Operation{
+			{
+				OperationIdentifier: &types.OperationIdentifier{Index: 0},
+				Type:                "TRANSFER",
+				Status:              "SUCCESS",
+				Account:             &types.AccountIdentifier{Address: "addr1"},
+				Amount: &types.Amount{
+					Value:    "1000",
+					Currency: currency,
+				},
+			},
+		}
+		if i > 0 {
+			operations = transfer("addr1", "addr2", i)
+		}
+
+		fixture.Blocks = append(fixture.Blocks, &types.Block{
+			BlockIdentifier:       identifier,
+			ParentBlockIdentifier: parent,
+			Timestamp:             1590000000000 + i*1000,
+			Transactions: []*types.Transaction{
+				{
+					TransactionIdentifier: &types.TransactionIdentifier{
+						Hash: fmt.Sprintf("tx %d", i),
+					},
+					Operations: operations,
+				},
+			},
+		})
+	}
+
+	return fixture
+}
+
+func TestLoadFixture(t *testing.T) {
+	var tests = map[string]struct {
+		fixture *Fixture
+		err     bool
+	}{
+		"valid fixture": {
+			fixture: func() *Fixture {
+				f := testFixture(5)
+				f.Reorgs = []*Reorg{{Index: 2, Depth: 2}}
+				return f
+			}(),
+		},
+		"no blocks": {
+			fixture: &Fixture{Network: network},
+			err:     true,
+		},
+		"broken chain": {
+			fixture: func() *Fixture {
+				f := testFixture(5)
+				f.Blocks[3].ParentBlockIdentifier = f.Blocks[1].BlockIdentifier
+				return f
+			}(),
+			err: true,
+		},
+		"reorg of genesis": {
+			fixture: func() *Fixture {
+				f := testFixture(5)
+				f.Reorgs = []*Reorg{{Index: 0, Depth: 1}}
+				return f
+			}(),
+			err: true,
+		},
+		"reorg never replaced": {
+			fixture: func() *Fixture {
+				f := testFixture(5)
+				f.Reorgs = []*Reorg{{Index: 3, Depth: 2}}
+				return f
+			}(),
+			err: true,
+		},
+		"overlapping reorgs": {
+			fixture: func() *Fixture {
+				f := testFixture(10)
+				f.Reorgs = []*Reorg{{Index: 2, Depth: 2}, {Index: 3, Depth: 1}}
+				return f
+			}(),
+			err: true,
+		},
+		"unknown missing operation": {
+			fixture: func() *Fixture {
+				f := testFixture(5)
+				f.MissingOperations = []*MissingOperation{
+					{BlockIndex: 2, TransactionHash: "tx 2", OperationIndex: 5},
+				}
+				return f
+			}(),
+			err: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(dir)
+
+			filePath := path.Join(dir, "fixture.json")
+			assert.NoError(t, utils.SerializeAndWrite(filePath, test.fixture))
+
+			fixture, err := LoadFixture(filePath)
+			if test.err {
+				assert.Error(t, err)
+				assert.Nil(t, fixture)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, DefaultOperationStatuses, fixture.OperationStatuses)
+			}
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	fixture := testFixture(6)
+	fixture.Reorgs = []*Reorg{{Index: 2, Depth: 2}}
+	fixture.MissingOperations = []*MissingOperation{
+		{BlockIndex: 5, TransactionHash: "tx 5", OperationIndex: 0},
+	}
+	fixture.BalanceDiscrepancies = []*BalanceDiscrepancy{
+		{
+			Account:  &types.AccountIdentifier{Address: "addr2"},
+			Currency: currency,
+			Index:    4,
+			Amount:   "100",
+		},
+	}
+	assert.NoError(t, fixture.validate())
+
+	s, err := NewServer(fixture)
+	assert.NoError(t, err)
+	handler, err := s.Handler()
+	assert.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	f := fetcher.New(server.URL, fetcher.WithMaxRetries(1))
+	_, _, err = f.InitializeAsserter(ctx)
+	assert.NoError(t, err)
+
+	index := int64(2)
+	t.Run("orphaned block is served", func(t *testing.T) {
+		status, err := f.NetworkStatusRetry(ctx, network, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), status.CurrentBlockIdentifier.Index)
+		assert.Equal(t, "block 2-orphan", status.CurrentBlockIdentifier.Hash)
+
+		block, err := f.BlockRetry(ctx, network, &types.PartialBlockIdentifier{Index: &index})
+		assert.NoError(t, err)
+		assert.Equal(t, "block 2-orphan", block.BlockIdentifier.Hash)
+		assert.Equal(t, fixture.Blocks[2].Transactions, block.Transactions)
+
+		nextIndex := int64(4)
+		_, err = f.BlockRetry(ctx, network, &types.PartialBlockIdentifier{Index: &nextIndex})
+		assert.Error(t, err)
+	})
+
+	t.Run("orphaned block is replaced", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := f.NetworkStatusRetry(ctx, network, nil)
+			assert.NoError(t, err)
+		}
+
+		block, err := f.BlockRetry(ctx, network, &types.PartialBlockIdentifier{Index: &index})
+		assert.NoError(t, err)
+		assert.Equal(t, fixture.Blocks[2].BlockIdentifier, block.BlockIdentifier)
+
+		// Orphaned blocks can still be fetched by hash
+		orphanHash := "block 2-orphan"
+		block, err = f.BlockRetry(ctx, network, &types.PartialBlockIdentifier{Hash: &orphanHash})
+		assert.NoError(t, err)
+		assert.Equal(t, orphanHash, block.BlockIdentifier.Hash)
+	})
+
+	t.Run("missing operation", func(t *testing.T) {
+		status, err := f.NetworkStatusRetry(ctx, network, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), status.CurrentBlockIdentifier.Index)
+
+		missingIndex := int64(5)
+		block, err := f.BlockRetry(ctx, network, &types.PartialBlockIdentifier{Index: &missingIndex})
+		assert.NoError(t, err)
+		operations := block.Transactions[0].Operations
+		assert.Len(t, operations, 1)
+		assert.Equal(t, int64(0), operations[0].OperationIdentifier.Index)
+		assert.Equal(t, "addr2", operations[0].Account.Address)
+		assert.Len(t, operations[0].RelatedOperations, 0)
+
+		// The fixture should not be modified
+		assert.Len(t, fixture.Blocks[5].Transactions[0].Operations, 2)
+	})
+
+	t.Run("balances", func(t *testing.T) {
+		account := &types.AccountIdentifier{Address: "addr1"}
+		balanceIndex := int64(3)
+		block, balances, _, err := f.AccountBalanceRetry(
+			ctx,
+			network,
+			account,
+			&types.PartialBlockIdentifier{Index: &balanceIndex},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, fixture.Blocks[3].BlockIdentifier, block)
+		assert.Equal(t, []*types.Amount{{Value: "994", Currency: currency}}, balances)
+
+		// The missing operation is still applied
+		block, balances, _, err = f.AccountBalanceRetry(ctx, network, account, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, fixture.Blocks[5].BlockIdentifier, block)
+		assert.Equal(t, []*types.Amount{{Value: "985", Currency: currency}}, balances)
+
+		// Discrepancies are applied at and after their index
+		account = &types.AccountIdentifier{Address: "addr2"}
+		_, balances, _, err = f.AccountBalanceRetry(
+			ctx,
+			network,
+			account,
+			&types.PartialBlockIdentifier{Index: &balanceIndex},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Amount{{Value: "6", Currency: currency}}, balances)
+
+		_, balances, _, err = f.AccountBalanceRetry(ctx, network, account, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Amount{{Value: "115", Currency: currency}}, balances)
+
+		// Unknown accounts have a balance of 0
+		account = &types.AccountIdentifier{Address: "addr3"}
+		_, balances, _, err = f.AccountBalanceRetry(ctx, network, account, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Amount{{Value: "0", Currency: currency}}, balances)
+	})
+}
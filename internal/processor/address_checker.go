@@ -0,0 +1,266 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ storage.BlockWorker = (*AddressChecker)(nil)
+
+const (
+	// bech32Charset contains the characters of the
+	// bech32 data part in order of their value.
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// bech32ChecksumLength is the number of characters
+	// at the end of the data part used by the checksum.
+	bech32ChecksumLength = 6
+)
+
+var (
+	// ErrAddressFormatInvalid is returned when an address does
+	// not match the configured address pattern.
+	ErrAddressFormatInvalid = errors.New("address does not match pattern")
+
+	// ErrAddressChecksumInvalid is returned when an address
+	// does not have a valid checksum.
+	ErrAddressChecksumInvalid = errors.New("address checksum is invalid")
+
+	// ErrSubAccountFormatInvalid is returned when a sub-account
+	// is not allowed or its address does not match the configured
+	// sub-account address pattern.
+	ErrSubAccountFormatInvalid = errors.New("sub-account is invalid")
+)
+
+// AddressChecker is a storage.BlockWorker that validates the
+// format of the account identifier of every operation in each
+// block added to storage (which includes the account of every
+// balance change).
+type AddressChecker struct {
+	*configuration.AddressFormatConfiguration
+
+	address           *regexp.Regexp
+	subAccountAddress *regexp.Regexp
+}
+
+// anchoredRegexp compiles pattern so that it only
+// matches entire strings (instead of any substring).
+// Patterns are validated when the configuration is loaded.
+func anchoredRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("^(?:" + pattern + ")$")
+}
+
+// NewAddressChecker returns a new *AddressChecker.
+func NewAddressChecker(config *configuration.AddressFormatConfiguration) *AddressChecker {
+	checker := &AddressChecker{AddressFormatConfiguration: config}
+	if len(config.AddressPattern) > 0 {
+		checker.address = anchoredRegexp(config.AddressPattern)
+	}
+
+	if len(config.SubAccountAddressPattern) > 0 {
+		checker.subAccountAddress = anchoredRegexp(config.SubAccountAddressPattern)
+	}
+
+	return checker
+}
+
+// CheckAccount returns an error if an account identifier
+// does not match the configured format.
+func (c *AddressChecker) CheckAccount(account *types.AccountIdentifier) error {
+	if c.address != nil && !c.address.MatchString(account.Address) {
+		return fmt.Errorf(
+			"%w: %s does not match %s",
+			ErrAddressFormatInvalid,
+			account.Address,
+			c.AddressPattern,
+		)
+	}
+
+	switch c.Checksum {
+	case configuration.EIP55Checksum:
+		if err := checkEIP55(account.Address); err != nil {
+			return err
+		}
+	case configuration.Bech32Checksum:
+		if err := checkBech32(account.Address, c.Bech32Prefix); err != nil {
+			return err
+		}
+	}
+
+	if account.SubAccount == nil {
+		return nil
+	}
+
+	if c.SubAccountsDisallowed {
+		return fmt.Errorf(
+			"%w: sub-account %s is not allowed",
+			ErrSubAccountFormatInvalid,
+			account.SubAccount.Address,
+		)
+	}
+
+	if c.subAccountAddress != nil && !c.subAccountAddress.MatchString(account.SubAccount.Address) {
+		return fmt.Errorf(
+			"%w: sub-account %s does not match %s",
+			ErrSubAccountFormatInvalid,
+			account.SubAccount.Address,
+			c.SubAccountAddressPattern,
+		)
+	}
+
+	return nil
+}
+
+// checkEIP55 returns an error if address is not a
+// hex address with a valid EIP-55 checksum.
+func checkEIP55(address string) error {
+	if !strings.HasPrefix(address, "0x") || !common.IsHexAddress(address) {
+		return fmt.Errorf("%w: %s is not a hex address", ErrAddressChecksumInvalid, address)
+	}
+
+	if checksummed := common.HexToAddress(address).Hex(); checksummed != address {
+		return fmt.Errorf(
+			"%w: %s does not match EIP-55 checksum %s",
+			ErrAddressChecksumInvalid,
+			address,
+			checksummed,
+		)
+	}
+
+	return nil
+}
+
+// bech32Polymod computes the BCH checksum of
+// bech32 values (as described in BIP-173).
+func bech32Polymod(values []byte) uint32 {
+	generator := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < len(generator); i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+// checkBech32 returns an error if address is not a bech32 string
+// with a valid checksum (and prefix, if populated). The 90 character
+// limit in BIP-173 is not enforced because some networks exceed it.
+func checkBech32(address string, prefix string) error {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return fmt.Errorf("%w: %s has mixed case", ErrAddressChecksumInvalid, address)
+	}
+	address = strings.ToLower(address)
+
+	separator := strings.LastIndex(address, "1")
+	if separator < 1 || separator+bech32ChecksumLength+1 > len(address) {
+		return fmt.Errorf("%w: %s has an invalid separator position", ErrAddressChecksumInvalid, address)
+	}
+
+	hrp := address[:separator]
+	if len(prefix) > 0 && hrp != prefix {
+		return fmt.Errorf(
+			"%w: %s does not have prefix %s",
+			ErrAddressChecksumInvalid,
+			address,
+			prefix,
+		)
+	}
+
+	values := make([]byte, 0, len(hrp)*2+1+len(address)-separator-1)
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return fmt.Errorf("%w: %s has an invalid prefix", ErrAddressChecksumInvalid, address)
+		}
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	for _, char := range address[separator+1:] {
+		value := strings.IndexRune(bech32Charset, char)
+		if value < 0 {
+			return fmt.Errorf(
+				"%w: %s has invalid character %q",
+				ErrAddressChecksumInvalid,
+				address,
+				char,
+			)
+		}
+		values = append(values, byte(value))
+	}
+
+	if bech32Polymod(values) != 1 {
+		return fmt.Errorf("%w: %s has an invalid bech32 checksum", ErrAddressChecksumInvalid, address)
+	}
+
+	return nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *AddressChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if op.Account == nil {
+				continue
+			}
+
+			if err := c.CheckAccount(op.Account); err != nil {
+				return nil, fmt.Errorf(
+					"%w: invalid account in operation %d in transaction %s in block %+v",
+					err,
+					op.OperationIdentifier.Index,
+					tx.TransactionIdentifier.Hash,
+					block.BlockIdentifier,
+				)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Addresses are only checked when blocks are added.
+func (c *AddressChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
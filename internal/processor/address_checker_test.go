@@ -0,0 +1,221 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressChecker(t *testing.T) {
+	var tests = map[string]struct {
+		config  *configuration.AddressFormatConfiguration
+		account *types.AccountIdentifier
+		err     error
+	}{
+		"pattern match": {
+			config: &configuration.AddressFormatConfiguration{
+				AddressPattern: "^addr[0-9]+$",
+			},
+			account: &types.AccountIdentifier{Address: "addr1"},
+		},
+		"pattern mismatch": {
+			config: &configuration.AddressFormatConfiguration{
+				AddressPattern: "^addr[0-9]+$",
+			},
+			account: &types.AccountIdentifier{Address: "addr1 "},
+			err:     ErrAddressFormatInvalid,
+		},
+		"unanchored pattern match": {
+			config: &configuration.AddressFormatConfiguration{
+				AddressPattern: "addr[0-9]+",
+			},
+			account: &types.AccountIdentifier{Address: "addr1"},
+		},
+		"unanchored pattern substring": {
+			config: &configuration.AddressFormatConfiguration{
+				AddressPattern: "addr[0-9]+",
+			},
+			account: &types.AccountIdentifier{Address: "xaddr1x"},
+			err:     ErrAddressFormatInvalid,
+		},
+		"alternation pattern substring": {
+			config: &configuration.AddressFormatConfiguration{
+				AddressPattern: "addr[0-9]+|acct[0-9]+",
+			},
+			account: &types.AccountIdentifier{Address: "addr1 acct1"},
+			err:     ErrAddressFormatInvalid,
+		},
+		"valid eip55": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.EIP55Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			},
+		},
+		"eip55 not checksummed": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.EIP55Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			},
+			err: ErrAddressChecksumInvalid,
+		},
+		"eip55 wrong length": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.EIP55Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+			},
+			err: ErrAddressChecksumInvalid,
+		},
+		"valid bech32": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum:     configuration.Bech32Checksum,
+				Bech32Prefix: "bc",
+			},
+			account: &types.AccountIdentifier{
+				Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			},
+		},
+		"valid uppercase bech32": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.Bech32Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			},
+		},
+		"bech32 invalid checksum": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.Bech32Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+			},
+			err: ErrAddressChecksumInvalid,
+		},
+		"bech32 mixed case": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum: configuration.Bech32Checksum,
+			},
+			account: &types.AccountIdentifier{
+				Address: "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			},
+			err: ErrAddressChecksumInvalid,
+		},
+		"bech32 wrong prefix": {
+			config: &configuration.AddressFormatConfiguration{
+				Checksum:     configuration.Bech32Checksum,
+				Bech32Prefix: "tb",
+			},
+			account: &types.AccountIdentifier{
+				Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			},
+			err: ErrAddressChecksumInvalid,
+		},
+		"sub-account disallowed": {
+			config: &configuration.AddressFormatConfiguration{
+				SubAccountsDisallowed: true,
+			},
+			account: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "stake"},
+			},
+			err: ErrSubAccountFormatInvalid,
+		},
+		"sub-account pattern match": {
+			config: &configuration.AddressFormatConfiguration{
+				SubAccountAddressPattern: "^(stake|locked)$",
+			},
+			account: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "stake"},
+			},
+		},
+		"sub-account pattern mismatch": {
+			config: &configuration.AddressFormatConfiguration{
+				SubAccountAddressPattern: "^(stake|locked)$",
+			},
+			account: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "vesting"},
+			},
+			err: ErrSubAccountFormatInvalid,
+		},
+		"unanchored sub-account pattern substring": {
+			config: &configuration.AddressFormatConfiguration{
+				SubAccountAddressPattern: "stake|locked",
+			},
+			account: &types.AccountIdentifier{
+				Address:    "addr1",
+				SubAccount: &types.SubAccountIdentifier{Address: "unstake"},
+			},
+			err: ErrSubAccountFormatInvalid,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := NewAddressChecker(test.config)
+			block := &types.Block{
+				BlockIdentifier: &types.BlockIdentifier{
+					Hash:  "block 1",
+					Index: 1,
+				},
+				Transactions: []*types.Transaction{
+					{
+						TransactionIdentifier: &types.TransactionIdentifier{
+							Hash: "tx 1",
+						},
+						Operations: []*types.Operation{
+							{
+								OperationIdentifier: &types.OperationIdentifier{
+									Index: 0,
+								},
+								Type: "fee",
+							},
+							{
+								OperationIdentifier: &types.OperationIdentifier{
+									Index: 1,
+								},
+								Type:    "transfer",
+								Account: test.account,
+							},
+						},
+					},
+				},
+			}
+
+			_, err := checker.AddingBlock(context.Background(), block, nil)
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+				assert.True(t, strings.Contains(err.Error(), "tx 1"))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ package processor
 import (
 	"context"
 	"fmt"
+	"regexp"
 
+	"github.com/coinbase/rosetta-cli/configuration"
 	"github.com/coinbase/rosetta-cli/internal/storage"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
@@ -38,6 +40,70 @@ type BalanceStorageHelper struct {
 	// Configuration settings
 	lookupBalanceByBlock bool
 	exemptAccounts       map[string]struct{}
+	exemptionRules       []*exemptionRule
+}
+
+// exemptionRule is a configuration.ExemptionRule
+// with compiled patterns.
+type exemptionRule struct {
+	*configuration.ExemptionRule
+
+	address           *regexp.Regexp
+	subAccountAddress *regexp.Regexp
+}
+
+// newExemptionRule compiles the patterns in a configuration.ExemptionRule.
+func newExemptionRule(rule *configuration.ExemptionRule) *exemptionRule {
+	compiled := &exemptionRule{ExemptionRule: rule}
+	if len(rule.AddressPattern) > 0 {
+		compiled.address = anchoredRegexp(rule.AddressPattern)
+	}
+
+	if len(rule.SubAccountAddressPattern) > 0 {
+		compiled.subAccountAddress = anchoredRegexp(rule.SubAccountAddressPattern)
+	}
+
+	return compiled
+}
+
+// matches returns a boolean indicating if an operation
+// matches all populated fields of the rule.
+func (r *exemptionRule) matches(op *types.Operation) bool {
+	if r.address != nil && !r.address.MatchString(op.Account.Address) {
+		return false
+	}
+
+	if r.subAccountAddress != nil || len(r.SubAccountMetadata) > 0 {
+		if op.Account.SubAccount == nil {
+			return false
+		}
+
+		if r.subAccountAddress != nil &&
+			!r.subAccountAddress.MatchString(op.Account.SubAccount.Address) {
+			return false
+		}
+
+		for key, value := range r.SubAccountMetadata {
+			opValue, ok := op.Account.SubAccount.Metadata[key]
+			if !ok || types.Hash(opValue) != types.Hash(value) {
+				return false
+			}
+		}
+	}
+
+	if len(r.CurrencySymbol) > 0 && op.Amount.Currency.Symbol != r.CurrencySymbol {
+		return false
+	}
+
+	if len(r.OperationType) > 0 && op.Type != r.OperationType {
+		return false
+	}
+
+	if len(r.OperationStatus) > 0 && op.Status != r.OperationStatus {
+		return false
+	}
+
+	return true
 }
 
 // NewBalanceStorageHelper returns a new BalanceStorageHelper.
@@ -46,6 +112,7 @@ func NewBalanceStorageHelper(
 	fetcher *fetcher.Fetcher,
 	lookupBalanceByBlock bool,
 	exemptAccounts []*reconciler.AccountCurrency,
+	exemptionRules []*configuration.ExemptionRule,
 ) *BalanceStorageHelper {
 	exemptMap := map[string]struct{}{}
 
@@ -55,11 +122,17 @@ func NewBalanceStorageHelper(
 		exemptMap[types.Hash(account)] = struct{}{}
 	}
 
+	rules := make([]*exemptionRule, len(exemptionRules))
+	for i, rule := range exemptionRules {
+		rules[i] = newExemptionRule(rule)
+	}
+
 	return &BalanceStorageHelper{
 		network:              network,
 		fetcher:              fetcher,
 		lookupBalanceByBlock: lookupBalanceByBlock,
 		exemptAccounts:       exemptMap,
+		exemptionRules:       rules,
 	}
 }
 
@@ -114,7 +187,16 @@ func (h *BalanceStorageHelper) ExemptFunc() parser.ExemptOperation {
 			Currency: op.Amount.Currency,
 		})
 
-		_, exists := h.exemptAccounts[thisAcct]
-		return exists
+		if _, exists := h.exemptAccounts[thisAcct]; exists {
+			return true
+		}
+
+		for _, rule := range h.exemptionRules {
+			if rule.matches(op) {
+				return true
+			}
+		}
+
+		return false
 	}
 }
@@ -17,6 +17,8 @@ package processor
 import (
 	"testing"
 
+	"github.com/coinbase/rosetta-cli/configuration"
+
 	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/stretchr/testify/assert"
@@ -78,7 +80,7 @@ func TestExemptFunc(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			helper := NewBalanceStorageHelper(nil, nil, false, test.exemptAccounts)
+			helper := NewBalanceStorageHelper(nil, nil, false, test.exemptAccounts, nil)
 
 			result := helper.ExemptFunc()(&types.Operation{
 				Account: opAmountCurrency.Account,
@@ -92,3 +94,110 @@ func TestExemptFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestExemptionRules(t *testing.T) {
+	stakeOp := &types.Operation{
+		Type:   "reward",
+		Status: "success",
+		Account: &types.AccountIdentifier{
+			Address: "validator1",
+			SubAccount: &types.SubAccountIdentifier{
+				Address: "stake-12",
+				Metadata: map[string]interface{}{
+					"pool":  "main",
+					"epoch": float64(10),
+				},
+			},
+		},
+		Amount: &types.Amount{
+			Value:    "100",
+			Currency: opAmountCurrency.Currency,
+		},
+	}
+
+	var tests = map[string]struct {
+		rule   *configuration.ExemptionRule
+		op     *types.Operation
+		exempt bool
+	}{
+		"address pattern": {
+			rule:   &configuration.ExemptionRule{AddressPattern: "^validator[0-9]+$"},
+			op:     stakeOp,
+			exempt: true,
+		},
+		"address pattern mismatch": {
+			rule: &configuration.ExemptionRule{AddressPattern: "^miner"},
+			op:   stakeOp,
+		},
+		"address pattern substring": {
+			rule: &configuration.ExemptionRule{AddressPattern: "validator"},
+			op:   stakeOp,
+		},
+		"sub-account address pattern": {
+			rule:   &configuration.ExemptionRule{SubAccountAddressPattern: "stake-[0-9]+"},
+			op:     stakeOp,
+			exempt: true,
+		},
+		"sub-account address pattern substring": {
+			rule: &configuration.ExemptionRule{SubAccountAddressPattern: "stake"},
+			op:   stakeOp,
+		},
+		"sub-account pattern without sub-account": {
+			rule: &configuration.ExemptionRule{SubAccountAddressPattern: ".*"},
+			op: &types.Operation{
+				Account: opAmountCurrency.Account,
+				Amount: &types.Amount{
+					Value:    "100",
+					Currency: opAmountCurrency.Currency,
+				},
+			},
+		},
+		"sub-account metadata": {
+			rule: &configuration.ExemptionRule{
+				SubAccountMetadata: map[string]interface{}{"epoch": float64(10)},
+			},
+			op:     stakeOp,
+			exempt: true,
+		},
+		"sub-account metadata mismatch": {
+			rule: &configuration.ExemptionRule{
+				SubAccountMetadata: map[string]interface{}{"pool": "other"},
+			},
+			op: stakeOp,
+		},
+		"currency symbol": {
+			rule:   &configuration.ExemptionRule{CurrencySymbol: "BTC"},
+			op:     stakeOp,
+			exempt: true,
+		},
+		"operation type and status": {
+			rule: &configuration.ExemptionRule{
+				OperationType:   "reward",
+				OperationStatus: "success",
+			},
+			op:     stakeOp,
+			exempt: true,
+		},
+		"operation type and status mismatch": {
+			rule: &configuration.ExemptionRule{
+				OperationType:   "reward",
+				OperationStatus: "failure",
+			},
+			op: stakeOp,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			helper := NewBalanceStorageHelper(
+				nil,
+				nil,
+				false,
+				nil,
+				[]*configuration.ExemptionRule{test.rule},
+			)
+
+			assert.Equal(t, test.exempt, helper.ExemptFunc()(test.op))
+		})
+	}
+}
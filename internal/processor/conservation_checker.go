@@ -0,0 +1,163 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*ConservationChecker)(nil)
+
+var (
+	// ErrTransactionImbalance is returned when the balance
+	// changes in a transaction do not sum to zero.
+	ErrTransactionImbalance = errors.New("balance changes in transaction do not sum to zero")
+)
+
+// ConservationChecker is a storage.BlockWorker that checks
+// that the successful balance-changing operations in each
+// transaction sum to zero for each currency.
+type ConservationChecker struct {
+	asserter *asserter.Asserter
+
+	// exemptTypes are the fee and mint/burn operation
+	// types excluded from the sum.
+	exemptTypes map[string]struct{}
+}
+
+// NewConservationChecker returns a new *ConservationChecker.
+// Operations with a type in feeTypes or mintBurnTypes are
+// excluded from the sum.
+func NewConservationChecker(
+	asserter *asserter.Asserter,
+	feeTypes []string,
+	mintBurnTypes []string,
+) *ConservationChecker {
+	exemptTypes := map[string]struct{}{}
+	for _, opType := range feeTypes {
+		exemptTypes[opType] = struct{}{}
+	}
+
+	for _, opType := range mintBurnTypes {
+		exemptTypes[opType] = struct{}{}
+	}
+
+	return &ConservationChecker{
+		asserter:    asserter,
+		exemptTypes: exemptTypes,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *ConservationChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	// The genesis block often allocates funds
+	// without any counter-party operations.
+	if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+		return nil, nil
+	}
+
+	for _, tx := range block.Transactions {
+		if err := c.checkTransaction(tx); err != nil {
+			return nil, fmt.Errorf(
+				"%w in transaction %s in block %+v",
+				err,
+				tx.TransactionIdentifier.Hash,
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	return nil, nil
+}
+
+// checkTransaction returns an error describing each currency
+// with balance changes that do not sum to zero in a transaction.
+func (c *ConservationChecker) checkTransaction(tx *types.Transaction) error {
+	changes := map[string]string{}
+	currencies := map[string]*types.Currency{}
+	for _, op := range tx.Operations {
+		if _, ok := c.exemptTypes[op.Type]; ok {
+			continue
+		}
+
+		if op.Account == nil || op.Amount == nil {
+			continue
+		}
+
+		successful, err := c.asserter.OperationSuccessful(op)
+		if err != nil {
+			return fmt.Errorf("%w: unable to check operation success", err)
+		}
+
+		if !successful {
+			continue
+		}
+
+		key := types.Hash(op.Amount.Currency)
+		existing, ok := changes[key]
+		if !ok {
+			existing = "0"
+		}
+
+		newVal, err := types.AddValues(existing, op.Amount.Value)
+		if err != nil {
+			return err
+		}
+
+		changes[key] = newVal
+		currencies[key] = op.Amount.Currency
+	}
+
+	imbalances := []string{}
+	for key, change := range changes {
+		if change != "0" {
+			imbalances = append(imbalances, fmt.Sprintf("%s%s", change, currencies[key].Symbol))
+		}
+	}
+
+	if len(imbalances) > 0 {
+		sort.Strings(imbalances)
+		return fmt.Errorf(
+			"%w: imbalance of %s",
+			ErrTransactionImbalance,
+			strings.Join(imbalances, ", "),
+		)
+	}
+
+	return nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Balance conservation is only checked when blocks are added.
+func (c *ConservationChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
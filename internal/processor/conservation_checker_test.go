@@ -0,0 +1,111 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func conservationGenesis(ops ...*types.Operation) *types.Block {
+	block := supplyBlock(0, ops...)
+	block.ParentBlockIdentifier = block.BlockIdentifier
+
+	return block
+}
+
+func TestConservationChecker(t *testing.T) {
+	var tests = map[string]struct {
+		block     *types.Block
+		imbalance string
+	}{
+		"balanced": {
+			block: supplyBlock(
+				1,
+				supplyOp("Transfer", "Success", "addr1", "-10"),
+				supplyOp("Transfer", "Success", "addr2", "10"),
+			),
+		},
+		"missing counter-party": {
+			block: supplyBlock(
+				1,
+				supplyOp("Transfer", "Success", "addr1", "-10"),
+				supplyOp("Transfer", "Success", "addr2", "8"),
+			),
+			imbalance: "-2BTC",
+		},
+		"failed operation": {
+			block: supplyBlock(
+				1,
+				supplyOp("Transfer", "Success", "addr1", "-10"),
+				supplyOp("Transfer", "Failure", "addr2", "10"),
+			),
+			imbalance: "-10BTC",
+		},
+		"all failed": {
+			block: supplyBlock(
+				1,
+				supplyOp("Transfer", "Failure", "addr1", "-10"),
+				supplyOp("Transfer", "Failure", "addr2", "8"),
+			),
+		},
+		"fee": {
+			block: supplyBlock(
+				1,
+				supplyOp("Transfer", "Success", "addr1", "-10"),
+				supplyOp("Transfer", "Success", "addr2", "10"),
+				supplyOp("Fee", "Success", "addr1", "-1"),
+			),
+		},
+		"genesis allocation": {
+			block: conservationGenesis(
+				supplyOp("Transfer", "Success", "addr1", "50"),
+			),
+		},
+		"mint": {
+			block: supplyBlock(
+				1,
+				supplyOp("Mint", "Success", "addr1", "50"),
+			),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := NewConservationChecker(
+				(&mockSupplyHelper{}).Asserter(),
+				[]string{"Fee"},
+				[]string{"Mint"},
+			)
+
+			_, err := checker.AddingBlock(context.Background(), test.block, nil)
+			if len(test.imbalance) > 0 {
+				assert.True(t, errors.Is(err, ErrTransactionImbalance))
+				assert.True(t, strings.Contains(err.Error(), test.imbalance), err.Error())
+				assert.True(t, strings.Contains(
+					err.Error(),
+					test.block.Transactions[0].TransactionIdentifier.Hash,
+				))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
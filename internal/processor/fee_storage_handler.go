@@ -0,0 +1,62 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-cli/internal/logger"
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.FeeStorageHandler = (*FeeStorageHandler)(nil)
+
+// FeeStorageHandler is invoked whenever the fees in a block
+// are added or removed from fee storage so that they can be
+// written to the fee stream.
+type FeeStorageHandler struct {
+	logger *logger.Logger
+}
+
+// NewFeeStorageHandler returns a new *FeeStorageHandler.
+func NewFeeStorageHandler(logger *logger.Logger) *FeeStorageHandler {
+	return &FeeStorageHandler{
+		logger: logger,
+	}
+}
+
+// FeesAdded is called whenever a block is committed to BlockStorage.
+func (h *FeeStorageHandler) FeesAdded(
+	ctx context.Context,
+	block *types.Block,
+	fees []*storage.TransactionFee,
+) error {
+	_ = h.logger.FeeStream(ctx, block.BlockIdentifier, fees, false)
+
+	return nil
+}
+
+// FeesRemoved is called whenever a block is removed from BlockStorage.
+func (h *FeeStorageHandler) FeesRemoved(
+	ctx context.Context,
+	block *types.Block,
+	fees []*storage.TransactionFee,
+) error {
+	_ = h.logger.FeeStream(ctx, block.BlockIdentifier, fees, true)
+
+	return nil
+}
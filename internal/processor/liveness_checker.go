@@ -0,0 +1,193 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/syncer"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+var (
+	// ErrTipStalled is returned when the network tip
+	// does not advance within the stall timeout.
+	ErrTipStalled = errors.New("network tip stalled")
+
+	// ErrTipRegressed is returned when the network tip goes
+	// backwards further than the max reorg depth.
+	ErrTipRegressed = errors.New("network tip went backwards")
+)
+
+// LivenessFetcher is the subset of *fetcher.Fetcher
+// used by the LivenessChecker.
+type LivenessFetcher interface {
+	NetworkStatusRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		metadata map[string]interface{},
+	) (*types.NetworkStatusResponse, error)
+}
+
+// LivenessChecker polls /network/status to ensure the network
+// tip keeps advancing. It also records the latest tip so that
+// sync lag can be logged.
+type LivenessChecker struct {
+	network          *types.NetworkIdentifier
+	fetcher          LivenessFetcher
+	pollingFrequency time.Duration
+	stallTimeout     time.Duration
+	maxRegression    int64
+	haltOnFailure    bool
+
+	// now returns the current time. It is
+	// replaced in tests.
+	now func() time.Time
+
+	// highestTip is the largest tip index seen
+	// and lastAdvance is when it was first seen.
+	highestTip    int64
+	lastAdvance   time.Time
+	stallReported bool
+
+	tipMutex sync.Mutex
+	tip      *types.BlockIdentifier
+}
+
+// NewLivenessChecker returns a new *LivenessChecker. If
+// maxReorgDepth is 0, the tip may go backwards by at most
+// syncer.PastBlockSize blocks (the deepest reorg the
+// syncer can handle).
+func NewLivenessChecker(
+	network *types.NetworkIdentifier,
+	fetcher LivenessFetcher,
+	pollingFrequency time.Duration,
+	stallTimeout time.Duration,
+	maxReorgDepth int64,
+	haltOnFailure bool,
+) *LivenessChecker {
+	maxRegression := maxReorgDepth
+	if maxRegression == 0 {
+		maxRegression = syncer.PastBlockSize
+	}
+
+	return &LivenessChecker{
+		network:          network,
+		fetcher:          fetcher,
+		pollingFrequency: pollingFrequency,
+		stallTimeout:     stallTimeout,
+		maxRegression:    maxRegression,
+		haltOnFailure:    haltOnFailure,
+		now:              time.Now,
+	}
+}
+
+// Start polls the network status until there is
+// an error or the context is canceled.
+func (c *LivenessChecker) Start(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if err := c.Poll(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.pollingFrequency):
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Tip returns the most recently fetched network
+// tip (or nil if it has not been fetched).
+func (c *LivenessChecker) Tip() *types.BlockIdentifier {
+	c.tipMutex.Lock()
+	defer c.tipMutex.Unlock()
+
+	return c.tip
+}
+
+// Poll fetches the network status once and checks that
+// the tip has not stalled or gone backwards.
+func (c *LivenessChecker) Poll(ctx context.Context) error {
+	status, err := c.fetcher.NetworkStatusRetry(ctx, c.network, nil)
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch network status", err)
+	}
+
+	tip := status.CurrentBlockIdentifier
+	c.tipMutex.Lock()
+	c.tip = tip
+	c.tipMutex.Unlock()
+
+	now := c.now()
+	if c.lastAdvance.IsZero() || tip.Index > c.highestTip {
+		c.highestTip = tip.Index
+		c.lastAdvance = now
+		c.stallReported = false
+		return nil
+	}
+
+	if tip.Index < c.highestTip-c.maxRegression {
+		err := fmt.Errorf(
+			"%w: tip %d:%s is %d blocks behind the highest tip %d",
+			ErrTipRegressed,
+			tip.Index,
+			tip.Hash,
+			c.highestTip-tip.Index,
+			c.highestTip,
+		)
+
+		// The new tip is tracked so that the
+		// regression is only reported once.
+		c.highestTip = tip.Index
+		c.lastAdvance = now
+		c.stallReported = false
+
+		return c.handleFailure(err)
+	}
+
+	stalled := now.Sub(c.lastAdvance)
+	if stalled < c.stallTimeout || c.stallReported {
+		return nil
+	}
+
+	c.stallReported = true
+	return c.handleFailure(fmt.Errorf(
+		"%w: tip %d:%s (timestamp %d) has not advanced in %s",
+		ErrTipStalled,
+		tip.Index,
+		tip.Hash,
+		status.CurrentBlockTimestamp,
+		stalled.Round(time.Second),
+	))
+}
+
+// handleFailure returns err if the LivenessChecker should
+// halt on failure. Otherwise, err is logged as a warning.
+func (c *LivenessChecker) handleFailure(err error) error {
+	if c.haltOnFailure {
+		return err
+	}
+
+	color.Yellow(err.Error())
+	return nil
+}
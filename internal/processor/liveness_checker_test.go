@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLivenessFetcher struct {
+	tip int64
+}
+
+func (f *mockLivenessFetcher) NetworkStatusRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	metadata map[string]interface{},
+) (*types.NetworkStatusResponse, error) {
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(f.tip),
+			Index: f.tip,
+		},
+	}, nil
+}
+
+func TestLivenessChecker(t *testing.T) {
+	ctx := context.Background()
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+
+	var tests = map[string]struct {
+		tips          []int64
+		elapsed       time.Duration
+		maxReorgDepth int64
+
+		err error
+	}{
+		"advancing": {
+			tips:    []int64{10, 11, 12, 13},
+			elapsed: 50 * time.Second,
+		},
+		"stalled": {
+			tips:    []int64{10, 11, 11, 11},
+			elapsed: 50 * time.Second,
+			err:     ErrTipStalled,
+		},
+		"not stalled yet": {
+			tips:    []int64{10, 11, 11},
+			elapsed: 50 * time.Second,
+		},
+		"reorg": {
+			tips:          []int64{10, 11, 9, 12},
+			elapsed:       time.Second,
+			maxReorgDepth: 2,
+		},
+		"regressed": {
+			tips:          []int64{10, 11, 8},
+			elapsed:       time.Second,
+			maxReorgDepth: 2,
+			err:           ErrTipRegressed,
+		},
+		"regressed beyond past block size": {
+			tips:    []int64{100, 50},
+			elapsed: time.Second,
+			err:     ErrTipRegressed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetcher := &mockLivenessFetcher{}
+			checker := NewLivenessChecker(
+				network,
+				fetcher,
+				time.Second,
+				100*time.Second,
+				test.maxReorgDepth,
+				true,
+			)
+
+			now := time.Now()
+			checker.now = func() time.Time {
+				return now
+			}
+
+			var err error
+			for _, tip := range test.tips {
+				fetcher.tip = tip
+				if err = checker.Poll(ctx); err != nil {
+					break
+				}
+
+				assert.Equal(t, tip, checker.Tip().Index)
+				now = now.Add(test.elapsed)
+			}
+
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("warn on failure", func(t *testing.T) {
+		fetcher := &mockLivenessFetcher{tip: 10}
+		checker := NewLivenessChecker(network, fetcher, time.Second, time.Second, 0, false)
+
+		now := time.Now()
+		checker.now = func() time.Time {
+			return now
+		}
+
+		assert.Nil(t, checker.Tip())
+		assert.NoError(t, checker.Poll(ctx))
+
+		now = now.Add(time.Minute)
+		assert.NoError(t, checker.Poll(ctx))
+		assert.True(t, checker.stallReported)
+	})
+}
@@ -0,0 +1,360 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/fatih/color"
+)
+
+const (
+	// mempoolBlockHash is the hash of the block each
+	// mempool transaction is placed in when it is
+	// validated like a block transaction.
+	mempoolBlockHash = "mempool"
+
+	// maxMempoolFailures is the number of consecutive polls
+	// that may fail to fetch the mempool (or a mempool
+	// transaction) before the MempoolChecker returns an error.
+	maxMempoolFailures = 10
+)
+
+// MempoolFetcher is the subset of *fetcher.Fetcher
+// used by the MempoolChecker.
+type MempoolFetcher interface {
+	Mempool(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+	) ([]*types.TransactionIdentifier, error)
+
+	MempoolTransaction(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		transaction *types.TransactionIdentifier,
+	) (*types.Transaction, map[string]interface{}, error)
+
+	NetworkStatusRetry(
+		ctx context.Context,
+		network *types.NetworkIdentifier,
+		metadata map[string]interface{},
+	) (*types.NetworkStatusResponse, error)
+}
+
+// mempoolTransaction is a transaction seen in the mempool.
+type mempoolTransaction struct {
+	identifier *types.TransactionIdentifier
+
+	// tipWhenLeft is the index of the network tip when the
+	// transaction was no longer in the mempool (or -1 if
+	// it is still in the mempool).
+	tipWhenLeft int64
+}
+
+// MempoolChecker polls /mempool and validates each mempool
+// transaction. Transactions that leave the mempool must
+// appear in a block synced into BlockStorage within the
+// inclusion window or they are reported as dropped.
+type MempoolChecker struct {
+	network        *types.NetworkIdentifier
+	fetcher        MempoolFetcher
+	parser         *parser.Parser
+	blockStorage   *storage.BlockStorage
+	counterStorage *storage.CounterStorage
+
+	// validators are BlockWorkers that only validate
+	// blocks. They are called with a nil DatabaseTransaction
+	// so that mempool transactions are checked with the
+	// same rules as block transactions. Validators must not
+	// validate the block itself (like its metadata), as mempool
+	// transactions are placed in a synthetic block.
+	validators []storage.BlockWorker
+
+	pollingFrequency time.Duration
+	inclusionWindow  int64
+
+	transactions map[string]*mempoolTransaction
+
+	// failures is the number of consecutive
+	// polls that failed to fetch data.
+	failures int
+}
+
+// NewMempoolChecker returns a new *MempoolChecker.
+func NewMempoolChecker(
+	network *types.NetworkIdentifier,
+	fetcher MempoolFetcher,
+	asserter *asserter.Asserter,
+	blockStorage *storage.BlockStorage,
+	counterStorage *storage.CounterStorage,
+	validators []storage.BlockWorker,
+	pollingFrequency time.Duration,
+	inclusionWindow int64,
+) *MempoolChecker {
+	return &MempoolChecker{
+		network:          network,
+		fetcher:          fetcher,
+		parser:           parser.New(asserter, nil),
+		blockStorage:     blockStorage,
+		counterStorage:   counterStorage,
+		validators:       validators,
+		pollingFrequency: pollingFrequency,
+		inclusionWindow:  inclusionWindow,
+		transactions:     map[string]*mempoolTransaction{},
+	}
+}
+
+// Start polls the mempool until there is an error
+// or the context is canceled.
+func (c *MempoolChecker) Start(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if err := c.Poll(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.pollingFrequency):
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Poll fetches the mempool once, validates any new
+// transactions, and checks if transactions that left
+// the mempool appeared in a synced block. If the mempool
+// (or a mempool transaction) cannot be fetched, the poll
+// is skipped (so it is retried in the next poll) unless
+// too many consecutive polls failed.
+func (c *MempoolChecker) Poll(ctx context.Context) error {
+	mempool, err := c.fetcher.Mempool(ctx, c.network)
+	if err != nil {
+		return c.pollFailed(fmt.Errorf("%w: unable to fetch mempool", err))
+	}
+
+	inMempool := map[string]struct{}{}
+	for _, identifier := range mempool {
+		inMempool[identifier.Hash] = struct{}{}
+		if tx, ok := c.transactions[identifier.Hash]; ok {
+			// The transaction may return to the mempool
+			// after a reorg.
+			tx.tipWhenLeft = -1
+			continue
+		}
+
+		tx, err := c.fetchTransaction(ctx, identifier)
+		if err != nil {
+			return c.pollFailed(err)
+		}
+
+		if tx == nil {
+			continue
+		}
+
+		if err := c.checkTransaction(ctx, identifier, tx); err != nil {
+			return err
+		}
+
+		c.transactions[identifier.Hash] = &mempoolTransaction{
+			identifier:  identifier,
+			tipWhenLeft: -1,
+		}
+		_, _ = c.counterStorage.Update(ctx, storage.MempoolTransactionCounter, big.NewInt(1))
+	}
+
+	c.failures = 0
+	return c.checkInclusion(ctx, inMempool)
+}
+
+// pollFailed records a poll that failed to fetch data. It
+// returns err if too many consecutive polls failed (otherwise
+// the poll is skipped).
+func (c *MempoolChecker) pollFailed(err error) error {
+	c.failures++
+	if c.failures >= maxMempoolFailures {
+		return fmt.Errorf("%w: %d consecutive mempool polls failed", err, c.failures)
+	}
+
+	color.Yellow(
+		"%s: skipping mempool poll (%d/%d consecutive failures)",
+		err.Error(),
+		c.failures,
+		maxMempoolFailures,
+	)
+
+	return nil
+}
+
+// fetchTransaction fetches a transaction in the mempool. It
+// returns nil if the transaction could not be fetched because
+// it already left the mempool.
+func (c *MempoolChecker) fetchTransaction(
+	ctx context.Context,
+	identifier *types.TransactionIdentifier,
+) (*types.Transaction, error) {
+	tx, _, err := c.fetcher.MempoolTransaction(ctx, c.network, identifier)
+	if err != nil {
+		// The transaction may have left the mempool
+		// since the mempool was fetched.
+		mempool, mempoolErr := c.fetcher.Mempool(ctx, c.network)
+		if mempoolErr == nil && !containsTransaction(mempool, identifier) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"%w: unable to fetch mempool transaction %s",
+			err,
+			identifier.Hash,
+		)
+	}
+
+	return tx, nil
+}
+
+// checkTransaction validates a transaction
+// fetched from the mempool.
+func (c *MempoolChecker) checkTransaction(
+	ctx context.Context,
+	identifier *types.TransactionIdentifier,
+	tx *types.Transaction,
+) error {
+	if types.Hash(tx.TransactionIdentifier) != types.Hash(identifier) {
+		return fmt.Errorf(
+			"mempool transaction %s returned for requested transaction %s",
+			tx.TransactionIdentifier.Hash,
+			identifier.Hash,
+		)
+	}
+
+	// Mempool transactions are placed in a block that is
+	// not genesis so that all rules for block transactions
+	// are applied.
+	block := &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  mempoolBlockHash,
+			Index: 1,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  mempoolBlockHash,
+			Index: 0,
+		},
+		Transactions: []*types.Transaction{tx},
+	}
+
+	if _, err := c.parser.BalanceChanges(ctx, block, false); err != nil {
+		return fmt.Errorf(
+			"%w: unable to parse mempool transaction %s",
+			err,
+			identifier.Hash,
+		)
+	}
+
+	for _, validator := range c.validators {
+		if _, err := validator.AddingBlock(ctx, block, nil); err != nil {
+			return fmt.Errorf("%w: invalid mempool transaction %s", err, identifier.Hash)
+		}
+	}
+
+	return nil
+}
+
+// checkInclusion checks if transactions that left the mempool
+// appeared in a synced block within the inclusion window.
+func (c *MempoolChecker) checkInclusion(
+	ctx context.Context,
+	inMempool map[string]struct{},
+) error {
+	var tip int64 = -1
+	for hash, tx := range c.transactions {
+		if _, ok := inMempool[hash]; ok || tx.tipWhenLeft >= 0 {
+			continue
+		}
+
+		if tip < 0 {
+			status, err := c.fetcher.NetworkStatusRetry(ctx, c.network, nil)
+			if err != nil {
+				return fmt.Errorf("%w: unable to fetch network status", err)
+			}
+
+			tip = status.CurrentBlockIdentifier.Index
+		}
+
+		tx.tipWhenLeft = tip
+	}
+
+	head, err := c.blockStorage.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, storage.ErrHeadBlockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	for hash, tx := range c.transactions {
+		if tx.tipWhenLeft < 0 {
+			continue
+		}
+
+		blocks, _, err := c.blockStorage.FindTransaction(ctx, tx.identifier)
+		if err != nil {
+			return fmt.Errorf("%w: unable to find transaction %s", err, hash)
+		}
+
+		if len(blocks) > 0 {
+			delete(c.transactions, hash)
+			continue
+		}
+
+		if head.Index < tx.tipWhenLeft+c.inclusionWindow {
+			continue
+		}
+
+		log.Printf(
+			"Mempool transaction %s was dropped (not in any block through %d)\n",
+			hash,
+			head.Index,
+		)
+		_, _ = c.counterStorage.Update(ctx, storage.DroppedTransactionCounter, big.NewInt(1))
+		delete(c.transactions, hash)
+	}
+
+	return nil
+}
+
+// containsTransaction returns a boolean indicating if a
+// transaction identifier is in a slice of identifiers.
+func containsTransaction(
+	identifiers []*types.TransactionIdentifier,
+	identifier *types.TransactionIdentifier,
+) bool {
+	for _, other := range identifiers {
+		if other.Hash == identifier.Hash {
+			return true
+		}
+	}
+
+	return false
+}
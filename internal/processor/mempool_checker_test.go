@@ -0,0 +1,214 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errMempoolTransactionNotFound = errors.New("transaction not found")
+	errMempoolUnavailable         = errors.New("mempool unavailable")
+)
+
+type mockMempoolFetcher struct {
+	mempool      []*types.TransactionIdentifier
+	transactions map[string]*types.Transaction
+	tip          int64
+	unavailable  bool
+}
+
+func (f *mockMempoolFetcher) Mempool(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+) ([]*types.TransactionIdentifier, error) {
+	if f.unavailable {
+		return nil, errMempoolUnavailable
+	}
+
+	return f.mempool, nil
+}
+
+func (f *mockMempoolFetcher) MempoolTransaction(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	transaction *types.TransactionIdentifier,
+) (*types.Transaction, map[string]interface{}, error) {
+	tx, ok := f.transactions[transaction.Hash]
+	if !ok {
+		return nil, nil, errMempoolTransactionNotFound
+	}
+
+	return tx, nil, nil
+}
+
+func (f *mockMempoolFetcher) NetworkStatusRetry(
+	ctx context.Context,
+	network *types.NetworkIdentifier,
+	metadata map[string]interface{},
+) (*types.NetworkStatusResponse, error) {
+	return &types.NetworkStatusResponse{
+		CurrentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(f.tip),
+			Index: f.tip,
+		},
+	}, nil
+}
+
+func mempoolTx(hash string, ops ...*types.Operation) *types.Transaction {
+	for i, op := range ops {
+		op.OperationIdentifier = &types.OperationIdentifier{Index: int64(i)}
+	}
+
+	return &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		Operations:            ops,
+	}
+}
+
+func TestMempoolChecker(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := storage.NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	blockStorage := storage.NewBlockStorage(database)
+	counterStorage := storage.NewCounterStorage(database)
+
+	tx1 := mempoolTx(
+		"tx1",
+		supplyOp("Transfer", "Success", "addr1", "-10"),
+		supplyOp("Transfer", "Success", "addr2", "10"),
+	)
+	tx2 := mempoolTx("tx2", supplyOp("Transfer", "Success", "addr1", "-5"))
+	fetcher := &mockMempoolFetcher{
+		mempool: []*types.TransactionIdentifier{
+			tx1.TransactionIdentifier,
+			tx2.TransactionIdentifier,
+			{Hash: "tx3"},
+		},
+		transactions: map[string]*types.Transaction{
+			"tx1": tx1,
+			"tx2": tx2,
+		},
+		tip: 5,
+	}
+
+	checker := NewMempoolChecker(
+		&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+		fetcher,
+		(&mockSupplyHelper{}).Asserter(),
+		blockStorage,
+		counterStorage,
+		[]storage.BlockWorker{NewRelatedOperationsChecker(nil)},
+		time.Second,
+		10,
+	)
+
+	t.Run("unable to fetch mempool", func(t *testing.T) {
+		fetcher.unavailable = true
+		assert.NoError(t, checker.Poll(ctx))
+		assert.Equal(t, 1, checker.failures)
+
+		count, err := counterStorage.Get(ctx, storage.MempoolTransactionCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(0), count)
+
+		fetcher.unavailable = false
+	})
+
+	t.Run("unable to fetch transaction in mempool", func(t *testing.T) {
+		// The poll is skipped until too many
+		// consecutive polls fail.
+		for i := checker.failures; i < maxMempoolFailures-1; i++ {
+			assert.NoError(t, checker.Poll(ctx))
+		}
+
+		err := checker.Poll(ctx)
+		assert.True(t, errors.Is(err, errMempoolTransactionNotFound))
+	})
+
+	t.Run("transaction left before fetch", func(t *testing.T) {
+		fetcher.mempool = fetcher.mempool[:2]
+		assert.NoError(t, checker.Poll(ctx))
+
+		count, err := counterStorage.Get(ctx, storage.MempoolTransactionCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(2), count)
+		assert.Equal(t, 0, checker.failures)
+	})
+
+	t.Run("transactions leave mempool", func(t *testing.T) {
+		fetcher.mempool = []*types.TransactionIdentifier{}
+		assert.NoError(t, checker.Poll(ctx))
+
+		// tx1 is included in a block but tx2 is
+		// not included within the inclusion window.
+		assert.NoError(t, blockStorage.AddBlock(ctx, &types.Block{
+			BlockIdentifier:       &types.BlockIdentifier{Hash: "block 14", Index: 14},
+			ParentBlockIdentifier: &types.BlockIdentifier{Hash: "block 13", Index: 13},
+			Transactions:          []*types.Transaction{tx1},
+		}))
+		assert.NoError(t, checker.Poll(ctx))
+
+		dropped, err := counterStorage.Get(ctx, storage.DroppedTransactionCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(0), dropped)
+		assert.Len(t, checker.transactions, 1)
+
+		assert.NoError(t, blockStorage.AddBlock(ctx, &types.Block{
+			BlockIdentifier:       &types.BlockIdentifier{Hash: "block 15", Index: 15},
+			ParentBlockIdentifier: &types.BlockIdentifier{Hash: "block 14", Index: 14},
+		}))
+		assert.NoError(t, checker.Poll(ctx))
+
+		dropped, err = counterStorage.Get(ctx, storage.DroppedTransactionCounter)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(1), dropped)
+		assert.Len(t, checker.transactions, 0)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		tx := mempoolTx("tx4", supplyOp("Transfer", "Pending", "addr1", "-5"))
+		fetcher.mempool = []*types.TransactionIdentifier{tx.TransactionIdentifier}
+		fetcher.transactions[tx.TransactionIdentifier.Hash] = tx
+
+		assert.Error(t, checker.Poll(ctx))
+	})
+
+	t.Run("invalid related operations", func(t *testing.T) {
+		tx := mempoolTx("tx5", supplyOp("Transfer", "Success", "addr1", "-5"))
+		tx.Operations[0].RelatedOperations = []*types.OperationIdentifier{{Index: 0}}
+		fetcher.mempool = []*types.TransactionIdentifier{tx.TransactionIdentifier}
+		fetcher.transactions[tx.TransactionIdentifier.Hash] = tx
+
+		assert.True(t, errors.Is(checker.Poll(ctx), ErrRelatedOperationSelf))
+	})
+}
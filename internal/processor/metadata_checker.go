@@ -0,0 +1,207 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+var _ storage.BlockWorker = (*MetadataChecker)(nil)
+
+var (
+	// ErrMetadataInvalid is returned when block, transaction, or
+	// operation metadata does not match its JSON Schema.
+	ErrMetadataInvalid = errors.New("metadata does not match schema")
+)
+
+// MetadataChecker is a storage.BlockWorker that validates the
+// metadata of each block added to storage (and the metadata of its
+// transactions and operations) against JSON Schemas.
+type MetadataChecker struct {
+	blockSchema       *gojsonschema.Schema
+	transactionSchema *gojsonschema.Schema
+	operationSchemas  map[string]*gojsonschema.Schema
+}
+
+// loadSchema loads the JSON Schema at path. If path
+// is empty, nil is returned.
+func loadSchema(path string) (*gojsonschema.Schema, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get absolute path of %s", err, path)
+	}
+
+	// Loading the schema by reference allows it to
+	// $ref other schemas relative to its path.
+	schema, err := gojsonschema.NewSchema(
+		gojsonschema.NewReferenceLoader("file://" + filepath.ToSlash(absPath)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load schema %s", err, path)
+	}
+
+	return schema, nil
+}
+
+// NewMetadataChecker returns a new *MetadataChecker using the JSON
+// Schema files at the provided paths. Metadata without a schema
+// (an empty path or an operation type not in operationSchemaFiles)
+// is not validated.
+func NewMetadataChecker(
+	blockSchemaFile string,
+	transactionSchemaFile string,
+	operationSchemaFiles map[string]string,
+) (*MetadataChecker, error) {
+	blockSchema, err := loadSchema(blockSchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load block schema", err)
+	}
+
+	transactionSchema, err := loadSchema(transactionSchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load transaction schema", err)
+	}
+
+	operationSchemas := map[string]*gojsonschema.Schema{}
+	for opType, path := range operationSchemaFiles {
+		schema, err := loadSchema(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load schema for operation type %s", err, opType)
+		}
+
+		operationSchemas[opType] = schema
+	}
+
+	return &MetadataChecker{
+		blockSchema:       blockSchema,
+		transactionSchema: transactionSchema,
+		operationSchemas:  operationSchemas,
+	}, nil
+}
+
+// TransactionChecker returns a *MetadataChecker that only
+// validates the metadata of transactions and operations (and
+// not of blocks). It is used to validate transactions that are
+// not in a block yet (like mempool transactions).
+func (c *MetadataChecker) TransactionChecker() *MetadataChecker {
+	return &MetadataChecker{
+		transactionSchema: c.transactionSchema,
+		operationSchemas:  c.operationSchemas,
+	}
+}
+
+// validateMetadata returns a description of each way metadata
+// does not match schema. Missing metadata is validated as an
+// empty object.
+func validateMetadata(
+	schema *gojsonschema.Schema,
+	metadata map[string]interface{},
+	description string,
+) ([]string, error) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(metadata))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to validate %s metadata", err, description)
+	}
+
+	violations := make([]string, len(result.Errors()))
+	for i, resultErr := range result.Errors() {
+		violations[i] = fmt.Sprintf("%s: %s", description, resultErr.String())
+	}
+
+	return violations, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+// All violations in a block are reported together.
+func (c *MetadataChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	violations, err := validateMetadata(c.blockSchema, block.Metadata, "block")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range block.Transactions {
+		txViolations, err := validateMetadata(
+			c.transactionSchema,
+			tx.Metadata,
+			fmt.Sprintf("transaction %s", tx.TransactionIdentifier.Hash),
+		)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, txViolations...)
+
+		for _, op := range tx.Operations {
+			opViolations, err := validateMetadata(
+				c.operationSchemas[op.Type],
+				op.Metadata,
+				fmt.Sprintf(
+					"operation %d in transaction %s",
+					op.OperationIdentifier.Index,
+					tx.TransactionIdentifier.Hash,
+				),
+			)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, opViolations...)
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, fmt.Errorf(
+			"%w: block %+v: %s",
+			ErrMetadataInvalid,
+			block.BlockIdentifier,
+			strings.Join(violations, "; "),
+		)
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Metadata is only checked when blocks are added.
+func (c *MetadataChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
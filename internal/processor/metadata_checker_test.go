@@ -0,0 +1,178 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	heightSchema = `{
+		"type": "object",
+		"required": ["height"],
+		"properties": {"height": {"type": "integer"}}
+	}`
+
+	// transferSchema references heightSchema to
+	// test relative references.
+	transferSchema = `{
+		"type": "object",
+		"required": ["memo"],
+		"properties": {
+			"memo": {"type": "string"},
+			"lock": {"$ref": "height.json"}
+		}
+	}`
+)
+
+func metadataBlock(
+	blockMetadata map[string]interface{},
+	txMetadata map[string]interface{},
+	ops ...*types.Operation,
+) *types.Block {
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 1",
+			Index: 1,
+		},
+		Metadata: blockMetadata,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: "tx 1",
+				},
+				Operations: ops,
+				Metadata:   txMetadata,
+			},
+		},
+	}
+}
+
+func metadataOp(opType string, metadata map[string]interface{}) *types.Operation {
+	return &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{
+			Index: 0,
+		},
+		Type:     opType,
+		Metadata: metadata,
+	}
+}
+
+func TestMetadataChecker(t *testing.T) {
+	var tests = map[string]struct {
+		block      *types.Block
+		violations []string
+	}{
+		"valid": {
+			block: metadataBlock(
+				map[string]interface{}{"height": 1},
+				nil,
+				metadataOp("transfer", map[string]interface{}{
+					"memo": "hello",
+					"lock": map[string]interface{}{"height": 10},
+				}),
+				metadataOp("fee", nil),
+			),
+		},
+		"missing block metadata": {
+			block:      metadataBlock(nil, nil),
+			violations: []string{"block: (root): height is required"},
+		},
+		"invalid block metadata": {
+			block:      metadataBlock(map[string]interface{}{"height": "1"}, nil),
+			violations: []string{"block: height"},
+		},
+		"invalid operation metadata": {
+			block: metadataBlock(
+				map[string]interface{}{"height": 1},
+				nil,
+				metadataOp("transfer", map[string]interface{}{
+					"memo": 1,
+					"lock": map[string]interface{}{},
+				}),
+			),
+			violations: []string{
+				"operation 0 in transaction tx 1: memo",
+				"operation 0 in transaction tx 1: lock",
+			},
+		},
+	}
+
+	dir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(dir)
+
+	heightPath := path.Join(dir, "height.json")
+	assert.NoError(t, ioutil.WriteFile(heightPath, []byte(heightSchema), 0600))
+
+	transferPath := path.Join(dir, "transfer.json")
+	assert.NoError(t, ioutil.WriteFile(transferPath, []byte(transferSchema), 0600))
+
+	checker, err := NewMetadataChecker(
+		heightPath,
+		"",
+		map[string]string{"transfer": transferPath},
+	)
+	assert.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := checker.AddingBlock(context.Background(), test.block, nil)
+			if len(test.violations) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrMetadataInvalid))
+			for _, violation := range test.violations {
+				assert.True(t, strings.Contains(err.Error(), violation), err.Error())
+			}
+		})
+	}
+
+	t.Run("transaction checker", func(t *testing.T) {
+		transactionChecker := checker.TransactionChecker()
+
+		// Block metadata is not validated.
+		_, err := transactionChecker.AddingBlock(
+			context.Background(),
+			metadataBlock(nil, nil),
+			nil,
+		)
+		assert.NoError(t, err)
+
+		_, err = transactionChecker.AddingBlock(
+			context.Background(),
+			metadataBlock(nil, nil, metadataOp("transfer", map[string]interface{}{"memo": 1})),
+			nil,
+		)
+		assert.True(t, errors.Is(err, ErrMetadataInvalid))
+	})
+
+	t.Run("missing schema", func(t *testing.T) {
+		_, err := NewMetadataChecker(path.Join(dir, "missing.json"), "", nil)
+		assert.Error(t, err)
+	})
+}
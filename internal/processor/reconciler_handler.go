@@ -26,6 +26,17 @@ import (
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
+// ReconciliationFailure contains all information reported
+// by the reconciler about a failed reconciliation.
+type ReconciliationFailure struct {
+	Type            string                   `json:"type"`
+	Account         *types.AccountIdentifier `json:"account_identifier"`
+	Currency        *types.Currency          `json:"currency"`
+	ComputedBalance string                   `json:"computed_balance"`
+	NodeBalance     string                   `json:"node_balance"`
+	Block           *types.BlockIdentifier   `json:"block_identifier"`
+}
+
 // ReconcilerHandler implements the Reconciler.Handler interface.
 type ReconcilerHandler struct {
 	logger                    *logger.Logger
@@ -35,6 +46,10 @@ type ReconcilerHandler struct {
 	InactiveFailureBlock *types.BlockIdentifier
 
 	ActiveFailureBlock *types.BlockIdentifier
+
+	// Failure is populated with the reconciliation failure
+	// that caused the run to halt.
+	Failure *ReconciliationFailure
 }
 
 // NewReconcilerHandler creates a new ReconcilerHandler.
@@ -74,6 +89,15 @@ func (h *ReconcilerHandler) ReconciliationFailed(
 	}
 
 	if h.haltOnReconciliationError {
+		h.Failure = &ReconciliationFailure{
+			Type:            reconciliationType,
+			Account:         account,
+			Currency:        currency,
+			ComputedBalance: computedBalance,
+			NodeBalance:     nodeBalance,
+			Block:           block,
+		}
+
 		if reconciliationType == reconciler.InactiveReconciliation {
 			// Populate inactive failure information so we can try to find block with
 			// missing ops.
@@ -17,9 +17,11 @@ package processor
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/coinbase/rosetta-cli/internal/storage"
 
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
@@ -73,11 +75,44 @@ func (h *ReconcilerHelper) CurrentBlock(
 // AccountBalance returns the balance of an account in block storage.
 // It is necessary to perform this check outside of the Reconciler
 // package to allow for separation from a default storage backend.
+//
+// If headBlock is orphaned while the balance is fetched, the balance
+// may already be reverted so reconciler.ErrBlockGone is returned
+// (which causes the reconciler to skip the comparison). The same
+// error is returned for accounts with any operation exempt from
+// balance tracking because their balance is never accurate.
 func (h *ReconcilerHelper) AccountBalance(
 	ctx context.Context,
 	account *types.AccountIdentifier,
 	currency *types.Currency,
 	headBlock *types.BlockIdentifier,
 ) (*types.Amount, *types.BlockIdentifier, error) {
-	return h.balanceStorage.GetBalance(ctx, account, currency, headBlock)
+	exempt, err := h.balanceStorage.Exempt(ctx, account, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if exempt {
+		return nil, nil, fmt.Errorf(
+			"%w: %s has exempt operations",
+			reconciler.ErrBlockGone,
+			types.PrettyPrintStruct(account),
+		)
+	}
+
+	amount, block, err := h.balanceStorage.GetBalance(ctx, account, currency, headBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exists, err := h.BlockExists(ctx, headBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !exists {
+		return nil, nil, fmt.Errorf("%w %+v", reconciler.ErrBlockGone, headBlock)
+	}
+
+	return amount, block, nil
 }
@@ -0,0 +1,140 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockExemptHelper exempts all operations
+// of an address from balance tracking.
+type mockExemptHelper struct {
+	mockSupplyHelper
+
+	address string
+}
+
+func (h *mockExemptHelper) ExemptFunc() parser.ExemptOperation {
+	return func(op *types.Operation) bool {
+		return op.Account.Address == h.address
+	}
+}
+
+func TestReconcilerHelperAccountBalance(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := storage.NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	balanceStorage := storage.NewBalanceStorage(database)
+	exemptAccount := &types.AccountIdentifier{Address: "exempt"}
+	balanceStorage.Initialize(
+		&mockExemptHelper{address: exemptAccount.Address},
+		&mockSupplyHandler{},
+	)
+
+	blockStorage := storage.NewBlockStorage(database)
+	blockStorage.Initialize([]storage.BlockWorker{balanceStorage})
+
+	genesis := &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+	}
+	block := &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 1",
+			Index: 1,
+		},
+		ParentBlockIdentifier: genesis.BlockIdentifier,
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              "Success",
+						Account:             exemptAccount,
+						Amount:              &types.Amount{Value: "100", Currency: supplyCurrency},
+					},
+				},
+			},
+		},
+	}
+	assert.NoError(t, blockStorage.AddBlock(ctx, genesis))
+	assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+	account := &types.AccountIdentifier{Address: "addr1"}
+	helper := NewReconcilerHelper(blockStorage, balanceStorage)
+
+	t.Run("head block exists", func(t *testing.T) {
+		amount, lastUpdated, err := helper.AccountBalance(
+			ctx,
+			account,
+			supplyCurrency,
+			block.BlockIdentifier,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, &types.Amount{Value: "0", Currency: supplyCurrency}, amount)
+		assert.Equal(t, block.BlockIdentifier, lastUpdated)
+	})
+
+	t.Run("exempt account", func(t *testing.T) {
+		amount, lastUpdated, err := helper.AccountBalance(
+			ctx,
+			exemptAccount,
+			supplyCurrency,
+			block.BlockIdentifier,
+		)
+		assert.True(t, errors.Is(err, reconciler.ErrBlockGone))
+		assert.Nil(t, amount)
+		assert.Nil(t, lastUpdated)
+	})
+
+	t.Run("head block orphaned", func(t *testing.T) {
+		assert.NoError(t, blockStorage.RemoveBlock(ctx, block.BlockIdentifier))
+
+		amount, lastUpdated, err := helper.AccountBalance(
+			ctx,
+			account,
+			supplyCurrency,
+			block.BlockIdentifier,
+		)
+		assert.True(t, errors.Is(err, reconciler.ErrBlockGone))
+		assert.Nil(t, amount)
+		assert.Nil(t, lastUpdated)
+	})
+}
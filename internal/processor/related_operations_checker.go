@@ -0,0 +1,200 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*RelatedOperationsChecker)(nil)
+
+var (
+	// ErrRelatedOperationMissing is returned when a related
+	// operation does not exist in the same transaction.
+	ErrRelatedOperationMissing = errors.New("related operation does not exist")
+
+	// ErrRelatedOperationSelf is returned when an operation
+	// is related to itself.
+	ErrRelatedOperationSelf = errors.New("operation is related to itself")
+
+	// ErrRelatedOperationCycle is returned when the related
+	// operations in a transaction form a cycle.
+	ErrRelatedOperationCycle = errors.New("related operations form a cycle")
+
+	// ErrOperationNotPaired is returned when an operation of a
+	// paired type is not linked to exactly one other operation
+	// of the same type.
+	ErrOperationNotPaired = errors.New("operation is not paired")
+)
+
+// RelatedOperationsChecker is a storage.BlockWorker that validates
+// the related operations of each transaction added to storage.
+type RelatedOperationsChecker struct {
+	pairedOperationTypes map[string]struct{}
+}
+
+// NewRelatedOperationsChecker returns a new *RelatedOperationsChecker.
+// Each operation with a type in pairedOperationTypes must be linked
+// (in either direction) to exactly one other operation of the same
+// type, like the debit and credit of a transfer.
+func NewRelatedOperationsChecker(pairedOperationTypes []string) *RelatedOperationsChecker {
+	paired := map[string]struct{}{}
+	for _, opType := range pairedOperationTypes {
+		paired[opType] = struct{}{}
+	}
+
+	return &RelatedOperationsChecker{
+		pairedOperationTypes: paired,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *RelatedOperationsChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	for _, tx := range block.Transactions {
+		if err := c.checkTransaction(tx); err != nil {
+			return nil, fmt.Errorf(
+				"%w: invalid related operations in transaction %s in block %+v",
+				err,
+				tx.TransactionIdentifier.Hash,
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	return nil, nil
+}
+
+// checkTransaction returns an error if the related operations
+// of a transaction do not form a valid graph.
+func (c *RelatedOperationsChecker) checkTransaction(tx *types.Transaction) error {
+	operations := map[int64]*types.Operation{}
+	for _, op := range tx.Operations {
+		operations[op.OperationIdentifier.Index] = op
+	}
+
+	// links contains the operations linked to each operation
+	// (in either direction).
+	links := map[int64][]int64{}
+	for _, op := range tx.Operations {
+		index := op.OperationIdentifier.Index
+		for _, related := range op.RelatedOperations {
+			if related.Index == index {
+				return fmt.Errorf("%w: operation %d", ErrRelatedOperationSelf, index)
+			}
+
+			if _, ok := operations[related.Index]; !ok {
+				return fmt.Errorf(
+					"%w: operation %d is related to operation %d",
+					ErrRelatedOperationMissing,
+					index,
+					related.Index,
+				)
+			}
+
+			links[index] = append(links[index], related.Index)
+			links[related.Index] = append(links[related.Index], index)
+		}
+	}
+
+	if err := checkRelatedOperationCycles(tx.Operations, operations); err != nil {
+		return err
+	}
+
+	for _, op := range tx.Operations {
+		if _, ok := c.pairedOperationTypes[op.Type]; !ok {
+			continue
+		}
+
+		pairs := 0
+		for _, linked := range links[op.OperationIdentifier.Index] {
+			if operations[linked].Type == op.Type {
+				pairs++
+			}
+		}
+
+		if pairs != 1 {
+			return fmt.Errorf(
+				"%w: operation %d of type %s is linked to %d operations of the same type",
+				ErrOperationNotPaired,
+				op.OperationIdentifier.Index,
+				op.Type,
+				pairs,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkRelatedOperationCycles returns an error if following
+// related operations from any operation leads back to it.
+func checkRelatedOperationCycles(
+	ops []*types.Operation,
+	operations map[int64]*types.Operation,
+) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := map[int64]int{}
+	var visit func(index int64) error
+	visit = func(index int64) error {
+		switch state[index] {
+		case visiting:
+			return fmt.Errorf("%w: operation %d", ErrRelatedOperationCycle, index)
+		case visited:
+			return nil
+		}
+
+		state[index] = visiting
+		for _, related := range operations[index].RelatedOperations {
+			if err := visit(related.Index); err != nil {
+				return err
+			}
+		}
+		state[index] = visited
+
+		return nil
+	}
+
+	for _, op := range ops {
+		if err := visit(op.OperationIdentifier.Index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Related operations are only checked when blocks are added.
+func (c *RelatedOperationsChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func relatedOp(index int64, opType string, related ...int64) *types.Operation {
+	op := &types.Operation{
+		OperationIdentifier: &types.OperationIdentifier{
+			Index: index,
+		},
+		Type: opType,
+	}
+
+	for _, relatedIndex := range related {
+		op.RelatedOperations = append(op.RelatedOperations, &types.OperationIdentifier{
+			Index: relatedIndex,
+		})
+	}
+
+	return op
+}
+
+func TestRelatedOperationsChecker(t *testing.T) {
+	var tests = map[string]struct {
+		pairedTypes []string
+		ops         []*types.Operation
+		err         error
+	}{
+		"no related operations": {
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer"),
+			},
+		},
+		"valid group": {
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer", 0),
+				relatedOp(2, "fee", 0, 1),
+			},
+		},
+		"missing related operation": {
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer", 2),
+			},
+			err: ErrRelatedOperationMissing,
+		},
+		"self reference": {
+			ops: []*types.Operation{
+				relatedOp(0, "transfer", 0),
+			},
+			err: ErrRelatedOperationSelf,
+		},
+		"cycle": {
+			ops: []*types.Operation{
+				relatedOp(0, "transfer", 2),
+				relatedOp(1, "transfer", 0),
+				relatedOp(2, "transfer", 1),
+			},
+			err: ErrRelatedOperationCycle,
+		},
+		"paired": {
+			pairedTypes: []string{"transfer"},
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer", 0),
+				relatedOp(2, "fee"),
+				relatedOp(3, "transfer"),
+				relatedOp(4, "transfer", 3, 2),
+			},
+		},
+		"not paired": {
+			pairedTypes: []string{"transfer"},
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer", 0),
+				relatedOp(2, "transfer"),
+			},
+			err: ErrOperationNotPaired,
+		},
+		"paired more than once": {
+			pairedTypes: []string{"transfer"},
+			ops: []*types.Operation{
+				relatedOp(0, "transfer"),
+				relatedOp(1, "transfer", 0),
+				relatedOp(2, "transfer", 0),
+			},
+			err: ErrOperationNotPaired,
+		},
+		"paired with other type": {
+			pairedTypes: []string{"transfer"},
+			ops: []*types.Operation{
+				relatedOp(0, "fee"),
+				relatedOp(1, "transfer", 0),
+			},
+			err: ErrOperationNotPaired,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			checker := NewRelatedOperationsChecker(test.pairedTypes)
+			block := &types.Block{
+				BlockIdentifier: &types.BlockIdentifier{
+					Hash:  "block 1",
+					Index: 1,
+				},
+				Transactions: []*types.Transaction{
+					{
+						TransactionIdentifier: &types.TransactionIdentifier{
+							Hash: "tx 1",
+						},
+						Operations: test.ops,
+					},
+				},
+			}
+
+			_, err := checker.AddingBlock(context.Background(), block, nil)
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+				assert.True(t, strings.Contains(err.Error(), "tx 1"))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
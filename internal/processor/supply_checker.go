@@ -0,0 +1,235 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*SupplyChecker)(nil)
+
+var (
+	// ErrSupplyMismatch is returned when the total supply of
+	// a currency is not equal to the expected supply.
+	ErrSupplyMismatch = errors.New("total supply does not match expected supply")
+
+	// ErrUnexpectedSupplyChange is returned when the total supply
+	// of a currency changes in operations that are not allowed
+	// to mint or burn.
+	ErrUnexpectedSupplyChange = errors.New("supply changed in non-mint/burn operations")
+)
+
+// ExpectedSupply is the total supply of some
+// currencies after the block at Index is added.
+type ExpectedSupply struct {
+	Index  int64           `json:"index"`
+	Supply []*types.Amount `json:"supply"`
+}
+
+// SupplyChecker is a storage.BlockWorker that checks
+// the total supply of each currency when a block is added.
+// It must run after BalanceStorage so that balance changes
+// in the block are already applied.
+type SupplyChecker struct {
+	balanceStorage *storage.BalanceStorage
+	asserter       *asserter.Asserter
+	exemptFunc     parser.ExemptOperation
+
+	// expectedSupply is checked after every block.
+	expectedSupply []*types.Amount
+
+	// expectedSupplyByBlock is checked after
+	// specific blocks.
+	expectedSupplyByBlock map[int64][]*types.Amount
+
+	// mintBurnTypes are the only operation types
+	// allowed to change supply.
+	mintBurnTypes map[string]struct{}
+}
+
+// NewSupplyChecker returns a new *SupplyChecker. Any combination
+// of expectedSupply, expectedSupplyByBlock, and mintBurnTypes
+// may be provided (empty values are not checked).
+func NewSupplyChecker(
+	balanceStorage *storage.BalanceStorage,
+	helper storage.BalanceStorageHelper,
+	expectedSupply []*types.Amount,
+	expectedSupplyByBlock []*ExpectedSupply,
+	mintBurnTypes []string,
+) *SupplyChecker {
+	supplyByBlock := map[int64][]*types.Amount{}
+	for _, expected := range expectedSupplyByBlock {
+		supplyByBlock[expected.Index] = expected.Supply
+	}
+
+	typeMap := map[string]struct{}{}
+	for _, opType := range mintBurnTypes {
+		typeMap[opType] = struct{}{}
+	}
+
+	return &SupplyChecker{
+		balanceStorage:        balanceStorage,
+		asserter:              helper.Asserter(),
+		exemptFunc:            helper.ExemptFunc(),
+		expectedSupply:        expectedSupply,
+		expectedSupplyByBlock: supplyByBlock,
+		mintBurnTypes:         typeMap,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (s *SupplyChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	if err := s.checkSupply(ctx, block, transaction, s.expectedSupply); err != nil {
+		return nil, err
+	}
+
+	expected, ok := s.expectedSupplyByBlock[block.BlockIdentifier.Index]
+	if ok {
+		if err := s.checkSupply(ctx, block, transaction, expected); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.mintBurnTypes) > 0 {
+		if err := s.checkSupplyChanges(block); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Supply is only checked when blocks are added.
+func (s *SupplyChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
+
+// checkSupply returns an error if the total supply of any
+// currency in expected does not match.
+func (s *SupplyChecker) checkSupply(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+	expected []*types.Amount,
+) error {
+	for _, amount := range expected {
+		supply, err := s.balanceStorage.GetSupply(ctx, transaction, amount.Currency)
+		if err != nil {
+			return fmt.Errorf("%w: unable to get supply of %+v", err, amount.Currency)
+		}
+
+		if supply.Value != amount.Value {
+			return fmt.Errorf(
+				"%w: supply of %+v is %s (expected %s) at %+v",
+				ErrSupplyMismatch,
+				amount.Currency,
+				supply.Value,
+				amount.Value,
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkSupplyChanges returns an error if the balance changes in
+// operations that are not mint/burn operations do not sum to zero
+// for each currency in a block.
+func (s *SupplyChecker) checkSupplyChanges(block *types.Block) error {
+	changes := map[string]string{}
+	currencies := map[string]*types.Currency{}
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if _, ok := s.mintBurnTypes[op.Type]; ok {
+				continue
+			}
+
+			skip, err := s.skipOperation(op)
+			if err != nil {
+				return err
+			}
+
+			if skip {
+				continue
+			}
+
+			key := types.Hash(op.Amount.Currency)
+			existing, ok := changes[key]
+			if !ok {
+				existing = "0"
+			}
+
+			newVal, err := types.AddValues(existing, op.Amount.Value)
+			if err != nil {
+				return err
+			}
+
+			changes[key] = newVal
+			currencies[key] = op.Amount.Currency
+		}
+	}
+
+	for key, change := range changes {
+		if change != "0" {
+			return fmt.Errorf(
+				"%w: supply of %+v changed by %s at %+v",
+				ErrUnexpectedSupplyChange,
+				currencies[key],
+				change,
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	return nil
+}
+
+// skipOperation returns a boolean indicating whether
+// an operation does not change any balance in storage
+// (mirroring parser.BalanceChanges).
+func (s *SupplyChecker) skipOperation(op *types.Operation) (bool, error) {
+	successful, err := s.asserter.OperationSuccessful(op)
+	if err != nil {
+		return false, err
+	}
+
+	if !successful || op.Account == nil || op.Amount == nil {
+		return true, nil
+	}
+
+	if s.exemptFunc != nil && s.exemptFunc(op) {
+		return true, nil
+	}
+
+	return false, nil
+}
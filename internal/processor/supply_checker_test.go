@@ -0,0 +1,268 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	supplyCurrency = &types.Currency{
+		Symbol:   "BTC",
+		Decimals: 8,
+	}
+)
+
+type mockSupplyHelper struct{}
+
+func (h *mockSupplyHelper) AccountBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) (*types.Amount, error) {
+	return &types.Amount{
+		Value:    "0",
+		Currency: currency,
+	}, nil
+}
+
+func (h *mockSupplyHelper) Asserter() *asserter.Asserter {
+	a, _ := asserter.NewClientWithOptions(
+		&types.NetworkIdentifier{
+			Blockchain: "bitcoin",
+			Network:    "mainnet",
+		},
+		&types.BlockIdentifier{
+			Hash:  "block 0",
+			Index: 0,
+		},
+		[]string{"Transfer", "Mint"},
+		[]*types.OperationStatus{
+			{
+				Status:     "Success",
+				Successful: true,
+			},
+			{
+				Status:     "Failure",
+				Successful: false,
+			},
+		},
+		[]*types.Error{},
+	)
+	return a
+}
+
+func (h *mockSupplyHelper) ExemptFunc() parser.ExemptOperation {
+	return nil
+}
+
+type mockSupplyHandler struct{}
+
+func (h *mockSupplyHandler) BlockAdded(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func (h *mockSupplyHandler) BlockRemoved(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func supplyBlock(index int64, ops ...*types.Operation) *types.Block {
+	for i, op := range ops {
+		op.OperationIdentifier = &types.OperationIdentifier{Index: int64(i)}
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index),
+			Index: index,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index - 1),
+			Index: index - 1,
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: types.Hash(index),
+				},
+				Operations: ops,
+			},
+		},
+	}
+}
+
+func supplyOp(opType string, status string, address string, value string) *types.Operation {
+	return &types.Operation{
+		Type:   opType,
+		Status: status,
+		Account: &types.AccountIdentifier{
+			Address: address,
+		},
+		Amount: &types.Amount{
+			Value:    value,
+			Currency: supplyCurrency,
+		},
+	}
+}
+
+func TestSupplyChecker(t *testing.T) {
+	var tests = map[string]struct {
+		expectedSupply        []*types.Amount
+		expectedSupplyByBlock []*ExpectedSupply
+		mintBurnTypes         []string
+
+		blocks []*types.Block
+		err    error
+	}{
+		"fixed supply": {
+			expectedSupply: []*types.Amount{
+				{Value: "100", Currency: supplyCurrency},
+			},
+			blocks: []*types.Block{
+				supplyBlock(1, supplyOp("Transfer", "Success", "addr1", "100")),
+				supplyBlock(
+					2,
+					supplyOp("Transfer", "Success", "addr1", "-40"),
+					supplyOp("Transfer", "Success", "addr2", "40"),
+				),
+			},
+		},
+		"fixed supply inflation": {
+			expectedSupply: []*types.Amount{
+				{Value: "100", Currency: supplyCurrency},
+			},
+			blocks: []*types.Block{
+				supplyBlock(1, supplyOp("Transfer", "Success", "addr1", "100")),
+				supplyBlock(
+					2,
+					supplyOp("Transfer", "Success", "addr1", "-40"),
+					supplyOp("Transfer", "Success", "addr2", "41"),
+				),
+			},
+			err: ErrSupplyMismatch,
+		},
+		"supply by block": {
+			expectedSupplyByBlock: []*ExpectedSupply{
+				{
+					Index:  2,
+					Supply: []*types.Amount{{Value: "150", Currency: supplyCurrency}},
+				},
+			},
+			blocks: []*types.Block{
+				supplyBlock(1, supplyOp("Mint", "Success", "addr1", "100")),
+				supplyBlock(2, supplyOp("Mint", "Success", "addr1", "50")),
+			},
+		},
+		"supply by block mismatch": {
+			expectedSupplyByBlock: []*ExpectedSupply{
+				{
+					Index:  1,
+					Supply: []*types.Amount{{Value: "50", Currency: supplyCurrency}},
+				},
+			},
+			blocks: []*types.Block{
+				supplyBlock(1, supplyOp("Mint", "Success", "addr1", "100")),
+			},
+			err: ErrSupplyMismatch,
+		},
+		"mint allowed": {
+			mintBurnTypes: []string{"Mint"},
+			blocks: []*types.Block{
+				supplyBlock(
+					1,
+					supplyOp("Mint", "Success", "addr1", "100"),
+					supplyOp("Transfer", "Success", "addr1", "-40"),
+					supplyOp("Transfer", "Success", "addr2", "40"),
+					supplyOp("Transfer", "Failure", "addr2", "1000"),
+				),
+			},
+		},
+		"transfer mints": {
+			mintBurnTypes: []string{"Mint"},
+			blocks: []*types.Block{
+				supplyBlock(
+					1,
+					supplyOp("Mint", "Success", "addr1", "100"),
+					supplyOp("Transfer", "Success", "addr1", "-40"),
+					supplyOp("Transfer", "Success", "addr2", "50"),
+				),
+			},
+			err: ErrUnexpectedSupplyChange,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newDir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(newDir)
+
+			database, err := storage.NewBadgerStorage(ctx, newDir)
+			assert.NoError(t, err)
+			defer database.Close(ctx)
+
+			helper := &mockSupplyHelper{}
+			balanceStorage := storage.NewBalanceStorage(database)
+			balanceStorage.Initialize(helper, &mockSupplyHandler{})
+			balanceStorage.EnableSupply()
+
+			blockStorage := storage.NewBlockStorage(database)
+			blockStorage.Initialize([]storage.BlockWorker{
+				balanceStorage,
+				NewSupplyChecker(
+					balanceStorage,
+					helper,
+					test.expectedSupply,
+					test.expectedSupplyByBlock,
+					test.mintBurnTypes,
+				),
+			})
+
+			for _, block := range test.blocks {
+				err = blockStorage.AddBlock(ctx, block)
+				if err != nil {
+					break
+				}
+			}
+
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
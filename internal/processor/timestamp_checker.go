@@ -0,0 +1,152 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ storage.BlockWorker = (*TimestampChecker)(nil)
+
+var (
+	// ErrTimestampDecreased is returned when a block timestamp
+	// is less than the timestamp of its parent.
+	ErrTimestampDecreased = errors.New("block timestamp is less than parent timestamp")
+
+	// ErrTimestampInFuture is returned when a block timestamp
+	// is further ahead of the local clock than allowed.
+	ErrTimestampInFuture = errors.New("block timestamp is too far in the future")
+
+	// ErrBlockIntervalOutOfRange is returned when the time between
+	// a block and its parent is outside of the configured range.
+	ErrBlockIntervalOutOfRange = errors.New("block interval is out of range")
+)
+
+// TimestampChecker is a storage.BlockWorker that validates
+// the timestamp of each block added to storage. The asserter
+// already rejects timestamps before 2000 (which are almost
+// certainly in seconds instead of milliseconds) when blocks
+// are fetched.
+type TimestampChecker struct {
+	blockStorage *storage.BlockStorage
+
+	decreaseAllowed bool
+	maxSkew         int64
+	minInterval     int64
+	maxInterval     int64
+
+	// now returns the current time (overridden in tests).
+	now func() time.Time
+}
+
+// NewTimestampChecker returns a new *TimestampChecker. All
+// durations are in milliseconds and an interval of 0 is not
+// checked.
+func NewTimestampChecker(
+	blockStorage *storage.BlockStorage,
+	decreaseAllowed bool,
+	maxSkew int64,
+	minInterval int64,
+	maxInterval int64,
+) *TimestampChecker {
+	return &TimestampChecker{
+		blockStorage:    blockStorage,
+		decreaseAllowed: decreaseAllowed,
+		maxSkew:         maxSkew,
+		minInterval:     minInterval,
+		maxInterval:     maxInterval,
+		now:             time.Now,
+	}
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (c *TimestampChecker) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	now := c.now().UnixNano() / int64(time.Millisecond)
+	if block.Timestamp > now+c.maxSkew {
+		return nil, fmt.Errorf(
+			"%w: timestamp %d of %+v is %d ms ahead of local time",
+			ErrTimestampInFuture,
+			block.Timestamp,
+			block.BlockIdentifier,
+			block.Timestamp-now,
+		)
+	}
+
+	// The genesis block often has an arbitrary timestamp
+	// (like 0), so it is not compared to other blocks.
+	if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+		return nil, nil
+	}
+
+	// The parent is not stored when syncing
+	// does not start at genesis.
+	parent, err := c.blockStorage.GetBlock(ctx, block.ParentBlockIdentifier)
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get parent block", err)
+	}
+
+	if parent.BlockIdentifier.Index == parent.ParentBlockIdentifier.Index {
+		return nil, nil
+	}
+
+	interval := block.Timestamp - parent.Timestamp
+	if interval < 0 && !c.decreaseAllowed {
+		return nil, fmt.Errorf(
+			"%w: timestamp %d of %+v is less than timestamp %d of %+v",
+			ErrTimestampDecreased,
+			block.Timestamp,
+			block.BlockIdentifier,
+			parent.Timestamp,
+			parent.BlockIdentifier,
+		)
+	}
+
+	if (c.minInterval > 0 && interval < c.minInterval) ||
+		(c.maxInterval > 0 && interval > c.maxInterval) {
+		return nil, fmt.Errorf(
+			"%w: %d ms between %+v and %+v",
+			ErrBlockIntervalOutOfRange,
+			interval,
+			parent.BlockIdentifier,
+			block.BlockIdentifier,
+		)
+	}
+
+	return nil, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+// Timestamps are only checked when blocks are added.
+func (c *TimestampChecker) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction storage.DatabaseTransaction,
+) (storage.CommitWorker, error) {
+	return nil, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// timestampNow is the local time used in tests
+	// (in milliseconds).
+	timestampNow = int64(1600000000000)
+)
+
+func timestampBlock(index int64, timestamp int64) *types.Block {
+	parentIndex := index - 1
+	if index == 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index),
+			Index: index,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(parentIndex),
+			Index: parentIndex,
+		},
+		Timestamp: timestamp,
+	}
+}
+
+func TestTimestampChecker(t *testing.T) {
+	var tests = map[string]struct {
+		decreaseAllowed bool
+		minInterval     int64
+		maxInterval     int64
+
+		blocks []*types.Block
+		err    error
+	}{
+		"valid": {
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-2000),
+				timestampBlock(2, timestampNow-1000),
+				timestampBlock(3, timestampNow+1000),
+			},
+		},
+		"in future": {
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow+2000),
+			},
+			err: ErrTimestampInFuture,
+		},
+		"decreased": {
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-1000),
+				timestampBlock(2, timestampNow-2000),
+			},
+			err: ErrTimestampDecreased,
+		},
+		"decrease allowed": {
+			decreaseAllowed: true,
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-1000),
+				timestampBlock(2, timestampNow-2000),
+			},
+		},
+		"interval too short": {
+			minInterval: 1000,
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-1000),
+				timestampBlock(2, timestampNow-500),
+			},
+			err: ErrBlockIntervalOutOfRange,
+		},
+		"interval too long": {
+			maxInterval: 1000,
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-5000),
+				timestampBlock(2, timestampNow-1000),
+			},
+			err: ErrBlockIntervalOutOfRange,
+		},
+		"interval in range": {
+			minInterval: 1000,
+			maxInterval: 3000,
+			blocks: []*types.Block{
+				timestampBlock(0, 0),
+				timestampBlock(1, timestampNow-5000),
+				timestampBlock(2, timestampNow-3000),
+			},
+		},
+		"start after genesis": {
+			maxInterval: 1000,
+			blocks: []*types.Block{
+				timestampBlock(10, timestampNow-5000),
+				timestampBlock(11, timestampNow-4000),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newDir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(newDir)
+
+			database, err := storage.NewBadgerStorage(ctx, newDir)
+			assert.NoError(t, err)
+			defer database.Close(ctx)
+
+			blockStorage := storage.NewBlockStorage(database)
+			checker := NewTimestampChecker(
+				blockStorage,
+				test.decreaseAllowed,
+				1000,
+				test.minInterval,
+				test.maxInterval,
+			)
+			checker.now = func() time.Time {
+				return time.Unix(0, timestampNow*int64(time.Millisecond))
+			}
+			blockStorage.Initialize([]storage.BlockWorker{checker})
+
+			for _, block := range test.blocks {
+				err = blockStorage.AddBlock(ctx, block)
+				if err != nil {
+					break
+				}
+			}
+
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
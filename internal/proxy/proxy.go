@@ -0,0 +1,276 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// errorCodes are the HTTP status codes returned
+	// when injecting an error.
+	errorCodes = []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// Faults configures the rate (in [0, 1]) at which each
+// type of fault is injected into proxied requests.
+type Faults struct {
+	// LatencyRate is the rate at which Latency is
+	// added before forwarding a request.
+	LatencyRate float64
+	Latency     time.Duration
+
+	// ErrorRate is the rate at which a 5xx error is
+	// returned instead of forwarding a request.
+	ErrorRate float64
+
+	// TimeoutRate is the rate at which a request is held
+	// (without forwarding it) until the client gives up
+	// or Timeout elapses.
+	TimeoutRate float64
+	Timeout     time.Duration
+
+	// TruncateRate is the rate at which only half of a
+	// response body is returned.
+	TruncateRate float64
+
+	// ReorderRate is the rate at which a response is held
+	// until another response is returned (or ReorderWindow
+	// elapses), so that concurrent requests are answered
+	// out of order.
+	ReorderRate   float64
+	ReorderWindow time.Duration
+}
+
+// Validate ensures all rates are in [0, 1].
+func (f *Faults) Validate() error {
+	rates := map[string]float64{
+		"latency":  f.LatencyRate,
+		"error":    f.ErrorRate,
+		"timeout":  f.TimeoutRate,
+		"truncate": f.TruncateRate,
+		"reorder":  f.ReorderRate,
+	}
+
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate %f must be in [0, 1]", name, rate)
+		}
+	}
+
+	return nil
+}
+
+// Proxy is an http.Handler that forwards requests to a
+// Rosetta server and injects faults into some of them.
+type Proxy struct {
+	target *url.URL
+	faults *Faults
+	client *http.Client
+
+	random      *rand.Rand
+	randomMutex sync.Mutex
+
+	// responded is closed (and replaced) each time
+	// a response is returned so that reordered
+	// responses can be released.
+	responded      chan struct{}
+	respondedMutex sync.Mutex
+}
+
+// New returns a new *Proxy that forwards requests to
+// target using transport (or http.DefaultTransport if
+// nil). The seed is used to determine which requests
+// faults are injected into.
+func New(
+	target *url.URL,
+	faults *Faults,
+	seed int64,
+	transport http.RoundTripper,
+) *Proxy {
+	return &Proxy{
+		target:    target,
+		faults:    faults,
+		client:    &http.Client{Transport: transport},
+		random:    rand.New(rand.NewSource(seed)),
+		responded: make(chan struct{}),
+	}
+}
+
+// inject returns true with probability rate.
+func (p *Proxy) inject(rate float64) bool {
+	if rate == 0 {
+		return false
+	}
+
+	p.randomMutex.Lock()
+	defer p.randomMutex.Unlock()
+
+	return p.random.Float64() < rate
+}
+
+// waitForResponse returns a channel that is closed
+// when the next response is returned.
+func (p *Proxy) waitForResponse() <-chan struct{} {
+	p.respondedMutex.Lock()
+	defer p.respondedMutex.Unlock()
+
+	return p.responded
+}
+
+// signalResponse releases any held responses.
+func (p *Proxy) signalResponse() {
+	p.respondedMutex.Lock()
+	defer p.respondedMutex.Unlock()
+
+	close(p.responded)
+	p.responded = make(chan struct{})
+}
+
+// ServeHTTP forwards a request to the target and
+// injects faults.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer p.signalResponse()
+
+	// The request context is only cancelled when the client
+	// disconnects if the request body has been read.
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if p.inject(p.faults.TimeoutRate) {
+		log.Printf("injecting timeout on %s\n", r.URL.Path)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(p.faults.Timeout):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+
+		return
+	}
+
+	if p.inject(p.faults.ErrorRate) {
+		p.randomMutex.Lock()
+		code := errorCodes[p.random.Intn(len(errorCodes))]
+		p.randomMutex.Unlock()
+
+		log.Printf("injecting %d error on %s\n", code, r.URL.Path)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+
+	if p.inject(p.faults.LatencyRate) {
+		log.Printf("injecting %s latency on %s\n", p.faults.Latency, r.URL.Path)
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(p.faults.Latency):
+		}
+	}
+
+	resp, err := p.forward(r, requestBody)
+	if err != nil {
+		log.Printf("%s: unable to forward request to %s\n", err.Error(), r.URL.Path)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if p.inject(p.faults.ReorderRate) {
+		log.Printf("injecting reorder on %s\n", r.URL.Path)
+		select {
+		case <-p.waitForResponse():
+		case <-time.After(p.faults.ReorderWindow):
+		}
+	}
+
+	for key, values := range resp.header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	body := resp.body
+	if p.inject(p.faults.TruncateRate) {
+		log.Printf("injecting truncated body on %s\n", r.URL.Path)
+
+		// We set the Content-Length to the length of the full
+		// body so the client detects the truncation.
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		body = body[:len(body)/2]
+	}
+
+	w.WriteHeader(resp.statusCode)
+	_, _ = w.Write(body)
+}
+
+// response is a response from the target.
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// forward makes a request to the target
+// and reads the response.
+func (p *Proxy) forward(r *http.Request, requestBody []byte) (*response, error) {
+	targetURL := *p.target
+	targetURL.Path = strings.TrimSuffix(p.target.Path, "/") + r.URL.Path
+	req, err := http.NewRequestWithContext(
+		r.Context(),
+		r.Method,
+		targetURL.String(),
+		bytes.NewReader(requestBody),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create request", err)
+	}
+	req.Header = r.Header.Clone()
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read response body", err)
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+	return &response{
+		statusCode: resp.StatusCode,
+		header:     header,
+		body:       body,
+	}, nil
+}
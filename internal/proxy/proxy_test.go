@@ -0,0 +1,209 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/connection"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testBody = `{"current_block_identifier":{"index":100,"hash":"block 100"}}`
+)
+
+func newTestProxy(t *testing.T, faults *Faults) (*httptest.Server, func()) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/echo" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, testBody)
+	}))
+
+	targetURL, err := url.Parse(target.URL)
+	assert.NoError(t, err)
+
+	proxy := httptest.NewServer(New(targetURL, faults, 1, nil))
+	return proxy, func() {
+		proxy.Close()
+		target.Close()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, (&Faults{ErrorRate: 1, LatencyRate: 0.5}).Validate())
+	assert.Error(t, (&Faults{ErrorRate: 1.5}).Validate())
+	assert.Error(t, (&Faults{TruncateRate: -0.1}).Validate())
+}
+
+func TestProxyTransport(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Api-Key"))
+	}))
+	defer target.Close()
+
+	targetURL, err := url.Parse(target.URL)
+	assert.NoError(t, err)
+
+	transport, err := connection.NewTransport(&configuration.ConnectionConfiguration{
+		Headers: map[string]string{"x-api-key": "key"},
+	})
+	assert.NoError(t, err)
+
+	proxy := httptest.NewServer(New(targetURL, &Faults{}, 1, transport))
+	defer proxy.Close()
+
+	resp, err := http.Post(proxy.URL+"/block", "application/json", bytes.NewBufferString(`{}`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "key", string(body))
+}
+
+func TestProxy(t *testing.T) {
+	var tests = map[string]struct {
+		faults *Faults
+		client *http.Client
+
+		expectedStatus []int
+		expectedBody   string
+		expectedErr    bool
+	}{
+		"no faults": {
+			faults:         &Faults{},
+			expectedStatus: []int{http.StatusOK},
+			expectedBody:   testBody,
+		},
+		"errors": {
+			faults:         &Faults{ErrorRate: 1},
+			expectedStatus: errorCodes,
+		},
+		"latency": {
+			faults: &Faults{LatencyRate: 1, Latency: 10 * time.Second},
+			client: &http.Client{Timeout: 100 * time.Millisecond},
+
+			expectedErr: true,
+		},
+		"timeout (client gives up)": {
+			faults: &Faults{TimeoutRate: 1, Timeout: time.Minute},
+			client: &http.Client{Timeout: 100 * time.Millisecond},
+
+			expectedErr: true,
+		},
+		"timeout (proxy gives up)": {
+			faults:         &Faults{TimeoutRate: 1, Timeout: 10 * time.Millisecond},
+			expectedStatus: []int{http.StatusGatewayTimeout},
+		},
+		"truncated body": {
+			faults:      &Faults{TruncateRate: 1},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxy, closer := newTestProxy(t, test.faults)
+			defer closer()
+
+			client := test.client
+			if client == nil {
+				client = &http.Client{}
+			}
+
+			resp, err := client.Post(
+				proxy.URL+"/network/status",
+				"application/json",
+				bytes.NewBufferString(`{}`),
+			)
+			if err == nil {
+				var body []byte
+				body, err = ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				if err == nil {
+					assert.Contains(t, test.expectedStatus, resp.StatusCode)
+					if len(test.expectedBody) > 0 {
+						assert.Equal(t, test.expectedBody, string(body))
+					}
+				}
+			}
+
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReorder(t *testing.T) {
+	// With a seed of 1, the first draw is ~0.60 and the
+	// second draw is ~0.94, so only the first response
+	// is held.
+	proxy, closer := newTestProxy(t, &Faults{
+		ReorderRate:   0.7,
+		ReorderWindow: time.Minute,
+	})
+	defer closer()
+
+	// The first response should be held until the second
+	// request is answered.
+	start := time.Now()
+	durations := make([]time.Duration, 2)
+	var wg sync.WaitGroup
+	for i, delay := range []time.Duration{0, 50 * time.Millisecond} {
+		wg.Add(1)
+		go func(i int, delay time.Duration) {
+			defer wg.Done()
+			time.Sleep(delay)
+
+			resp, err := http.Post(
+				proxy.URL+"/echo",
+				"application/json",
+				bytes.NewBufferString(fmt.Sprintf(`{"request":%d}`, i)),
+			)
+			assert.NoError(t, err)
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, fmt.Sprintf(`{"request":%d}`, i), string(body))
+			durations[i] = time.Since(start)
+		}(i, delay)
+	}
+	wg.Wait()
+
+	assert.True(t, durations[0] >= 50*time.Millisecond)
+	assert.True(t, durations[0] < time.Minute)
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// initialBackoff is the time all requests are paused
+	// after the first throttled response.
+	initialBackoff = time.Second
+
+	// maxBackoff is the longest time all requests are paused
+	// after consecutive throttled responses (unless the server
+	// requests a longer pause with the Retry-After header).
+	maxBackoff = time.Minute
+
+	// maxThrottledRetries is the number of times a throttled
+	// request is retried before the throttled response is
+	// returned.
+	maxThrottledRetries = 10
+)
+
+// Transport is an http.RoundTripper that limits the rate of
+// requests made with the underlying transport. When the server
+// responds with 429 Too Many Requests, all requests are paused
+// (for the duration in the Retry-After header or an exponentially
+// increasing backoff) and the throttled request is retried.
+type Transport struct {
+	transport http.RoundTripper
+	limiter   *rate.Limiter
+
+	mutex       sync.Mutex
+	backoff     time.Duration
+	pausedUntil time.Time
+}
+
+// NewTransport returns a new *Transport that makes requestsPerSecond
+// requests on average with bursts of up to burst requests. If
+// requestsPerSecond is 0, the rate of requests is not limited. If
+// transport is nil, http.DefaultTransport is used to make requests.
+func NewTransport(
+	requestsPerSecond float64,
+	burst int,
+	transport http.RoundTripper,
+) *Transport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	limit := rate.Limit(requestsPerSecond)
+	if requestsPerSecond == 0 {
+		limit = rate.Inf
+	}
+
+	return &Transport{
+		transport: transport,
+		limiter:   rate.NewLimiter(limit, burst),
+	}
+}
+
+// RoundTrip waits until the rate limit allows another request
+// and then makes the request using the underlying transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The request body is read so that it can
+	// be sent again if the request is throttled.
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read request body", err)
+		}
+		req.Body.Close()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		if requestBody != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+		}
+
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.resetBackoff()
+			return resp, nil
+		}
+
+		if attempt == maxThrottledRetries {
+			return resp, nil
+		}
+
+		pause := t.pause(resp.Header.Get("Retry-After"))
+		log.Printf(
+			"%s request throttled by server, retrying after %s\n",
+			req.URL.Path,
+			pause,
+		)
+
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// wait blocks until requests are no longer paused
+// and the rate limit allows another request.
+func (t *Transport) wait(ctx context.Context) error {
+	t.mutex.Lock()
+	pause := time.Until(t.pausedUntil)
+	t.mutex.Unlock()
+
+	if pause > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+
+	return t.limiter.Wait(ctx)
+}
+
+// pause pauses all requests after a throttled response and
+// returns the duration of the pause. If requests are already
+// paused (because concurrent requests were throttled), the
+// backoff is not increased.
+func (t *Transport) pause(retryAfter string) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	if now.Before(t.pausedUntil) {
+		return t.pausedUntil.Sub(now)
+	}
+
+	switch {
+	case t.backoff == 0:
+		t.backoff = initialBackoff
+	case t.backoff < maxBackoff:
+		t.backoff *= 2
+		if t.backoff > maxBackoff {
+			t.backoff = maxBackoff
+		}
+	}
+
+	pause := t.backoff
+	if serverPause, ok := parseRetryAfter(retryAfter, now); ok {
+		pause = serverPause
+	}
+
+	t.pausedUntil = now.Add(pause)
+	return pause
+}
+
+// resetBackoff resets the backoff after
+// a request is not throttled.
+func (t *Transport) resetBackoff() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.backoff = 0
+}
+
+// parseRetryAfter returns the duration in a Retry-After
+// header (in seconds or as an HTTP date) and a boolean
+// indicating if the header could be parsed.
+func parseRetryAfter(retryAfter string, now time.Time) (time.Duration, bool) {
+	if len(retryAfter) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(retryAfter)
+	if err != nil {
+		return 0, false
+	}
+
+	if date.Before(now) {
+		return 0, true
+	}
+
+	return date.Sub(now), true
+}
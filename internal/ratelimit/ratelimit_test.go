@@ -0,0 +1,184 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/connection"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func post(t *testing.T, client *http.Client, url string, body string) (int, string) {
+	resp, err := client.Post(url, "application/json", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(respBody)
+}
+
+func TestTransport(t *testing.T) {
+	calls := 0
+	throttled := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if throttled > 0 {
+			throttled--
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"calls":%d,"request":%s}`, calls, body)
+	}))
+	defer server.Close()
+
+	t.Run("limit requests", func(t *testing.T) {
+		client := &http.Client{Transport: NewTransport(20, 1, nil)}
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			code, _ := post(t, client, server.URL+"/block", `{}`)
+			assert.Equal(t, http.StatusOK, code)
+		}
+
+		// The first request is allowed immediately
+		// and each other request waits 50ms.
+		assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	})
+
+	t.Run("retry throttled requests", func(t *testing.T) {
+		calls = 0
+		throttled = 2
+		client := &http.Client{Transport: NewTransport(0, 1, nil)}
+
+		code, resp := post(t, client, server.URL+"/block", `{"index":1}`)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, `{"calls":3,"request":{"index":1}}`, resp)
+	})
+
+	t.Run("too many throttled requests", func(t *testing.T) {
+		calls = 0
+		throttled = maxThrottledRetries + 1
+		client := &http.Client{Transport: NewTransport(0, 1, nil)}
+
+		code, _ := post(t, client, server.URL+"/block", `{}`)
+		assert.Equal(t, http.StatusTooManyRequests, code)
+		assert.Equal(t, maxThrottledRetries+1, calls)
+	})
+}
+
+func TestTransportTimeout(t *testing.T) {
+	throttled := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if throttled {
+			throttled = false
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	// The pause requested by the server is longer than
+	// the HTTP timeout but is not counted against it.
+	client := &http.Client{Transport: NewTransport(
+		0,
+		1,
+		connection.NewTimeoutTransport(100*time.Millisecond, nil),
+	)}
+
+	start := time.Now()
+	code, resp := post(t, client, server.URL+"/block", `{}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{}`, resp)
+	assert.True(t, time.Since(start) >= time.Second)
+}
+
+func TestPause(t *testing.T) {
+	transport := NewTransport(0, 1, nil)
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		assert.Equal(t, initialBackoff, transport.pause(""))
+
+		transport.pausedUntil = time.Time{}
+		assert.Equal(t, 2*initialBackoff, transport.pause(""))
+
+		// Requests throttled while paused do
+		// not increase the backoff.
+		assert.True(t, transport.pause("") <= 2*initialBackoff)
+		assert.Equal(t, 2*initialBackoff, transport.backoff)
+
+		transport.resetBackoff()
+		transport.pausedUntil = time.Time{}
+		assert.Equal(t, initialBackoff, transport.pause(""))
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		transport.pausedUntil = time.Time{}
+		assert.Equal(t, 5*time.Second, transport.pause("5"))
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	var tests = map[string]struct {
+		retryAfter string
+
+		pause time.Duration
+		ok    bool
+	}{
+		"empty": {},
+		"seconds": {
+			retryAfter: "30",
+			pause:      30 * time.Second,
+			ok:         true,
+		},
+		"date": {
+			retryAfter: now.Add(time.Minute).Format(http.TimeFormat),
+			pause:      time.Minute,
+			ok:         true,
+		},
+		"past date": {
+			retryAfter: now.Add(-time.Minute).Format(http.TimeFormat),
+			ok:         true,
+		},
+		"invalid": {
+			retryAfter: "soon",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pause, ok := parseRetryAfter(test.retryAfter, now)
+			assert.Equal(t, test.pause, pause)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// replayMissCode is the Rosetta error code returned
+	// when a request is not found in a fixture.
+	replayMissCode = 0
+
+	// maxEntrySize is the largest fixture entry (in bytes)
+	// that can be read during replay.
+	maxEntrySize = 512 * 1024 * 1024
+)
+
+// Entry is a single request and response
+// saved in a fixture.
+type Entry struct {
+	Endpoint   string          `json:"endpoint"`
+	Request    json.RawMessage `json:"request"`
+	StatusCode int             `json:"status_code"`
+	Response   json.RawMessage `json:"response"`
+}
+
+// key returns the string used to look up an *Entry
+// during replay. Requests are hashed using types.Hash so that
+// the ordering of JSON fields does not matter.
+func key(endpoint string, request []byte) (string, error) {
+	var r interface{}
+	if err := json.Unmarshal(request, &r); err != nil {
+		return "", fmt.Errorf("%w: unable to unmarshal request to %s", err, endpoint)
+	}
+
+	return fmt.Sprintf("%s:%s", endpoint, types.Hash(r)), nil
+}
+
+// Recorder is an http.RoundTripper that saves every request
+// and response made to a Rosetta server in a gzipped fixture
+// of newline-delimited JSON.
+type Recorder struct {
+	transport http.RoundTripper
+
+	file   *os.File
+	writer *gzip.Writer
+
+	mutex sync.Mutex
+}
+
+// NewRecorder returns a new *Recorder that writes to
+// fixturePath. If transport is nil, http.DefaultTransport
+// is used to make requests.
+func NewRecorder(fixturePath string, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	file, err := os.Create(fixturePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create fixture %s", err, fixturePath)
+	}
+
+	return &Recorder{
+		transport: transport,
+		file:      file,
+		writer:    gzip.NewWriter(file),
+	}, nil
+}
+
+// RoundTrip makes a request using the underlying transport
+// and saves the request and response to the fixture.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read request body", err)
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read response body", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	// We don't record responses that are not valid JSON (like
+	// those returned by a load balancer) because they will be
+	// retried by the fetcher anyways.
+	if !json.Valid(requestBody) || !json.Valid(responseBody) {
+		return resp, nil
+	}
+
+	if err := r.write(&Entry{
+		Endpoint:   req.URL.Path,
+		Request:    requestBody,
+		StatusCode: resp.StatusCode,
+		Response:   responseBody,
+	}); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// write appends an *Entry to the fixture. The gzip writer
+// is flushed after each entry so that the fixture is usable
+// even if the process exits without calling Close.
+func (r *Recorder) write(entry *Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal fixture entry", err)
+	}
+
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("%w: unable to write fixture entry", err)
+	}
+
+	if err := r.writer.Flush(); err != nil {
+		return fmt.Errorf("%w: unable to flush fixture", err)
+	}
+
+	return nil
+}
+
+// Close flushes all remaining data to the fixture
+// and closes it.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.writer.Close(); err != nil {
+		return fmt.Errorf("%w: unable to close fixture writer", err)
+	}
+
+	return r.file.Close()
+}
+
+// Replayer is an http.RoundTripper that serves responses
+// from a fixture written by a *Recorder instead of making
+// requests to a Rosetta server.
+type Replayer struct {
+	entries map[string]*Entry
+}
+
+// NewReplayer loads all entries in the fixture at
+// fixturePath. When the same request was recorded multiple
+// times (like /network/status), the last response is
+// served.
+func NewReplayer(fixturePath string) (*Replayer, error) {
+	file, err := os.Open(fixturePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to open fixture %s", err, fixturePath)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read fixture %s", err, fixturePath)
+	}
+	defer reader.Close()
+
+	entries := map[string]*Entry{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxEntrySize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A fixture written by a process that exited before
+			// calling Close may end with an incomplete entry.
+			break
+		}
+
+		entryKey, err := key(entry.Endpoint, entry.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[entryKey] = &entry
+	}
+
+	// A fixture that was not closed is missing the gzip
+	// footer, which is not an issue because each entry was
+	// flushed when written.
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: unable to scan fixture %s", err, fixturePath)
+	}
+
+	return &Replayer{entries: entries}, nil
+}
+
+// Entries returns the number of unique requests
+// in the fixture.
+func (r *Replayer) Entries() int {
+	return len(r.entries)
+}
+
+// RoundTrip returns the recorded response for a request. If
+// the request was not recorded, a Rosetta error is returned
+// with a 500 status code.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read request body", err)
+		}
+		req.Body.Close()
+	}
+
+	entryKey, err := key(req.URL.Path, requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode := http.StatusInternalServerError
+	var responseBody []byte
+	entry, ok := r.entries[entryKey]
+	if ok {
+		statusCode = entry.StatusCode
+		responseBody = entry.Response
+	} else {
+		responseBody, err = json.Marshal(&types.Error{
+			Code: replayMissCode,
+			Message: fmt.Sprintf(
+				"request to %s not found in fixture: %s",
+				req.URL.Path,
+				string(requestBody),
+			),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to marshal replay error", err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json; charset=UTF-8"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func post(t *testing.T, client *http.Client, url string, body string) (int, string) {
+	resp, err := client.Post(url, "application/json", bytes.NewBufferString(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, string(respBody)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path == "/block" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code":1,"message":"block not found","retriable":false}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"path":%q,"calls":%d,"request":%s}`, r.URL.Path, calls, body)
+	}))
+	defer server.Close()
+
+	fixturePath := path.Join(newDir, "fixture.gz")
+	networkRequest := `{"network_identifier":{"blockchain":"bitcoin","network":"mainnet"}}`
+	balanceRequest := `{"account_identifier":{"address":"addr1"},"block_identifier":{"index":1}}`
+	blockRequest := `{"block_identifier":{"index":100}}`
+
+	var recordedStatus, recordedBalance, recordedBlock string
+	t.Run("record", func(t *testing.T) {
+		r, err := NewRecorder(fixturePath, nil)
+		assert.NoError(t, err)
+		client := &http.Client{Transport: r}
+
+		// The last /network/status response should be
+		// replayed.
+		post(t, client, server.URL+"/network/status", networkRequest)
+		code, resp := post(t, client, server.URL+"/network/status", networkRequest)
+		assert.Equal(t, http.StatusOK, code)
+		recordedStatus = resp
+
+		code, resp = post(t, client, server.URL+"/account/balance", balanceRequest)
+		assert.Equal(t, http.StatusOK, code)
+		recordedBalance = resp
+
+		code, resp = post(t, client, server.URL+"/block", blockRequest)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		recordedBlock = resp
+
+		// We intentionally do not call Close to ensure
+		// flushed entries can be replayed.
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		r, err := NewReplayer(fixturePath)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, r.Entries())
+		client := &http.Client{Transport: r}
+		callsBefore := calls
+
+		code, resp := post(t, client, "http://localhost/network/status", networkRequest)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, recordedStatus, resp)
+
+		// Field ordering should not matter
+		code, resp = post(
+			t,
+			client,
+			"http://localhost/account/balance",
+			`{"block_identifier":{"index":1},"account_identifier":{"address":"addr1"}}`,
+		)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, recordedBalance, resp)
+
+		code, resp = post(t, client, "http://localhost/block", blockRequest)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		assert.Equal(t, recordedBlock, resp)
+
+		code, resp = post(t, client, "http://localhost/block", `{"block_identifier":{"index":101}}`)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		var rosettaErr types.Error
+		assert.NoError(t, json.Unmarshal([]byte(resp), &rosettaErr))
+		assert.Contains(t, rosettaErr.Message, "not found in fixture")
+
+		assert.Equal(t, callsBefore, calls)
+	})
+
+	t.Run("closed fixture", func(t *testing.T) {
+		closedPath := path.Join(newDir, "closed.gz")
+		r, err := NewRecorder(closedPath, nil)
+		assert.NoError(t, err)
+		client := &http.Client{Transport: r}
+		post(t, client, server.URL+"/network/status", networkRequest)
+		assert.NoError(t, r.Close())
+
+		replayer, err := NewReplayer(closedPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, replayer.Entries())
+	})
+}
@@ -16,7 +16,9 @@ package statefulsyncer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
 
 	"github.com/coinbase/rosetta-cli/internal/logger"
@@ -29,6 +31,25 @@ import (
 
 var _ syncer.Handler = (*StatefulSyncer)(nil)
 
+var (
+	// ErrReorgTooDeep is returned when a re-org
+	// orphans more blocks than the configured
+	// max reorg depth.
+	ErrReorgTooDeep = errors.New("reorg too deep")
+)
+
+// BlockStorage is the subset of *storage.BlockStorage
+// used by the StatefulSyncer.
+type BlockStorage interface {
+	Initialize(workers []storage.BlockWorker)
+	SetNewStartIndex(ctx context.Context, startIndex int64) error
+	GetHeadBlockIdentifier(ctx context.Context) (*types.BlockIdentifier, error)
+	CreateBlockCache(ctx context.Context) []*types.BlockIdentifier
+	GetBlock(ctx context.Context, blockIdentifier *types.BlockIdentifier) (*types.Block, error)
+	AddBlock(ctx context.Context, block *types.Block) error
+	RemoveBlock(ctx context.Context, blockIdentifier *types.BlockIdentifier) error
+}
+
 // StatefulSyncer is an abstraction layer over
 // the stateless syncer package. This layer
 // handles sync restarts and provides
@@ -38,10 +59,16 @@ type StatefulSyncer struct {
 	network        *types.NetworkIdentifier
 	fetcher        *fetcher.Fetcher
 	cancel         context.CancelFunc
-	blockStorage   *storage.BlockStorage
+	blockStorage   BlockStorage
 	counterStorage *storage.CounterStorage
 	logger         *logger.Logger
 	workers        []storage.BlockWorker
+	maxReorgDepth  int64
+
+	// orphanedBlocks contains all blocks removed since
+	// the last block was added (in the order they were
+	// removed).
+	orphanedBlocks []*types.BlockIdentifier
 }
 
 // New returns a new *StatefulSyncer.
@@ -49,11 +76,12 @@ func New(
 	ctx context.Context,
 	network *types.NetworkIdentifier,
 	fetcher *fetcher.Fetcher,
-	blockStorage *storage.BlockStorage,
+	blockStorage BlockStorage,
 	counterStorage *storage.CounterStorage,
 	logger *logger.Logger,
 	cancel context.CancelFunc,
 	workers []storage.BlockWorker,
+	maxReorgDepth int64,
 ) *StatefulSyncer {
 	return &StatefulSyncer{
 		network:        network,
@@ -63,6 +91,7 @@ func New(
 		counterStorage: counterStorage,
 		workers:        workers,
 		logger:         logger,
+		maxReorgDepth:  maxReorgDepth,
 	}
 }
 
@@ -99,8 +128,74 @@ func (s *StatefulSyncer) Sync(ctx context.Context, startIndex int64, endIndex in
 	return syncer.Sync(ctx, startIndex, endIndex)
 }
 
+// checkReorgDepth returns an error if the blocks removed
+// since the last block was added exceed the max reorg depth.
+func (s *StatefulSyncer) checkReorgDepth(replacement *types.BlockIdentifier) error {
+	depth := int64(len(s.orphanedBlocks))
+	if s.maxReorgDepth > 0 && depth > s.maxReorgDepth {
+		return fmt.Errorf(
+			"%w: reorg of depth %d replaced by %d:%s exceeds max depth %d",
+			ErrReorgTooDeep,
+			depth,
+			replacement.Index,
+			replacement.Hash,
+			s.maxReorgDepth,
+		)
+	}
+
+	return nil
+}
+
+// recordReorg is called when a block is added after
+// at least 1 block was removed.
+func (s *StatefulSyncer) recordReorg(ctx context.Context, replacement *types.BlockIdentifier) error {
+	depth := int64(len(s.orphanedBlocks))
+	removed := s.orphanedBlocks
+	s.orphanedBlocks = nil
+
+	log.Printf(
+		"Reorg of depth %d detected (replaced by %d:%s)\n",
+		depth,
+		replacement.Index,
+		replacement.Hash,
+	)
+
+	if err := s.logger.ReorgStream(ctx, removed, replacement); err != nil {
+		return fmt.Errorf("%w: unable to log reorg", err)
+	}
+
+	if _, err := s.counterStorage.Update(ctx, storage.ReorgCounter, big.NewInt(1)); err != nil {
+		return fmt.Errorf("%w: unable to update reorg counter", err)
+	}
+
+	maxDepth, err := s.counterStorage.Get(ctx, storage.MaxReorgDepthCounter)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get max reorg depth", err)
+	}
+
+	if maxDepth.Int64() < depth {
+		_, err := s.counterStorage.Update(
+			ctx,
+			storage.MaxReorgDepthCounter,
+			big.NewInt(depth-maxDepth.Int64()),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: unable to update max reorg depth", err)
+		}
+	}
+
+	return nil
+}
+
 // BlockAdded is called by the syncer when a block is added.
 func (s *StatefulSyncer) BlockAdded(ctx context.Context, block *types.Block) error {
+	// A reorg that is too deep is reported before the
+	// replacement block is added (and the reorg is only
+	// recorded once the replacement block is added).
+	if err := s.checkReorgDepth(block.BlockIdentifier); err != nil {
+		return err
+	}
+
 	err := s.blockStorage.AddBlock(ctx, block)
 	if err != nil {
 		return fmt.Errorf(
@@ -111,32 +206,90 @@ func (s *StatefulSyncer) BlockAdded(ctx context.Context, block *types.Block) err
 		)
 	}
 
+	if len(s.orphanedBlocks) > 0 {
+		if err := s.recordReorg(ctx, block.BlockIdentifier); err != nil {
+			return err
+		}
+	}
+
 	if err := s.logger.AddBlockStream(ctx, block); err != nil {
 		return nil
 	}
 
 	// Update Counters
-	_, _ = s.counterStorage.Update(ctx, storage.BlockCounter, big.NewInt(1))
-	_, _ = s.counterStorage.Update(
-		ctx,
-		storage.TransactionCounter,
-		big.NewInt(int64(len(block.Transactions))),
-	)
+	counters := s.operationCounters(block, 1)
+	counters[storage.BlockCounter] = big.NewInt(1)
+	counters[storage.TransactionCounter] = big.NewInt(int64(len(block.Transactions)))
 	opCount := int64(0)
 	for _, txn := range block.Transactions {
 		opCount += int64(len(txn.Operations))
 	}
-	_, _ = s.counterStorage.Update(ctx, storage.OperationCounter, big.NewInt(opCount))
+	counters[storage.OperationCounter] = big.NewInt(opCount)
+
+	if err := s.counterStorage.UpdateBatch(ctx, counters); err != nil {
+		return fmt.Errorf("%w: unable to update counters", err)
+	}
 
 	return nil
 }
 
+// operationCounters returns the change of the operation type,
+// operation status, and balance changing operation counters
+// caused by adding (sign of 1) or removing (sign of -1) a block.
+func (s *StatefulSyncer) operationCounters(block *types.Block, sign int64) map[string]*big.Int {
+	counts := map[string]int64{}
+	for _, txn := range block.Transactions {
+		for _, op := range txn.Operations {
+			counts[storage.OperationTypeCounter(op.Type)]++
+			counts[storage.OperationStatusCounter(op.Status)]++
+
+			if s.balanceChanging(op) {
+				counts[storage.BalanceChangingOperationCounter]++
+			}
+		}
+	}
+
+	counters := map[string]*big.Int{}
+	for counter, count := range counts {
+		counters[counter] = big.NewInt(sign * count)
+	}
+
+	return counters
+}
+
+// balanceChanging returns a boolean indicating if
+// an operation changes the balance of an account.
+func (s *StatefulSyncer) balanceChanging(op *types.Operation) bool {
+	if op.Account == nil || op.Amount == nil {
+		return false
+	}
+
+	successful, err := s.fetcher.Asserter.OperationSuccessful(op)
+	if err != nil {
+		return false
+	}
+
+	return successful
+}
+
 // BlockRemoved is called by the syncer when a block is removed.
 func (s *StatefulSyncer) BlockRemoved(
 	ctx context.Context,
 	blockIdentifier *types.BlockIdentifier,
 ) error {
-	err := s.blockStorage.RemoveBlock(ctx, blockIdentifier)
+	// The block is fetched before it is removed so that
+	// its operations can be removed from the counters.
+	block, err := s.blockStorage.GetBlock(ctx, blockIdentifier)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: unable to get block %s:%d",
+			err,
+			blockIdentifier.Hash,
+			blockIdentifier.Index,
+		)
+	}
+
+	err = s.blockStorage.RemoveBlock(ctx, blockIdentifier)
 	if err != nil {
 		return fmt.Errorf(
 			"%w: unable to remove block from storage %s:%d",
@@ -146,12 +299,19 @@ func (s *StatefulSyncer) BlockRemoved(
 		)
 	}
 
+	s.orphanedBlocks = append(s.orphanedBlocks, blockIdentifier)
+
 	if err := s.logger.RemoveBlockStream(ctx, blockIdentifier); err != nil {
 		return nil
 	}
 
 	// Update Counters
-	_, _ = s.counterStorage.Update(ctx, storage.OrphanCounter, big.NewInt(1))
+	counters := s.operationCounters(block, -1)
+	counters[storage.OrphanCounter] = big.NewInt(1)
+
+	if err := s.counterStorage.UpdateBatch(ctx, counters); err != nil {
+		return fmt.Errorf("%w: unable to update counters", err)
+	}
 
-	return err
+	return nil
 }
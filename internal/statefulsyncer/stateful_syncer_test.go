@@ -0,0 +1,342 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statefulsyncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/logger"
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errAddBlockFailed = errors.New("unable to add block")
+)
+
+type mockBlockStorage struct {
+	added   []*types.BlockIdentifier
+	removed []*types.BlockIdentifier
+	blocks  map[string]*types.Block
+
+	addErr error
+}
+
+func (m *mockBlockStorage) Initialize(workers []storage.BlockWorker) {}
+
+func (m *mockBlockStorage) SetNewStartIndex(ctx context.Context, startIndex int64) error {
+	return nil
+}
+
+func (m *mockBlockStorage) GetHeadBlockIdentifier(
+	ctx context.Context,
+) (*types.BlockIdentifier, error) {
+	return nil, storage.ErrHeadBlockNotFound
+}
+
+func (m *mockBlockStorage) CreateBlockCache(ctx context.Context) []*types.BlockIdentifier {
+	return []*types.BlockIdentifier{}
+}
+
+func (m *mockBlockStorage) AddBlock(ctx context.Context, block *types.Block) error {
+	if m.addErr != nil {
+		return m.addErr
+	}
+
+	m.added = append(m.added, block.BlockIdentifier)
+	if m.blocks == nil {
+		m.blocks = map[string]*types.Block{}
+	}
+	m.blocks[block.BlockIdentifier.Hash] = block
+
+	return nil
+}
+
+func (m *mockBlockStorage) GetBlock(
+	ctx context.Context,
+	blockIdentifier *types.BlockIdentifier,
+) (*types.Block, error) {
+	if block, ok := m.blocks[blockIdentifier.Hash]; ok {
+		return block, nil
+	}
+
+	// Blocks that were never added are returned
+	// without any transactions.
+	return &types.Block{BlockIdentifier: blockIdentifier}, nil
+}
+
+func (m *mockBlockStorage) RemoveBlock(
+	ctx context.Context,
+	blockIdentifier *types.BlockIdentifier,
+) error {
+	m.removed = append(m.removed, blockIdentifier)
+	return nil
+}
+
+func blockIdentifier(index int64) *types.BlockIdentifier {
+	return &types.BlockIdentifier{
+		Hash:  fmt.Sprintf("block %d", index),
+		Index: index,
+	}
+}
+
+func TestReorg(t *testing.T) {
+	var tests = map[string]struct {
+		orphaned      int64
+		maxReorgDepth int64
+		maxDepth      int64
+		addErr        error
+
+		reorgs           int64
+		expectedMaxDepth int64
+		added            bool
+		err              error
+	}{
+		"no reorg": {
+			added: true,
+		},
+		"reorg": {
+			orphaned:         2,
+			reorgs:           1,
+			expectedMaxDepth: 2,
+			added:            true,
+		},
+		"reorg at max reorg depth": {
+			orphaned:         3,
+			maxReorgDepth:    3,
+			reorgs:           1,
+			expectedMaxDepth: 3,
+			added:            true,
+		},
+		"reorg too deep": {
+			orphaned:      4,
+			maxReorgDepth: 3,
+			err:           ErrReorgTooDeep,
+		},
+		"reorg shallower than max depth": {
+			orphaned:         2,
+			maxDepth:         5,
+			reorgs:           1,
+			expectedMaxDepth: 5,
+			added:            true,
+		},
+		"unable to add replacement block": {
+			orphaned: 2,
+			addErr:   errAddBlockFailed,
+			err:      errAddBlockFailed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newDir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(newDir)
+
+			database, err := storage.NewBadgerStorage(ctx, newDir)
+			assert.NoError(t, err)
+			defer database.Close(ctx)
+
+			counterStorage := storage.NewCounterStorage(database)
+			_, err = counterStorage.Update(
+				ctx,
+				storage.MaxReorgDepthCounter,
+				big.NewInt(test.maxDepth),
+			)
+			assert.NoError(t, err)
+
+			blockStorage := &mockBlockStorage{addErr: test.addErr}
+			syncer := New(
+				ctx,
+				&types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"},
+				nil,
+				blockStorage,
+				counterStorage,
+				logger.NewLogger(counterStorage, newDir, false, false, false, false, true),
+				nil,
+				nil,
+				test.maxReorgDepth,
+			)
+
+			for i := int64(0); i < test.orphaned; i++ {
+				assert.NoError(t, syncer.BlockRemoved(ctx, blockIdentifier(10-i)))
+			}
+
+			replacement := blockIdentifier(11 - test.orphaned)
+			err = syncer.BlockAdded(ctx, &types.Block{
+				BlockIdentifier:       replacement,
+				ParentBlockIdentifier: blockIdentifier(10 - test.orphaned),
+			})
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if test.added {
+				assert.Equal(t, []*types.BlockIdentifier{replacement}, blockStorage.added)
+			} else {
+				assert.Len(t, blockStorage.added, 0)
+			}
+
+			reorgs, err := counterStorage.Get(ctx, storage.ReorgCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(test.reorgs), reorgs)
+
+			maxDepth, err := counterStorage.Get(ctx, storage.MaxReorgDepthCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(test.expectedMaxDepth), maxDepth)
+
+			orphans, err := counterStorage.Get(ctx, storage.OrphanCounter)
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(test.orphaned), orphans)
+		})
+	}
+}
+
+func TestOperationCounters(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := storage.NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	network := &types.NetworkIdentifier{Blockchain: "bitcoin", Network: "mainnet"}
+	a, err := asserter.NewClientWithOptions(
+		network,
+		blockIdentifier(0),
+		[]string{"Transfer", "Fee"},
+		[]*types.OperationStatus{
+			{
+				Status:     "Success",
+				Successful: true,
+			},
+			{
+				Status:     "Failure",
+				Successful: false,
+			},
+		},
+		[]*types.Error{},
+	)
+	assert.NoError(t, err)
+
+	f := fetcher.New("")
+	f.Asserter = a
+
+	counterStorage := storage.NewCounterStorage(database)
+	blockStorage := &mockBlockStorage{}
+	syncer := New(
+		ctx,
+		network,
+		f,
+		blockStorage,
+		counterStorage,
+		logger.NewLogger(counterStorage, newDir, false, false, false, false, false),
+		nil,
+		nil,
+		0,
+	)
+
+	account := &types.AccountIdentifier{Address: "addr1"}
+	amount := &types.Amount{
+		Value:    "100",
+		Currency: &types.Currency{Symbol: "BTC", Decimals: 8},
+	}
+	block := &types.Block{
+		BlockIdentifier:       blockIdentifier(1),
+		ParentBlockIdentifier: blockIdentifier(0),
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx1"},
+				Operations: []*types.Operation{
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 0},
+						Type:                "Transfer",
+						Status:              "Success",
+						Account:             account,
+						Amount:              amount,
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 1},
+						Type:                "Transfer",
+						Status:              "Failure",
+						Account:             account,
+						Amount:              amount,
+					},
+					{
+						OperationIdentifier: &types.OperationIdentifier{Index: 2},
+						Type:                "Fee",
+						Status:              "Success",
+					},
+				},
+			},
+		},
+	}
+
+	assertCounters := func(t *testing.T, expected map[string]int64) {
+		for counter, value := range expected {
+			v, err := counterStorage.Get(ctx, counter)
+			assert.NoError(t, err)
+			assert.Equal(t, big.NewInt(value), v, counter)
+		}
+	}
+
+	t.Run("add block", func(t *testing.T) {
+		assert.NoError(t, syncer.BlockAdded(ctx, block))
+		assertCounters(t, map[string]int64{
+			storage.BlockCounter:                      1,
+			storage.TransactionCounter:                1,
+			storage.OperationCounter:                  3,
+			storage.BalanceChangingOperationCounter:   1,
+			storage.OperationTypeCounter("Transfer"):  2,
+			storage.OperationTypeCounter("Fee"):       1,
+			storage.OperationStatusCounter("Success"): 2,
+			storage.OperationStatusCounter("Failure"): 1,
+			storage.OrphanCounter:                     0,
+		})
+	})
+
+	t.Run("remove block", func(t *testing.T) {
+		// Processed counters are not decremented but the
+		// breakdown of canonical operations is.
+		assert.NoError(t, syncer.BlockRemoved(ctx, block.BlockIdentifier))
+		assertCounters(t, map[string]int64{
+			storage.BlockCounter:                      1,
+			storage.TransactionCounter:                1,
+			storage.OperationCounter:                  3,
+			storage.BalanceChangingOperationCounter:   0,
+			storage.OperationTypeCounter("Transfer"):  0,
+			storage.OperationTypeCounter("Fee"):       0,
+			storage.OperationStatusCounter("Success"): 0,
+			storage.OperationStatusCounter("Failure"): 0,
+			storage.OrphanCounter:                     1,
+		})
+	})
+}
@@ -34,6 +34,20 @@ var _ BlockWorker = (*BalanceStorage)(nil)
 const (
 	// balanceNamespace is prepended to any stored balance.
 	balanceNamespace = "balance"
+
+	// historyNamespace is prepended to any stored balance
+	// history entry. This must not share a prefix with
+	// balanceNamespace or history entries would be returned
+	// when scanning for balances.
+	historyNamespace = "account-history"
+
+	// exemptNamespace is prepended to any account and currency
+	// with a successful operation exempt from balance tracking.
+	exemptNamespace = "exempt"
+
+	// supplyNamespace is prepended to the total supply
+	// of each currency.
+	supplyNamespace = "supply"
 )
 
 var (
@@ -57,6 +71,41 @@ func GetBalanceKey(account *types.AccountIdentifier, currency *types.Currency) [
 	)
 }
 
+func getHistoryPrefix(account *types.AccountIdentifier, currency *types.Currency) []byte {
+	return []byte(
+		fmt.Sprintf("%s/%s/%s/", historyNamespace, types.Hash(account), types.Hash(currency)),
+	)
+}
+
+// getHistoryKey returns the key of a balance history entry. The
+// block index is zero-padded so that entries are sorted by index
+// when scanning the prefix of an account and currency.
+func getHistoryKey(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	block *types.BlockIdentifier,
+) []byte {
+	return []byte(
+		fmt.Sprintf("%s%020d", getHistoryPrefix(account, currency), block.Index),
+	)
+}
+
+// getExemptKey returns the key that records that an
+// account has an exempt operation in a currency.
+func getExemptKey(account *types.AccountIdentifier, currency *types.Currency) []byte {
+	return []byte(
+		fmt.Sprintf("%s/%s/%s", exemptNamespace, types.Hash(account), types.Hash(currency)),
+	)
+}
+
+// getSupplyKey returns the key of the total supply
+// of a currency.
+func getSupplyKey(currency *types.Currency) []byte {
+	return []byte(
+		fmt.Sprintf("%s/%s", supplyNamespace, types.Hash(currency)),
+	)
+}
+
 // BalanceStorageHandler is invoked after balance changes are committed to the database.
 type BalanceStorageHandler interface {
 	BlockAdded(ctx context.Context, block *types.Block, changes []*parser.BalanceChange) error
@@ -86,6 +135,13 @@ type BalanceStorage struct {
 	handler BalanceStorageHandler
 
 	parser *parser.Parser
+
+	historyEnabled bool
+	supplyEnabled  bool
+
+	// negativeBalanceAccounts are allowed to have
+	// a negative balance.
+	negativeBalanceAccounts map[string]struct{}
 }
 
 // NewBalanceStorage returns a new BalanceStorage.
@@ -105,6 +161,44 @@ func (b *BalanceStorage) Initialize(helper BalanceStorageHelper, handler Balance
 	b.parser = parser.New(helper.Asserter(), helper.ExemptFunc())
 }
 
+// EnableHistory causes BalanceStorage to record every balance
+// change of each account in a separate history namespace. History
+// is only recorded for blocks added after this is called.
+func (b *BalanceStorage) EnableHistory() {
+	b.historyEnabled = true
+}
+
+// EnableSupply causes BalanceStorage to track the total supply
+// of each currency. Supply is only accurate if it is enabled
+// before any balance is stored.
+func (b *BalanceStorage) EnableSupply() {
+	b.supplyEnabled = true
+}
+
+// AllowNegativeBalances allows the balance of some accounts (like
+// a virtual mint or issuance account) to go negative. These
+// accounts are still tracked and reconciled.
+func (b *BalanceStorage) AllowNegativeBalances(accounts []*reconciler.AccountCurrency) {
+	b.negativeBalanceAccounts = map[string]struct{}{}
+	for _, account := range accounts {
+		b.negativeBalanceAccounts[types.Hash(account)] = struct{}{}
+	}
+}
+
+// negativeBalanceAllowed returns a boolean indicating if
+// the balance of an account may go negative.
+func (b *BalanceStorage) negativeBalanceAllowed(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) bool {
+	_, ok := b.negativeBalanceAccounts[types.Hash(&reconciler.AccountCurrency{
+		Account:  account,
+		Currency: currency,
+	})]
+
+	return ok
+}
+
 // AddingBlock is called by BlockStorage when adding a block to storage.
 func (b *BalanceStorage) AddingBlock(
 	ctx context.Context,
@@ -116,10 +210,23 @@ func (b *BalanceStorage) AddingBlock(
 		return nil, fmt.Errorf("%w: unable to calculate balance changes", err)
 	}
 
+	if err := b.addExemptAccounts(ctx, transaction, block); err != nil {
+		return nil, fmt.Errorf("%w: unable to store exempt accounts", err)
+	}
+
 	for _, change := range changes {
-		if err := b.UpdateBalance(ctx, transaction, change, block.ParentBlockIdentifier); err != nil {
+		newBalance, err := b.updateBalance(ctx, transaction, change, block.ParentBlockIdentifier)
+		if err != nil {
 			return nil, err
 		}
+
+		if !b.historyEnabled {
+			continue
+		}
+
+		if err := b.addHistory(ctx, transaction, change, newBalance); err != nil {
+			return nil, fmt.Errorf("%w: unable to store balance history", err)
+		}
 	}
 
 	return func(ctx context.Context) error {
@@ -142,6 +249,21 @@ func (b *BalanceStorage) RemovingBlock(
 		if err := b.UpdateBalance(ctx, transaction, change, block.BlockIdentifier); err != nil {
 			return nil, err
 		}
+
+		if !b.historyEnabled {
+			continue
+		}
+
+		// Balance changes of a removed block are assigned to the
+		// parent block, so we must use the removed block to find
+		// the history entry to delete.
+		err := transaction.Delete(
+			ctx,
+			getHistoryKey(change.Account, change.Currency, block.BlockIdentifier),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to remove balance history", err)
+		}
 	}
 
 	return func(ctx context.Context) error {
@@ -149,12 +271,130 @@ func (b *BalanceStorage) RemovingBlock(
 	}, nil
 }
 
+// addExemptAccounts records each account and currency with a
+// successful operation in block that is exempt from balance tracking.
+// Records are not removed when a block is orphaned because the
+// stored balance of the account may not include any exempt
+// operation (so it can never be reconciled).
+func (b *BalanceStorage) addExemptAccounts(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	block *types.Block,
+) error {
+	if b.parser.ExemptFunc == nil {
+		return nil
+	}
+
+	for _, tx := range block.Transactions {
+		for _, op := range tx.Operations {
+			if op.Account == nil || op.Amount == nil || !b.parser.ExemptFunc(op) {
+				continue
+			}
+
+			successful, err := b.parser.Asserter.OperationSuccessful(op)
+			if err != nil {
+				return err
+			}
+
+			if !successful {
+				continue
+			}
+
+			err = transaction.Set(ctx, getExemptKey(op.Account, op.Amount.Currency), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Exempt returns a boolean indicating if an account and currency
+// had any successful operation exempt from balance tracking. The
+// stored balance of an exempt account does not include these
+// operations, so it should not be reconciled.
+func (b *BalanceStorage) Exempt(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) (bool, error) {
+	transaction := b.db.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	exists, _, err := transaction.Get(ctx, getExemptKey(account, currency))
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
 type balanceEntry struct {
 	Account *types.AccountIdentifier `json:"account"`
 	Amount  *types.Amount            `json:"amount"`
 	Block   *types.BlockIdentifier   `json:"block"`
 }
 
+// BalanceHistoryEntry is a single balance change
+// of an account and the balance that resulted from it.
+type BalanceHistoryEntry struct {
+	Block      *types.BlockIdentifier `json:"block_identifier"`
+	Difference string                 `json:"difference"`
+	Balance    *types.Amount          `json:"balance"`
+}
+
+func (b *BalanceStorage) addHistory(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	change *parser.BalanceChange,
+	balance string,
+) error {
+	serialEntry, err := encode(&BalanceHistoryEntry{
+		Block:      change.Block,
+		Difference: change.Difference,
+		Balance: &types.Amount{
+			Value:    balance,
+			Currency: change.Currency,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return dbTransaction.Set(
+		ctx,
+		getHistoryKey(change.Account, change.Currency, change.Block),
+		serialEntry,
+	)
+}
+
+// GetBalanceHistory returns all recorded balance changes of a
+// types.AccountIdentifier and types.Currency, sorted by block index.
+// History is only recorded if EnableHistory was called.
+func (b *BalanceStorage) GetBalanceHistory(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) ([]*BalanceHistoryEntry, error) {
+	rawEntries, err := b.db.Scan(ctx, getHistoryPrefix(account, currency))
+	if err != nil {
+		return nil, fmt.Errorf("%w database scan failed", err)
+	}
+
+	entries := make([]*BalanceHistoryEntry, len(rawEntries))
+	for i, rawEntry := range rawEntries {
+		var entry BalanceHistoryEntry
+		if err := decode(rawEntry, &entry); err != nil {
+			return nil, fmt.Errorf("%w unable to parse balance history entry", err)
+		}
+
+		entries[i] = &entry
+	}
+
+	return entries, nil
+}
+
 // SetBalance allows a client to set the balance of an account in a database
 // transaction. This is particularly useful for bootstrapping balances.
 func (b *BalanceStorage) SetBalance(
@@ -166,6 +406,11 @@ func (b *BalanceStorage) SetBalance(
 ) error {
 	key := GetBalanceKey(account, amount.Currency)
 
+	existingValue, err := getStoredValue(ctx, dbTransaction, key)
+	if err != nil {
+		return err
+	}
+
 	serialBal, err := encode(&balanceEntry{
 		Account: account,
 		Amount:  amount,
@@ -179,7 +424,164 @@ func (b *BalanceStorage) SetBalance(
 		return err
 	}
 
-	return nil
+	return b.updateSupply(ctx, dbTransaction, account, amount.Currency, existingValue, amount.Value)
+}
+
+// getStoredValue returns the balance stored at a key
+// (or "0" if no balance is stored).
+func getStoredValue(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	key []byte,
+) (string, error) {
+	exists, balance, err := dbTransaction.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return "0", nil
+	}
+
+	var bal balanceEntry
+	if err := decode(balance, &bal); err != nil {
+		return "", err
+	}
+
+	return bal.Amount.Value, nil
+}
+
+// Supply is the total supply of a currency.
+type Supply struct {
+	Currency *types.Currency `json:"currency"`
+
+	// Value is the sum of the balances of all accounts
+	// that are not allowed to go negative.
+	Value string `json:"value"`
+
+	// NegativeBalanceValue is the sum of the balances of
+	// all accounts that are allowed to go negative.
+	NegativeBalanceValue string `json:"negative_balance_value,omitempty"`
+}
+
+// getSupply returns the *Supply of a currency
+// in a database transaction.
+func getSupply(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	currency *types.Currency,
+) (*Supply, error) {
+	exists, rawSupply, err := dbTransaction.Get(ctx, getSupplyKey(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	supply := Supply{
+		Currency: currency,
+		Value:    "0",
+	}
+	if exists {
+		if err := decode(rawSupply, &supply); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(supply.NegativeBalanceValue) == 0 {
+		supply.NegativeBalanceValue = "0"
+	}
+
+	return &supply, nil
+}
+
+// updateSupply adjusts the total supply of a currency
+// when a stored balance changes from oldValue to newValue
+// (if supply is enabled).
+func (b *BalanceStorage) updateSupply(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	oldValue string,
+	newValue string,
+) error {
+	if !b.supplyEnabled {
+		return nil
+	}
+
+	difference, err := types.SubtractValues(newValue, oldValue)
+	if err != nil {
+		return err
+	}
+
+	supply, err := getSupply(ctx, dbTransaction, currency)
+	if err != nil {
+		return err
+	}
+
+	// Accounts allowed to go negative (like a mint account) are
+	// tracked separately so they do not offset the supply
+	// they issue.
+	if b.negativeBalanceAllowed(account, currency) {
+		supply.NegativeBalanceValue, err = types.AddValues(supply.NegativeBalanceValue, difference)
+	} else {
+		supply.Value, err = types.AddValues(supply.Value, difference)
+	}
+	if err != nil {
+		return err
+	}
+
+	serialSupply, err := encode(supply)
+	if err != nil {
+		return err
+	}
+
+	return dbTransaction.Set(ctx, getSupplyKey(currency), serialSupply)
+}
+
+// GetSupply returns the total supply of a currency (the sum
+// of all stored balances of accounts not allowed to go negative)
+// in a database transaction. Supply is only tracked for balances
+// stored after it was introduced, so it is only accurate for data
+// directories synced from genesis with this version.
+func (b *BalanceStorage) GetSupply(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	currency *types.Currency,
+) (*types.Amount, error) {
+	supply, err := getSupply(ctx, dbTransaction, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Amount{
+		Value:    supply.Value,
+		Currency: currency,
+	}, nil
+}
+
+// GetAllSupply returns the *Supply of all
+// currencies with a stored balance.
+func (b *BalanceStorage) GetAllSupply(ctx context.Context) ([]*Supply, error) {
+	rawSupplies, err := b.db.Scan(ctx, []byte(supplyNamespace))
+	if err != nil {
+		return nil, fmt.Errorf("%w database scan failed", err)
+	}
+
+	supplies := make([]*Supply, len(rawSupplies))
+	for i, rawSupply := range rawSupplies {
+		var supply Supply
+		if err := decode(rawSupply, &supply); err != nil {
+			return nil, fmt.Errorf("%w unable to parse supply entry", err)
+		}
+
+		if len(supply.NegativeBalanceValue) == 0 {
+			supply.NegativeBalanceValue = "0"
+		}
+
+		supplies[i] = &supply
+	}
+
+	return supplies, nil
 }
 
 // UpdateBalance updates a types.AccountIdentifer
@@ -191,18 +593,31 @@ func (b *BalanceStorage) UpdateBalance(
 	change *parser.BalanceChange,
 	parentBlock *types.BlockIdentifier,
 ) error {
+	_, err := b.updateBalance(ctx, dbTransaction, change, parentBlock)
+	return err
+}
+
+// updateBalance is the implementation of UpdateBalance
+// and returns the resulting balance.
+func (b *BalanceStorage) updateBalance(
+	ctx context.Context,
+	dbTransaction DatabaseTransaction,
+	change *parser.BalanceChange,
+	parentBlock *types.BlockIdentifier,
+) (string, error) {
 	if change.Currency == nil {
-		return errors.New("invalid currency")
+		return "", errors.New("invalid currency")
 	}
 
 	key := GetBalanceKey(change.Account, change.Currency)
 	// Get existing balance on key
 	exists, balance, err := dbTransaction.Get(ctx, key)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var existingValue string
+	storedValue := "0"
 	switch {
 	case exists:
 		// This could happen if balances are bootstrapped and should not be
@@ -210,10 +625,11 @@ func (b *BalanceStorage) UpdateBalance(
 		var bal balanceEntry
 		err := decode(balance, &bal)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		existingValue = bal.Amount.Value
+		storedValue = existingValue
 	case parentBlock != nil && change.Block.Hash == parentBlock.Hash:
 		// Don't attempt to use the helper if we are going to query the same
 		// block we are processing (causes the duplicate issue).
@@ -222,7 +638,7 @@ func (b *BalanceStorage) UpdateBalance(
 		// Use helper to fetch existing balance.
 		amount, err := b.helper.AccountBalance(ctx, change.Account, change.Currency, parentBlock)
 		if err != nil {
-			return fmt.Errorf("%w: unable to get previous account balance", err)
+			return "", fmt.Errorf("%w: unable to get previous account balance", err)
 		}
 
 		existingValue = amount.Value
@@ -230,16 +646,16 @@ func (b *BalanceStorage) UpdateBalance(
 
 	newVal, err := types.AddValues(change.Difference, existingValue)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	bigNewVal, ok := new(big.Int).SetString(newVal, 10)
 	if !ok {
-		return fmt.Errorf("%s is not an integer", newVal)
+		return "", fmt.Errorf("%s is not an integer", newVal)
 	}
 
-	if bigNewVal.Sign() == -1 {
-		return fmt.Errorf(
+	if bigNewVal.Sign() == -1 && !b.negativeBalanceAllowed(change.Account, change.Currency) {
+		return "", fmt.Errorf(
 			"%w %s:%+v for %+v at %+v",
 			ErrNegativeBalance,
 			newVal,
@@ -258,10 +674,19 @@ func (b *BalanceStorage) UpdateBalance(
 		Block: change.Block,
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return dbTransaction.Set(ctx, key, serialBal)
+	if err := dbTransaction.Set(ctx, key, serialBal); err != nil {
+		return "", err
+	}
+
+	err = b.updateSupply(ctx, dbTransaction, change.Account, change.Currency, storedValue, newVal)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to update supply", err)
+	}
+
+	return newVal, nil
 }
 
 // GetBalance returns all the balances of a types.AccountIdentifier
@@ -321,6 +746,35 @@ func (b *BalanceStorage) GetBalance(
 	return popBal.Amount, popBal.Block, nil
 }
 
+// GetStoredBalance returns the stored balance of a types.AccountIdentifier
+// and the types.BlockIdentifier it was last updated at. Unlike GetBalance,
+// a missing balance is not fetched from the node (and nothing is
+// persisted), so ErrAccountNotFound is returned instead.
+func (b *BalanceStorage) GetStoredBalance(
+	ctx context.Context,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) (*types.Amount, *types.BlockIdentifier, error) {
+	transaction := b.db.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	exists, bal, err := transaction.Get(ctx, GetBalanceKey(account, currency))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !exists {
+		return nil, nil, ErrAccountNotFound
+	}
+
+	var popBal balanceEntry
+	if err := decode(bal, &popBal); err != nil {
+		return nil, nil, err
+	}
+
+	return popBal.Amount, popBal.Block, nil
+}
+
 // BootstrapBalance represents a balance of
 // a *types.AccountIdentifier and a *types.Currency in the
 // genesis block.
@@ -151,6 +151,7 @@ func TestBalance(t *testing.T) {
 
 	storage := NewBalanceStorage(database)
 	storage.Initialize(mockHelper, nil)
+	storage.EnableSupply()
 
 	t.Run("Get unset balance", func(t *testing.T) {
 		amount, block, err := storage.GetBalance(ctx, account, currency, newBlock)
@@ -448,6 +449,43 @@ func TestBalance(t *testing.T) {
 			},
 		}, accounts)
 	})
+
+	t.Run("Push balance negative on account allowed to go negative", func(t *testing.T) {
+		mintAccount := &types.AccountIdentifier{
+			Address: "mint",
+		}
+		storage.AllowNegativeBalances([]*reconciler.AccountCurrency{
+			{
+				Account:  mintAccount,
+				Currency: currency,
+			},
+		})
+		defer storage.AllowNegativeBalances(nil)
+
+		txn := storage.db.NewDatabaseTransaction(ctx, true)
+		err := storage.UpdateBalance(
+			ctx,
+			txn,
+			&parser.BalanceChange{
+				Account:    mintAccount,
+				Currency:   currency,
+				Block:      newBlock3,
+				Difference: largeDeduction.Value,
+			},
+			newBlock2,
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+
+		retrievedAmount, _, err := storage.GetBalance(ctx, mintAccount, currency, newBlock3)
+		assert.NoError(t, err)
+		assert.Equal(t, largeDeduction, retrievedAmount)
+
+		supply, err := storage.GetAllSupply(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, supply, 1)
+		assert.Equal(t, "-1000", supply[0].NegativeBalanceValue)
+	})
 }
 
 func TestBootstrapBalances(t *testing.T) {
@@ -607,6 +645,418 @@ func TestBootstrapBalances(t *testing.T) {
 	})
 }
 
+func TestBalanceHistory(t *testing.T) {
+	var (
+		account = &types.AccountIdentifier{
+			Address: "blah",
+		}
+		currency = &types.Currency{
+			Symbol:   "BLAH",
+			Decimals: 2,
+		}
+		block0 = &types.BlockIdentifier{
+			Hash:  "0",
+			Index: 0,
+		}
+		block1 = &types.BlockIdentifier{
+			Hash:  "1",
+			Index: 1,
+		}
+		block2 = &types.BlockIdentifier{
+			Hash:  "2",
+			Index: 2,
+		}
+		newBlock = func(
+			block *types.BlockIdentifier,
+			parent *types.BlockIdentifier,
+			value string,
+		) *types.Block {
+			return &types.Block{
+				BlockIdentifier:       block,
+				ParentBlockIdentifier: parent,
+				Transactions: []*types.Transaction{
+					{
+						TransactionIdentifier: &types.TransactionIdentifier{
+							Hash: block.Hash,
+						},
+						Operations: []*types.Operation{
+							{
+								OperationIdentifier: &types.OperationIdentifier{
+									Index: 0,
+								},
+								Type:    "Transfer",
+								Status:  "Success",
+								Account: account,
+								Amount: &types.Amount{
+									Value:    value,
+									Currency: currency,
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+		firstBlock  = newBlock(block1, block0, "100")
+		secondBlock = newBlock(block2, block1, "-40")
+	)
+
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	storage := NewBalanceStorage(database)
+	storage.Initialize(&MockBalanceStorageHelper{}, nil)
+	storage.EnableHistory()
+
+	t.Run("no history", func(t *testing.T) {
+		history, err := storage.GetBalanceHistory(ctx, account, currency)
+		assert.NoError(t, err)
+		assert.Len(t, history, 0)
+	})
+
+	t.Run("add blocks", func(t *testing.T) {
+		for _, block := range []*types.Block{firstBlock, secondBlock} {
+			txn := storage.db.NewDatabaseTransaction(ctx, true)
+			_, err := storage.AddingBlock(ctx, block, txn)
+			assert.NoError(t, err)
+			assert.NoError(t, txn.Commit(ctx))
+		}
+
+		history, err := storage.GetBalanceHistory(ctx, account, currency)
+		assert.NoError(t, err)
+		assert.Equal(t, []*BalanceHistoryEntry{
+			{
+				Block:      block1,
+				Difference: "100",
+				Balance: &types.Amount{
+					Value:    "100",
+					Currency: currency,
+				},
+			},
+			{
+				Block:      block2,
+				Difference: "-40",
+				Balance: &types.Amount{
+					Value:    "60",
+					Currency: currency,
+				},
+			},
+		}, history)
+	})
+
+	t.Run("remove block", func(t *testing.T) {
+		txn := storage.db.NewDatabaseTransaction(ctx, true)
+		_, err := storage.RemovingBlock(ctx, newBlock(block2, block1, "-40"), txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+
+		history, err := storage.GetBalanceHistory(ctx, account, currency)
+		assert.NoError(t, err)
+		assert.Equal(t, []*BalanceHistoryEntry{
+			{
+				Block:      block1,
+				Difference: "100",
+				Balance: &types.Amount{
+					Value:    "100",
+					Currency: currency,
+				},
+			},
+		}, history)
+
+		amount, _, err := storage.GetBalance(ctx, account, currency, block1)
+		assert.NoError(t, err)
+		assert.Equal(t, "100", amount.Value)
+	})
+
+	t.Run("history not returned as balance", func(t *testing.T) {
+		accounts, err := storage.GetAllAccountCurrency(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, accounts, 1)
+	})
+}
+
+func TestExempt(t *testing.T) {
+	var (
+		account = &types.AccountIdentifier{
+			Address: "blah",
+		}
+		exemptAccount = &types.AccountIdentifier{
+			Address: "blah2",
+		}
+		currency = &types.Currency{
+			Symbol:   "BLAH",
+			Decimals: 2,
+		}
+		block = &types.Block{
+			BlockIdentifier: &types.BlockIdentifier{
+				Hash:  "1",
+				Index: 1,
+			},
+			ParentBlockIdentifier: &types.BlockIdentifier{
+				Hash:  "0",
+				Index: 0,
+			},
+			Transactions: []*types.Transaction{
+				{
+					TransactionIdentifier: &types.TransactionIdentifier{
+						Hash: "tx1",
+					},
+					Operations: []*types.Operation{
+						{
+							OperationIdentifier: &types.OperationIdentifier{
+								Index: 0,
+							},
+							Type:    "Transfer",
+							Status:  "Success",
+							Account: account,
+							Amount: &types.Amount{
+								Value:    "100",
+								Currency: currency,
+							},
+						},
+						{
+							OperationIdentifier: &types.OperationIdentifier{
+								Index: 1,
+							},
+							Type:    "Transfer",
+							Status:  "Success",
+							Account: exemptAccount,
+							Amount: &types.Amount{
+								Value:    "100",
+								Currency: currency,
+							},
+						},
+					},
+				},
+			},
+		}
+	)
+
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	storage := NewBalanceStorage(database)
+	storage.Initialize(&MockBalanceStorageHelper{
+		ExemptAccounts: []*reconciler.AccountCurrency{
+			{
+				Account:  exemptAccount,
+				Currency: currency,
+			},
+		},
+	}, nil)
+
+	txn := storage.db.NewDatabaseTransaction(ctx, true)
+	_, err = storage.AddingBlock(ctx, block, txn)
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(ctx))
+
+	exempt, err := storage.Exempt(ctx, account, currency)
+	assert.NoError(t, err)
+	assert.False(t, exempt)
+
+	exempt, err = storage.Exempt(ctx, exemptAccount, currency)
+	assert.NoError(t, err)
+	assert.True(t, exempt)
+
+	// Accounts stay exempt after the block is orphaned.
+	txn = storage.db.NewDatabaseTransaction(ctx, true)
+	_, err = storage.RemovingBlock(ctx, block, txn)
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(ctx))
+
+	exempt, err = storage.Exempt(ctx, exemptAccount, currency)
+	assert.NoError(t, err)
+	assert.True(t, exempt)
+}
+
+func TestSupply(t *testing.T) {
+	var (
+		account = &types.AccountIdentifier{
+			Address: "blah",
+		}
+		account2 = &types.AccountIdentifier{
+			Address: "blah2",
+		}
+		currency = &types.Currency{
+			Symbol:   "BLAH",
+			Decimals: 2,
+		}
+		block0 = &types.BlockIdentifier{
+			Hash:  "0",
+			Index: 0,
+		}
+		block1 = &types.BlockIdentifier{
+			Hash:  "1",
+			Index: 1,
+		}
+		block2 = &types.BlockIdentifier{
+			Hash:  "2",
+			Index: 2,
+		}
+		transfer = func(account *types.AccountIdentifier, value string) *types.Operation {
+			return &types.Operation{
+				OperationIdentifier: &types.OperationIdentifier{
+					Index: 0,
+				},
+				Type:    "Transfer",
+				Status:  "Success",
+				Account: account,
+				Amount: &types.Amount{
+					Value:    value,
+					Currency: currency,
+				},
+			}
+		}
+		firstBlock = &types.Block{
+			BlockIdentifier:       block1,
+			ParentBlockIdentifier: block0,
+			Transactions: []*types.Transaction{
+				{
+					TransactionIdentifier: &types.TransactionIdentifier{
+						Hash: "tx1",
+					},
+					Operations: []*types.Operation{
+						transfer(account, "100"),
+					},
+				},
+			},
+		}
+		secondBlock = &types.Block{
+			BlockIdentifier:       block2,
+			ParentBlockIdentifier: block1,
+			Transactions: []*types.Transaction{
+				{
+					TransactionIdentifier: &types.TransactionIdentifier{
+						Hash: "tx2",
+					},
+					Operations: []*types.Operation{
+						transfer(account, "-40"),
+						transfer(account2, "30"),
+					},
+				},
+			},
+		}
+	)
+
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	storage := NewBalanceStorage(database)
+	storage.Initialize(&MockBalanceStorageHelper{}, nil)
+	storage.EnableSupply()
+
+	getSupply := func() string {
+		txn := storage.db.NewDatabaseTransaction(ctx, false)
+		defer txn.Discard(ctx)
+
+		supply, err := storage.GetSupply(ctx, txn, currency)
+		assert.NoError(t, err)
+		assert.Equal(t, currency, supply.Currency)
+
+		return supply.Value
+	}
+
+	t.Run("no supply", func(t *testing.T) {
+		assert.Equal(t, "0", getSupply())
+
+		supply, err := storage.GetAllSupply(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, supply, 0)
+	})
+
+	t.Run("add blocks", func(t *testing.T) {
+		for _, block := range []*types.Block{firstBlock, secondBlock} {
+			txn := storage.db.NewDatabaseTransaction(ctx, true)
+			_, err := storage.AddingBlock(ctx, block, txn)
+			assert.NoError(t, err)
+			assert.NoError(t, txn.Commit(ctx))
+		}
+
+		assert.Equal(t, "90", getSupply())
+	})
+
+	t.Run("remove block", func(t *testing.T) {
+		txn := storage.db.NewDatabaseTransaction(ctx, true)
+		_, err := storage.RemovingBlock(ctx, secondBlock, txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+
+		assert.Equal(t, "100", getSupply())
+	})
+
+	t.Run("overwrite balance", func(t *testing.T) {
+		txn := storage.db.NewDatabaseTransaction(ctx, true)
+		err := storage.SetBalance(ctx, txn, account, &types.Amount{
+			Value:    "25",
+			Currency: currency,
+		}, block1)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+
+		assert.Equal(t, "25", getSupply())
+	})
+
+	t.Run("get all supply", func(t *testing.T) {
+		supply, err := storage.GetAllSupply(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*Supply{
+			{
+				Value:                "25",
+				NegativeBalanceValue: "0",
+				Currency:             currency,
+			},
+		}, supply)
+
+		// Supply entries must not be returned as balances
+		accounts, err := storage.GetAllAccountCurrency(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, accounts, 2)
+	})
+
+	t.Run("supply disabled", func(t *testing.T) {
+		disabledDir, err := utils.CreateTempDir()
+		assert.NoError(t, err)
+		defer utils.RemoveTempDir(disabledDir)
+
+		disabledDatabase, err := NewBadgerStorage(ctx, disabledDir)
+		assert.NoError(t, err)
+		defer disabledDatabase.Close(ctx)
+
+		disabledStorage := NewBalanceStorage(disabledDatabase)
+		disabledStorage.Initialize(&MockBalanceStorageHelper{}, nil)
+
+		txn := disabledStorage.db.NewDatabaseTransaction(ctx, true)
+		_, err = disabledStorage.AddingBlock(ctx, firstBlock, txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+
+		supply, err := disabledStorage.GetAllSupply(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, supply, 0)
+	})
+}
+
 type MockBalanceStorageHelper struct {
 	AccountBalanceAmount string
 	AccountBalances      map[string]string
@@ -40,9 +40,20 @@ const (
 	// with the index of the stored block.
 	blockHashNamespace = "block-hash"
 
+	// blockIndexNamespace is prepended to the index of any
+	// stored block. It is used to lookup the block at an index
+	// (so that blocks can be pruned without walking back from
+	// the head block).
+	blockIndexNamespace = "block-index"
+
 	// transactionHashNamespace is prepended to any stored
 	// transaction hash.
 	transactionHashNamespace = "transaction-hash"
+
+	// prunedIndexKey is used to lookup the index of the most
+	// recently pruned block. All blocks with an index less than
+	// or equal to this index are pruned.
+	prunedIndexKey = "pruned-index"
 )
 
 var (
@@ -65,12 +76,20 @@ var (
 	// ErrDuplicateTransactionHash is returned when a transaction
 	// hash cannot be stored because it is a duplicate.
 	ErrDuplicateTransactionHash = errors.New("duplicate transaction hash")
+
+	// ErrBlockPruned is returned when attempting to remove
+	// a block that has been pruned.
+	ErrBlockPruned = errors.New("block pruned")
 )
 
 func getHeadBlockKey() []byte {
 	return []byte(headBlockKey)
 }
 
+func getPrunedIndexKey() []byte {
+	return []byte(prunedIndexKey)
+}
+
 func getBlockKey(blockIdentifier *types.BlockIdentifier) []byte {
 	return []byte(
 		fmt.Sprintf("%s/%s/%d", blockNamespace, blockIdentifier.Hash, blockIdentifier.Index),
@@ -81,6 +100,10 @@ func getBlockHashKey(blockIdentifier *types.BlockIdentifier) []byte {
 	return []byte(fmt.Sprintf("%s/%s", blockHashNamespace, blockIdentifier.Hash))
 }
 
+func getBlockIndexKey(index int64) []byte {
+	return []byte(fmt.Sprintf("%s/%d", blockIndexNamespace, index))
+}
+
 func getTransactionHashKey(transactionIdentifier *types.TransactionIdentifier) []byte {
 	return []byte(fmt.Sprintf("%s/%s", transactionHashNamespace, transactionIdentifier.Hash))
 }
@@ -220,6 +243,17 @@ func (b *BlockStorage) AddBlock(
 		return fmt.Errorf("%w: unable to store block hash", err)
 	}
 
+	// Store block identifier by index
+	indexBuf, err := encode(block.BlockIdentifier)
+	if err != nil {
+		return err
+	}
+
+	err = transaction.Set(ctx, getBlockIndexKey(block.BlockIdentifier.Index), indexBuf)
+	if err != nil {
+		return fmt.Errorf("%w: unable to store block index", err)
+	}
+
 	// Store all transaction hashes
 	for _, txn := range block.Transactions {
 		err = b.storeTransactionHash(
@@ -244,6 +278,17 @@ func (b *BlockStorage) RemoveBlock(
 	ctx context.Context,
 	blockIdentifier *types.BlockIdentifier,
 ) error {
+	prunedIndex, err := b.GetPrunedIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get pruned index", err)
+	}
+
+	// A pruned block does not contain its transactions, so
+	// it is not possible to revert its changes.
+	if blockIdentifier.Index <= prunedIndex {
+		return fmt.Errorf("%w: unable to remove block %+v", ErrBlockPruned, blockIdentifier)
+	}
+
 	block, err := b.GetBlock(ctx, blockIdentifier)
 	if err != nil {
 		return err
@@ -266,6 +311,11 @@ func (b *BlockStorage) RemoveBlock(
 		return err
 	}
 
+	// Remove block index
+	if err := transaction.Delete(ctx, getBlockIndexKey(blockIdentifier.Index)); err != nil {
+		return err
+	}
+
 	// Remove block
 	if err := transaction.Delete(ctx, getBlockKey(blockIdentifier)); err != nil {
 		return err
@@ -357,6 +407,228 @@ func (b *BlockStorage) SetNewStartIndex(
 	return nil
 }
 
+// GetPrunedIndex returns the index of the most recently
+// pruned block. If no blocks have been pruned, -1 is returned.
+func (b *BlockStorage) GetPrunedIndex(ctx context.Context) (int64, error) {
+	transaction := b.db.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	exists, val, err := transaction.Get(ctx, getPrunedIndexKey())
+	if err != nil {
+		return -1, err
+	}
+
+	if !exists {
+		return -1, nil
+	}
+
+	var index int64
+	if err := decode(val, &index); err != nil {
+		return -1, fmt.Errorf("%w: unable to decode pruned index", err)
+	}
+
+	return index, nil
+}
+
+// Prune removes the transactions of all blocks with an index less
+// than or equal to the provided index. The identifiers of pruned blocks
+// are kept so that the block cache can still be created and block
+// existence can still be checked. If pruneTransactionHashes is true,
+// the transaction hashes of pruned blocks are also removed (which
+// means duplicate transactions in pruned blocks will not be detected).
+//
+// Blocks are pruned in separate database transactions (oldest
+// first) to avoid blocking syncing. Only blocks after the pruned
+// index are looked up, so each invocation only reads the blocks
+// it prunes. The number of pruned blocks is returned.
+func (b *BlockStorage) Prune(
+	ctx context.Context,
+	index int64,
+	pruneTransactionHashes bool,
+) (int, error) {
+	prunedIndex, err := b.GetPrunedIndex(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: unable to get pruned index", err)
+	}
+
+	if index <= prunedIndex {
+		return 0, nil
+	}
+
+	head, err := b.GetHeadBlockIdentifier(ctx)
+	if errors.Is(err, ErrHeadBlockNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Blocks are looked up by index starting after the
+	// pruned index. If any block is not stored by index (it
+	// was stored before blocks were stored by index or it
+	// is being orphaned), we walk back from the head block
+	// instead.
+	toPrune, err := b.blocksAtIndexes(ctx, prunedIndex+1, index)
+	if err != nil {
+		return 0, err
+	}
+
+	if toPrune == nil {
+		toPrune, err = b.blocksBeforeHead(ctx, head, prunedIndex, index)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for i, blockIdentifier := range toPrune {
+		if err := b.pruneBlock(ctx, blockIdentifier, pruneTransactionHashes); err != nil {
+			return i, fmt.Errorf(
+				"%w: unable to prune block %+v",
+				err,
+				blockIdentifier,
+			)
+		}
+	}
+
+	return len(toPrune), nil
+}
+
+// blocksAtIndexes returns the identifiers of the blocks
+// stored at each index from start to end (inclusive).
+// If any index does not have a stored block, nil is
+// returned.
+func (b *BlockStorage) blocksAtIndexes(
+	ctx context.Context,
+	start int64,
+	end int64,
+) ([]*types.BlockIdentifier, error) {
+	transaction := b.db.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	blocks := []*types.BlockIdentifier{}
+	for index := start; index <= end; index++ {
+		exists, val, err := transaction.Get(ctx, getBlockIndexKey(index))
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, nil
+		}
+
+		var blockIdentifier types.BlockIdentifier
+		if err := decode(val, &blockIdentifier); err != nil {
+			return nil, fmt.Errorf("%w: unable to decode block index %d", err, index)
+		}
+
+		blocks = append(blocks, &blockIdentifier)
+	}
+
+	return blocks, nil
+}
+
+// blocksBeforeHead walks back from the head block and returns
+// the identifiers of all blocks with an index greater than
+// prunedIndex and less than or equal to index (oldest first).
+// If a block is orphaned while we walk back, no blocks are
+// returned (and we try again on the next invocation).
+func (b *BlockStorage) blocksBeforeHead(
+	ctx context.Context,
+	head *types.BlockIdentifier,
+	prunedIndex int64,
+	index int64,
+) ([]*types.BlockIdentifier, error) {
+	blocks := []*types.BlockIdentifier{}
+	currBlock := head
+	for currBlock.Index > prunedIndex {
+		block, err := b.GetBlock(ctx, currBlock)
+		if errors.Is(err, ErrBlockNotFound) {
+			return []*types.BlockIdentifier{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if currBlock.Index <= index {
+			blocks = append(blocks, currBlock)
+		}
+
+		// Stop at the genesis block
+		if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+			break
+		}
+
+		currBlock = block.ParentBlockIdentifier
+	}
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+
+	return blocks, nil
+}
+
+func (b *BlockStorage) pruneBlock(
+	ctx context.Context,
+	blockIdentifier *types.BlockIdentifier,
+	pruneTransactionHashes bool,
+) error {
+	transaction := b.db.NewDatabaseTransaction(ctx, true)
+	defer transaction.Discard(ctx)
+
+	exists, val, err := transaction.Get(ctx, getBlockKey(blockIdentifier))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("%w %+v", ErrBlockNotFound, blockIdentifier)
+	}
+
+	var block types.Block
+	if err := decode(val, &block); err != nil {
+		return err
+	}
+
+	if pruneTransactionHashes {
+		for _, txn := range block.Transactions {
+			err := b.removeTransactionHash(
+				ctx,
+				transaction,
+				blockIdentifier,
+				txn.TransactionIdentifier,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	buf, err := encode(&types.Block{
+		BlockIdentifier:       block.BlockIdentifier,
+		ParentBlockIdentifier: block.ParentBlockIdentifier,
+		Timestamp:             block.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := transaction.Set(ctx, getBlockKey(blockIdentifier), buf); err != nil {
+		return err
+	}
+
+	indexBuf, err := encode(blockIdentifier.Index)
+	if err != nil {
+		return err
+	}
+
+	if err := transaction.Set(ctx, getPrunedIndexKey(), indexBuf); err != nil {
+		return err
+	}
+
+	return transaction.Commit(ctx)
+}
+
 // CreateBlockCache populates a slice of blocks with the most recent
 // ones in storage.
 func (b *BlockStorage) CreateBlockCache(ctx context.Context) []*types.BlockIdentifier {
@@ -16,6 +16,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -424,3 +425,187 @@ func TestCreateBlockCache(t *testing.T) {
 		)
 	})
 }
+
+// prunableBlocks returns a chain of count blocks
+// (starting at genesis) with 1 transaction each.
+func prunableBlocks(count int64) []*types.Block {
+	blocks := []*types.Block{}
+	for i := int64(0); i < count; i++ {
+		parentIndex := i - 1
+		if i == 0 {
+			parentIndex = 0
+		}
+
+		blocks = append(blocks, &types.Block{
+			BlockIdentifier: &types.BlockIdentifier{
+				Hash:  fmt.Sprintf("block %d", i),
+				Index: i,
+			},
+			ParentBlockIdentifier: &types.BlockIdentifier{
+				Hash:  fmt.Sprintf("block %d", parentIndex),
+				Index: parentIndex,
+			},
+			Timestamp: i + 1,
+			Transactions: []*types.Transaction{
+				simpleTransactionFactory(
+					fmt.Sprintf("tx %d", i),
+					"addr1",
+					"100",
+					&types.Currency{Symbol: "hello"},
+				),
+			},
+		})
+	}
+
+	return blocks
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	storage := NewBlockStorage(database)
+	blocks := prunableBlocks(5)
+
+	t.Run("no blocks", func(t *testing.T) {
+		pruned, err := storage.Prune(ctx, 2, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+
+		prunedIndex, err := storage.GetPrunedIndex(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-1), prunedIndex)
+	})
+
+	t.Run("prune blocks", func(t *testing.T) {
+		for _, block := range blocks {
+			assert.NoError(t, storage.AddBlock(ctx, block))
+		}
+
+		pruned, err := storage.Prune(ctx, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+
+		prunedIndex, err := storage.GetPrunedIndex(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), prunedIndex)
+
+		block, err := storage.GetBlock(ctx, blocks[1].BlockIdentifier)
+		assert.NoError(t, err)
+		assert.Equal(t, &types.Block{
+			BlockIdentifier:       blocks[1].BlockIdentifier,
+			ParentBlockIdentifier: blocks[1].ParentBlockIdentifier,
+			Timestamp:             blocks[1].Timestamp,
+		}, block)
+
+		block, err = storage.GetBlock(ctx, blocks[2].BlockIdentifier)
+		assert.NoError(t, err)
+		assert.Equal(t, blocks[2], block)
+
+		// Transaction hashes are kept for duplicate detection
+		txBlocks, _, err := storage.FindTransaction(
+			ctx,
+			blocks[0].Transactions[0].TransactionIdentifier,
+		)
+		assert.NoError(t, err)
+		assert.Len(t, txBlocks, 1)
+	})
+
+	t.Run("prune already pruned blocks", func(t *testing.T) {
+		pruned, err := storage.Prune(ctx, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+	})
+
+	t.Run("prune transaction hashes", func(t *testing.T) {
+		pruned, err := storage.Prune(ctx, 2, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+
+		txBlocks, _, err := storage.FindTransaction(
+			ctx,
+			blocks[2].Transactions[0].TransactionIdentifier,
+		)
+		assert.NoError(t, err)
+		assert.Nil(t, txBlocks)
+	})
+
+	t.Run("block cache includes pruned blocks", func(t *testing.T) {
+		cache := storage.CreateBlockCache(ctx)
+		for _, block := range blocks {
+			assert.Contains(t, cache, block.BlockIdentifier)
+		}
+	})
+
+	t.Run("remove pruned block", func(t *testing.T) {
+		assert.NoError(t, storage.RemoveBlock(ctx, blocks[4].BlockIdentifier))
+		assert.NoError(t, storage.RemoveBlock(ctx, blocks[3].BlockIdentifier))
+
+		err := storage.RemoveBlock(ctx, blocks[2].BlockIdentifier)
+		assert.True(t, errors.Is(err, ErrBlockPruned))
+	})
+}
+
+func TestPruneBlockIndex(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	storage := NewBlockStorage(database)
+	blocks := prunableBlocks(5)
+	for _, block := range blocks {
+		assert.NoError(t, storage.AddBlock(ctx, block))
+	}
+
+	t.Run("blocks stored without index", func(t *testing.T) {
+		txn := database.NewDatabaseTransaction(ctx, true)
+		assert.NoError(t, txn.Delete(ctx, getBlockIndexKey(0)))
+		assert.NoError(t, txn.Commit(ctx))
+
+		// Blocks are found by walking back from the head.
+		pruned, err := storage.Prune(ctx, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+	})
+
+	t.Run("blocks stored by index", func(t *testing.T) {
+		// The head block is not read when blocks
+		// after the pruned index are stored by index.
+		txn := database.NewDatabaseTransaction(ctx, true)
+		assert.NoError(t, storage.StoreHeadBlockIdentifier(
+			ctx,
+			txn,
+			&types.BlockIdentifier{Hash: "missing", Index: 10},
+		))
+		assert.NoError(t, txn.Commit(ctx))
+
+		pruned, err := storage.Prune(ctx, 3, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+
+		prunedIndex, err := storage.GetPrunedIndex(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), prunedIndex)
+	})
+
+	t.Run("remove block index", func(t *testing.T) {
+		assert.NoError(t, storage.RemoveBlock(ctx, blocks[4].BlockIdentifier))
+
+		indexed, err := storage.blocksAtIndexes(ctx, 4, 4)
+		assert.NoError(t, err)
+		assert.Nil(t, indexed)
+	})
+}
@@ -16,9 +16,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
@@ -26,6 +28,23 @@ const (
 	coinNamespace        = "coinNamespace"
 	coinAccountNamespace = "coinAccountNamespace"
 
+	// coinSpentNamespace is prepended to the identifier of
+	// each spent coin (to detect coins spent twice).
+	coinSpentNamespace = "coinSpentNamespace"
+
+	// coinSpentBlockNamespace is prepended to the index of the
+	// block where each coin was spent (so that spent coins can
+	// be pruned with blocks).
+	coinSpentBlockNamespace = "coinSpentBlockNamespace"
+
+	// coinPrunedIndexKey stores the index of the most recent
+	// block with pruned spent coins.
+	coinPrunedIndexKey = "coinPrunedIndex"
+
+	// coinGenesisKey stores whether coins have been
+	// tracked since the genesis block.
+	coinGenesisKey = "coinGenesis"
+
 	// For UTXOs to be recognized by CoinStorage, they must contain
 	// coinCreated or coinSpent in the Operation.Metadata with a value
 	// of the unique identifier of the coin. In Bitcoin, this unique
@@ -34,6 +53,24 @@ const (
 	coinSpent   = "utxo_spent"
 )
 
+var (
+	// ErrDuplicateCoin is returned when a coin
+	// is created twice.
+	ErrDuplicateCoin = errors.New("coin created twice")
+
+	// ErrCoinSpentTwice is returned when a coin
+	// is spent twice.
+	ErrCoinSpentTwice = errors.New("coin spent twice")
+
+	// ErrCoinSpentBeforeCreated is returned when a coin is
+	// spent before it is created.
+	ErrCoinSpentBeforeCreated = errors.New("coin spent before it was created")
+
+	// ErrCoinBalanceMismatch is returned when the sum of the
+	// unspent coins of an account does not equal its balance.
+	ErrCoinBalanceMismatch = errors.New("unspent coins do not equal balance")
+)
+
 var _ BlockWorker = (*CoinStorage)(nil)
 
 // CoinStorage implements storage methods for storing
@@ -42,6 +79,13 @@ type CoinStorage struct {
 	db Database
 
 	asserter *asserter.Asserter
+
+	balanceCheckEnabled bool
+
+	// balanceCheckSkipped contains the hash of each
+	// reconciler.AccountCurrency skipped by the
+	// balance check.
+	balanceCheckSkipped map[string]struct{}
 }
 
 // NewCoinStorage returns a new CoinStorage.
@@ -93,62 +137,6 @@ func getAndDecodeCoin(
 	return true, &coin, nil
 }
 
-func (c *CoinStorage) tryAddingCoin(
-	ctx context.Context,
-	transaction DatabaseTransaction,
-	blockTransaction *types.Transaction,
-	operation *types.Operation,
-	identiferKey string,
-) error {
-	rawIdentifier, ok := operation.Metadata[identiferKey]
-	if ok {
-		coinIdentifier, ok := rawIdentifier.(string)
-		if !ok {
-			return fmt.Errorf("unable to parse created coin %v", rawIdentifier)
-		}
-
-		newCoin := &Coin{
-			Identifier:  coinIdentifier,
-			Transaction: blockTransaction,
-			Operation:   operation,
-		}
-
-		encodedResult, err := encode(newCoin)
-		if err != nil {
-			return fmt.Errorf("%w: unable to encode coin data", err)
-		}
-
-		if err := transaction.Set(ctx, getCoinKey(coinIdentifier), encodedResult); err != nil {
-			return fmt.Errorf("%w: unable to store coin", err)
-		}
-
-		accountExists, coins, err := getAndDecodeCoins(ctx, transaction, operation.Account)
-		if err != nil {
-			return fmt.Errorf("%w: unable to query coin account", err)
-		}
-
-		if !accountExists {
-			coins = map[string]struct{}{}
-		}
-
-		if _, exists := coins[coinIdentifier]; exists {
-			return fmt.Errorf(
-				"coin %s already exists in account %s",
-				coinIdentifier,
-				types.PrettyPrintStruct(operation.Account),
-			)
-		}
-
-		coins[coinIdentifier] = struct{}{}
-
-		if err := encodeAndSetCoins(ctx, transaction, operation.Account, coins); err != nil {
-			return fmt.Errorf("%w: unable to set coin account", err)
-		}
-	}
-
-	return nil
-}
-
 func encodeAndSetCoins(
 	ctx context.Context,
 	transaction DatabaseTransaction,
@@ -189,54 +177,380 @@ func getAndDecodeCoins(
 	return true, coins, nil
 }
 
-func (c *CoinStorage) tryRemovingCoin(
+func getCoinSpentKey(identifier string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", coinSpentNamespace, identifier))
+}
+
+func getCoinSpentBlockPrefix(index int64) []byte {
+	return []byte(fmt.Sprintf("%s/%d/", coinSpentBlockNamespace, index))
+}
+
+func getCoinSpentBlockKey(index int64, identifier string) []byte {
+	return append(getCoinSpentBlockPrefix(index), []byte(identifier)...)
+}
+
+// coinIdentifier returns the identifier of the coin created
+// or spent (depending on identifierKey) by an operation, if any.
+func coinIdentifier(operation *types.Operation, identifierKey string) (string, bool, error) {
+	rawIdentifier, ok := operation.Metadata[identifierKey]
+	if !ok {
+		return "", false, nil
+	}
+
+	identifier, ok := rawIdentifier.(string)
+	if !ok {
+		return "", false, fmt.Errorf("unable to parse coin identifier %v", rawIdentifier)
+	}
+
+	return identifier, true, nil
+}
+
+// storeCoin stores a coin and adds it to the
+// coins of its owner.
+func storeCoin(
 	ctx context.Context,
 	transaction DatabaseTransaction,
-	operation *types.Operation,
-	identiferKey string,
+	coin *Coin,
 ) error {
-	rawIdentifier, ok := operation.Metadata[identiferKey]
-	if ok {
-		coinIdentifier, ok := rawIdentifier.(string)
-		if !ok {
-			return fmt.Errorf("unable to parse spent coin %v", rawIdentifier)
+	encodedResult, err := encode(coin)
+	if err != nil {
+		return fmt.Errorf("%w: unable to encode coin data", err)
+	}
+
+	if err := transaction.Set(ctx, getCoinKey(coin.Identifier), encodedResult); err != nil {
+		return fmt.Errorf("%w: unable to store coin", err)
+	}
+
+	accountExists, coins, err := getAndDecodeCoins(ctx, transaction, coin.Operation.Account)
+	if err != nil {
+		return fmt.Errorf("%w: unable to query coin account", err)
+	}
+
+	if !accountExists {
+		coins = map[string]struct{}{}
+	}
+
+	if _, exists := coins[coin.Identifier]; exists {
+		return fmt.Errorf(
+			"%w: coin %s already exists in account %s",
+			ErrDuplicateCoin,
+			coin.Identifier,
+			types.PrettyPrintStruct(coin.Operation.Account),
+		)
+	}
+
+	coins[coin.Identifier] = struct{}{}
+
+	if err := encodeAndSetCoins(ctx, transaction, coin.Operation.Account, coins); err != nil {
+		return fmt.Errorf("%w: unable to set coin account", err)
+	}
+
+	return nil
+}
+
+// deleteCoin deletes a coin and removes it
+// from the coins of its owner.
+func deleteCoin(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	account *types.AccountIdentifier,
+	identifier string,
+) error {
+	if err := transaction.Delete(ctx, getCoinKey(identifier)); err != nil {
+		return fmt.Errorf("%w: unable to delete coin", err)
+	}
+
+	accountExists, coins, err := getAndDecodeCoins(ctx, transaction, account)
+	if err != nil {
+		return fmt.Errorf("%w: unable to query coin account", err)
+	}
+
+	if !accountExists {
+		return fmt.Errorf("unable to find owner of coin %s", identifier)
+	}
+
+	if _, exists := coins[identifier]; !exists {
+		return fmt.Errorf(
+			"unable to find coin %s in account %s",
+			identifier,
+			types.PrettyPrintStruct(account),
+		)
+	}
+
+	delete(coins, identifier)
+
+	if err := encodeAndSetCoins(ctx, transaction, account, coins); err != nil {
+		return fmt.Errorf("%w: unable to set coin account", err)
+	}
+
+	return nil
+}
+
+// createCoin stores a coin created by an operation and
+// returns a boolean indicating if the operation created a coin.
+func (c *CoinStorage) createCoin(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	blockTransaction *types.Transaction,
+	operation *types.Operation,
+) (bool, error) {
+	identifier, ok, err := coinIdentifier(operation, coinCreated)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	exists, _, err := transaction.Get(ctx, getCoinKey(identifier))
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to query for coin", err)
+	}
+
+	if exists {
+		return false, fmt.Errorf("%w: %s", ErrDuplicateCoin, identifier)
+	}
+
+	spent, spentCoin, err := transaction.Get(ctx, getCoinSpentKey(identifier))
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to query for spent coin", err)
+	}
+
+	// An empty spent coin was spent before we knew it
+	// was created.
+	if spent && len(spentCoin) > 0 {
+		return false, fmt.Errorf("%w: %s", ErrDuplicateCoin, identifier)
+	}
+
+	if spent {
+		return false, fmt.Errorf("%w: %s", ErrCoinSpentBeforeCreated, identifier)
+	}
+
+	if err := storeCoin(ctx, transaction, &Coin{
+		Identifier:  identifier,
+		Transaction: blockTransaction,
+		Operation:   operation,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// spendCoin deletes a coin spent by an operation and records
+// that it was spent (with the spent coin, if it is known). A
+// boolean indicating if the operation spent a coin is returned.
+func (c *CoinStorage) spendCoin(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	block *types.BlockIdentifier,
+	operation *types.Operation,
+	fromGenesis bool,
+) (bool, error) {
+	identifier, ok, err := coinIdentifier(operation, coinSpent)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	spent, _, err := transaction.Get(ctx, getCoinSpentKey(identifier))
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to query for spent coin", err)
+	}
+
+	if spent {
+		return false, fmt.Errorf("%w: %s", ErrCoinSpentTwice, identifier)
+	}
+
+	exists, coin, err := getAndDecodeCoin(ctx, transaction, identifier)
+	if err != nil {
+		return false, err
+	}
+
+	// If we did not start syncing at genesis, the coin
+	// could have been created before we started syncing.
+	if !exists && fromGenesis {
+		return false, fmt.Errorf("%w: %s", ErrCoinSpentBeforeCreated, identifier)
+	}
+
+	spentCoin := []byte{}
+	if exists {
+		if err := deleteCoin(ctx, transaction, operation.Account, identifier); err != nil {
+			return false, err
 		}
 
-		exists, _, err := transaction.Get(ctx, getCoinKey(coinIdentifier))
+		spentCoin, err = encode(coin)
 		if err != nil {
-			return fmt.Errorf("%w: unable to query for coin", err)
+			return false, fmt.Errorf("%w: unable to encode spent coin", err)
 		}
+	}
 
-		if !exists { // this could occur if coin was created before we started syncing
-			return nil
-		}
+	if err := transaction.Set(ctx, getCoinSpentKey(identifier), spentCoin); err != nil {
+		return false, fmt.Errorf("%w: unable to store spent coin", err)
+	}
 
-		if err := transaction.Delete(ctx, getCoinKey(coinIdentifier)); err != nil {
-			return fmt.Errorf("%w: unable to delete coin", err)
-		}
+	err = transaction.Set(ctx, getCoinSpentBlockKey(block.Index, identifier), []byte(identifier))
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to store spent coin block", err)
+	}
+
+	return true, nil
+}
+
+// uncreateCoin deletes a coin created by an operation
+// in a removed block.
+func (c *CoinStorage) uncreateCoin(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	operation *types.Operation,
+) error {
+	identifier, ok, err := coinIdentifier(operation, coinCreated)
+	if err != nil || !ok {
+		return err
+	}
+
+	return deleteCoin(ctx, transaction, operation.Account, identifier)
+}
+
+// unspendCoin restores a coin spent by an operation
+// in a removed block.
+func (c *CoinStorage) unspendCoin(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	block *types.BlockIdentifier,
+	blockTransaction *types.Transaction,
+	operation *types.Operation,
+) error {
+	identifier, ok, err := coinIdentifier(operation, coinSpent)
+	if err != nil || !ok {
+		return err
+	}
 
-		accountExists, coins, err := getAndDecodeCoins(ctx, transaction, operation.Account)
+	_, spentCoin, err := transaction.Get(ctx, getCoinSpentKey(identifier))
+	if err != nil {
+		return fmt.Errorf("%w: unable to query for spent coin", err)
+	}
+
+	if err := transaction.Delete(ctx, getCoinSpentKey(identifier)); err != nil {
+		return fmt.Errorf("%w: unable to delete spent coin", err)
+	}
+
+	if err := transaction.Delete(ctx, getCoinSpentBlockKey(block.Index, identifier)); err != nil {
+		return fmt.Errorf("%w: unable to delete spent coin block", err)
+	}
+
+	// If the coin was created before we started syncing, we
+	// only know the operation that spent it.
+	if len(spentCoin) == 0 {
+		return storeCoin(ctx, transaction, &Coin{
+			Identifier:  identifier,
+			Transaction: blockTransaction,
+			Operation:   operation,
+		})
+	}
+
+	var coin Coin
+	if err := decode(spentCoin, &coin); err != nil {
+		return fmt.Errorf("%w: unable to decode spent coin", err)
+	}
+
+	return storeCoin(ctx, transaction, &coin)
+}
+
+// EnableBalanceCheck causes CoinStorage to check that the sum of
+// the unspent coins of each account with a coin created or spent
+// in a block equals its balance in BalanceStorage. BalanceStorage
+// must process each block before CoinStorage. The check is only
+// performed if coins have been tracked since genesis and is
+// skipped for skippedAccounts (like accounts allowed to go
+// negative in BalanceStorage).
+func (c *CoinStorage) EnableBalanceCheck(skippedAccounts []*reconciler.AccountCurrency) {
+	c.balanceCheckEnabled = true
+	c.balanceCheckSkipped = map[string]struct{}{}
+	for _, account := range skippedAccounts {
+		c.balanceCheckSkipped[types.Hash(account)] = struct{}{}
+	}
+}
+
+// trackedFromGenesis returns a boolean indicating if coins have
+// been tracked since the genesis block. This is determined by
+// the first block added to CoinStorage.
+func (c *CoinStorage) trackedFromGenesis(
+	ctx context.Context,
+	block *types.Block,
+	transaction DatabaseTransaction,
+) (bool, error) {
+	exists, val, err := transaction.Get(ctx, []byte(coinGenesisKey))
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to query for coin genesis", err)
+	}
+
+	if exists {
+		return len(val) > 0 && val[0] == 1, nil
+	}
+
+	config, err := c.asserter.ClientConfiguration()
+	if err != nil {
+		return false, fmt.Errorf("%w: unable to get genesis block", err)
+	}
+
+	fromGenesis := block.BlockIdentifier != nil &&
+		block.BlockIdentifier.Index == config.GenesisBlockIdentifier.Index
+
+	val = []byte{0}
+	if fromGenesis {
+		val = []byte{1}
+	}
+
+	if err := transaction.Set(ctx, []byte(coinGenesisKey), val); err != nil {
+		return false, fmt.Errorf("%w: unable to store coin genesis", err)
+	}
+
+	return fromGenesis, nil
+}
+
+// checkBalance returns an error if the sum of the unspent coins of
+// an account in a currency does not equal its stored balance.
+func (c *CoinStorage) checkBalance(
+	ctx context.Context,
+	block *types.Block,
+	transaction DatabaseTransaction,
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+) error {
+	_, coins, err := getAndDecodeCoins(ctx, transaction, account)
+	if err != nil {
+		return err
+	}
+
+	sum := "0"
+	for identifier := range coins {
+		_, coin, err := getAndDecodeCoin(ctx, transaction, identifier)
 		if err != nil {
-			return fmt.Errorf("%w: unable to query coin account", err)
+			return err
 		}
 
-		if !accountExists {
-			return fmt.Errorf("%w: unable to find owner of coin", err)
+		if types.Hash(coin.Operation.Amount.Currency) != types.Hash(currency) {
+			continue
 		}
 
-		if _, exists := coins[coinIdentifier]; !exists {
-			return fmt.Errorf(
-				"unable to find coin %s in account %s",
-				coinIdentifier,
-				types.PrettyPrintStruct(operation.Account),
-			)
+		sum, err = types.AddValues(sum, coin.Operation.Amount.Value)
+		if err != nil {
+			return err
 		}
+	}
 
-		delete(coins, coinIdentifier)
+	balance, err := getStoredValue(ctx, transaction, GetBalanceKey(account, currency))
+	if err != nil {
+		return fmt.Errorf("%w: unable to get balance", err)
+	}
 
-		if err := encodeAndSetCoins(ctx, transaction, operation.Account, coins); err != nil {
-			return fmt.Errorf("%w: unable to set coin account", err)
-		}
+	if sum != balance {
+		return fmt.Errorf(
+			"%w: unspent coins of %s sum to %s %+v but balance is %s at %+v",
+			ErrCoinBalanceMismatch,
+			types.PrettyPrintStruct(account),
+			sum,
+			currency,
+			balance,
+			block.BlockIdentifier,
+		)
 	}
 
 	return nil
@@ -248,6 +562,12 @@ func (c *CoinStorage) AddingBlock(
 	block *types.Block,
 	transaction DatabaseTransaction,
 ) (CommitWorker, error) {
+	fromGenesis, err := c.trackedFromGenesis(ctx, block, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	touched := map[string]*reconciler.AccountCurrency{}
 	for _, txn := range block.Transactions {
 		for _, operation := range txn.Operations {
 			success, err := c.asserter.OperationSuccessful(operation)
@@ -263,13 +583,48 @@ func (c *CoinStorage) AddingBlock(
 				continue
 			}
 
-			if err := c.tryAddingCoin(ctx, transaction, txn, operation, coinCreated); err != nil {
+			created, err := c.createCoin(ctx, transaction, txn, operation)
+			if err != nil {
 				return nil, fmt.Errorf("%w: unable to add coin", err)
 			}
 
-			if err := c.tryRemovingCoin(ctx, transaction, operation, coinSpent); err != nil {
+			spent, err := c.spendCoin(
+				ctx,
+				transaction,
+				block.BlockIdentifier,
+				operation,
+				fromGenesis,
+			)
+			if err != nil {
 				return nil, fmt.Errorf("%w: unable to remove coin", err)
 			}
+
+			// Operations without a coin (like a fee paid from
+			// a balance) are not expected to match the sum of
+			// unspent coins.
+			if !created && !spent {
+				continue
+			}
+
+			accountCurrency := &reconciler.AccountCurrency{
+				Account:  operation.Account,
+				Currency: operation.Amount.Currency,
+			}
+			touched[types.Hash(accountCurrency)] = accountCurrency
+		}
+	}
+
+	if !c.balanceCheckEnabled || !fromGenesis {
+		return nil, nil
+	}
+
+	for key, t := range touched {
+		if _, ok := c.balanceCheckSkipped[key]; ok {
+			continue
+		}
+
+		if err := c.checkBalance(ctx, block, transaction, t.Account, t.Currency); err != nil {
+			return nil, err
 		}
 	}
 
@@ -282,8 +637,14 @@ func (c *CoinStorage) RemovingBlock(
 	block *types.Block,
 	transaction DatabaseTransaction,
 ) (CommitWorker, error) {
-	for _, txn := range block.Transactions {
-		for _, operation := range txn.Operations {
+	// We add spent coins and remove created coins during a re-org
+	// (opposite of AddingBlock). Operations are processed in reverse
+	// so that coins created and spent in the same block are restored
+	// before they are removed.
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		txn := block.Transactions[i]
+		for j := len(txn.Operations) - 1; j >= 0; j-- {
+			operation := txn.Operations[j]
 			success, err := c.asserter.OperationSuccessful(operation)
 			if err != nil {
 				return nil, fmt.Errorf("%w: unable to parse operation success", err)
@@ -297,13 +658,11 @@ func (c *CoinStorage) RemovingBlock(
 				continue
 			}
 
-			// We add spent coins and remove created coins during a re-org (opposite of
-			// AddingBlock).
-			if err := c.tryAddingCoin(ctx, transaction, txn, operation, coinSpent); err != nil {
+			if err := c.unspendCoin(ctx, transaction, block.BlockIdentifier, txn, operation); err != nil {
 				return nil, fmt.Errorf("%w: unable to add coin", err)
 			}
 
-			if err := c.tryRemovingCoin(ctx, transaction, operation, coinCreated); err != nil {
+			if err := c.uncreateCoin(ctx, transaction, operation); err != nil {
 				return nil, fmt.Errorf("%w: unable to remove coin", err)
 			}
 		}
@@ -312,6 +671,94 @@ func (c *CoinStorage) RemovingBlock(
 	return nil, nil
 }
 
+// getPrunedIndex returns the index of the most recent block
+// with pruned spent coins (or -1 if none have been pruned).
+func (c *CoinStorage) getPrunedIndex(ctx context.Context) (int64, error) {
+	exists, val, err := c.db.Get(ctx, []byte(coinPrunedIndexKey))
+	if err != nil {
+		return -1, err
+	}
+
+	if !exists {
+		return -1, nil
+	}
+
+	var index int64
+	if err := decode(val, &index); err != nil {
+		return -1, fmt.Errorf("%w: unable to decode pruned index", err)
+	}
+
+	return index, nil
+}
+
+// Prune removes the spent coins of all blocks with an index less
+// than or equal to index (which should be pruned from BlockStorage
+// as well, as these spent coins can no longer be restored in a
+// reorg). After a spent coin is pruned, spending it again is only
+// detected when coins have been tracked since genesis (the coin
+// is then reported as spent before it was created). The number
+// of pruned spent coins is returned.
+func (c *CoinStorage) Prune(ctx context.Context, index int64) (int, error) {
+	prunedIndex, err := c.getPrunedIndex(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%w: unable to get pruned index", err)
+	}
+
+	pruned := 0
+	for i := prunedIndex + 1; i <= index; i++ {
+		if ctx.Err() != nil {
+			return pruned, ctx.Err()
+		}
+
+		count, err := c.pruneSpentCoins(ctx, i)
+		if err != nil {
+			return pruned, fmt.Errorf("%w: unable to prune spent coins of block %d", err, i)
+		}
+
+		pruned += count
+	}
+
+	return pruned, nil
+}
+
+// pruneSpentCoins removes the spent coins of the block
+// at index and returns the number of pruned spent coins.
+func (c *CoinStorage) pruneSpentCoins(ctx context.Context, index int64) (int, error) {
+	identifiers, err := c.db.Scan(ctx, getCoinSpentBlockPrefix(index))
+	if err != nil {
+		return 0, fmt.Errorf("%w: unable to scan spent coins", err)
+	}
+
+	transaction := c.db.NewDatabaseTransaction(ctx, true)
+	defer transaction.Discard(ctx)
+
+	for _, identifier := range identifiers {
+		if err := transaction.Delete(ctx, getCoinSpentKey(string(identifier))); err != nil {
+			return 0, fmt.Errorf("%w: unable to delete spent coin", err)
+		}
+
+		err := transaction.Delete(ctx, getCoinSpentBlockKey(index, string(identifier)))
+		if err != nil {
+			return 0, fmt.Errorf("%w: unable to delete spent coin block", err)
+		}
+	}
+
+	indexBuf, err := encode(index)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := transaction.Set(ctx, []byte(coinPrunedIndexKey), indexBuf); err != nil {
+		return 0, err
+	}
+
+	if err := transaction.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(identifiers), nil
+}
+
 // GetCoins returns all unspent coins for a provided *types.AccountIdentifier.
 func (c *CoinStorage) GetCoins(
 	ctx context.Context,
@@ -16,11 +16,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/coinbase/rosetta-cli/internal/utils"
 
 	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,6 +76,10 @@ var (
 	failureStatus = "failure"
 
 	coinBlock = &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 1",
+			Index: 1,
+		},
 		Transactions: []*types.Transaction{
 			{
 				Operations: []*types.Operation{
@@ -113,6 +119,10 @@ var (
 	}
 
 	coinBlock2 = &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 2",
+			Index: 2,
+		},
 		Transactions: []*types.Transaction{
 			{
 				Operations: []*types.Operation{
@@ -132,6 +142,10 @@ var (
 	}
 
 	coinBlock3 = &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  "block 3",
+			Index: 3,
+		},
 		Transactions: []*types.Transaction{
 			{
 				Operations: []*types.Operation{
@@ -305,3 +319,344 @@ func TestCoinStorage(t *testing.T) {
 		assert.ElementsMatch(t, account3Coins, coins)
 	})
 }
+
+func lifecycleBlock(index int64, ops ...*types.Operation) *types.Block {
+	for i, op := range ops {
+		op.OperationIdentifier = &types.OperationIdentifier{Index: int64(i)}
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index),
+			Index: index,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index - 1),
+			Index: index - 1,
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: types.Hash(index),
+				},
+				Operations: ops,
+			},
+		},
+	}
+}
+
+func lifecycleOp(address string, value string, key string, identifier string) *types.Operation {
+	op := &types.Operation{
+		Type:   "Transfer",
+		Status: "Success",
+		Account: &types.AccountIdentifier{
+			Address: address,
+		},
+		Amount: &types.Amount{
+			Value: value,
+			Currency: &types.Currency{
+				Symbol:   "BTC",
+				Decimals: 8,
+			},
+		},
+	}
+
+	if len(key) > 0 {
+		op.Metadata = map[string]interface{}{
+			key: identifier,
+		}
+	}
+
+	return op
+}
+
+func TestCoinLifecycle(t *testing.T) {
+	var tests = map[string]struct {
+		blocks           []*types.Block
+		balanceCheck     bool
+		negativeAccounts []*reconciler.AccountCurrency
+
+		err error
+	}{
+		"create and spend": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(
+					1,
+					lifecycleOp("addr1", "-100", coinSpent, "coin1"),
+					lifecycleOp("addr2", "100", coinCreated, "coin2"),
+				),
+			},
+			balanceCheck: true,
+		},
+		"created twice": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(1, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+			},
+			err: ErrDuplicateCoin,
+		},
+		"created after spent": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(1, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+				lifecycleBlock(2, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+			},
+			err: ErrDuplicateCoin,
+		},
+		"spent twice": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(1, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+				lifecycleBlock(2, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+			},
+			err: ErrCoinSpentTwice,
+		},
+		"spent before created": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(1, lifecycleOp("addr1", "-100", coinSpent, "coin2")),
+			},
+			err: ErrCoinSpentBeforeCreated,
+		},
+		"spent before created (not from genesis)": {
+			blocks: []*types.Block{
+				lifecycleBlock(10, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+				lifecycleBlock(11, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+			},
+			err: ErrCoinSpentBeforeCreated,
+		},
+		"spent coin unknown (not from genesis)": {
+			blocks: []*types.Block{
+				lifecycleBlock(10, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+			},
+		},
+		"balance mismatch": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(
+					1,
+					lifecycleOp("addr1", "50", "", ""),
+					lifecycleOp("addr1", "10", coinCreated, "coin2"),
+				),
+			},
+			balanceCheck: true,
+			err:          ErrCoinBalanceMismatch,
+		},
+		"balance mismatch ignored": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(
+					1,
+					lifecycleOp("addr1", "50", "", ""),
+					lifecycleOp("addr1", "10", coinCreated, "coin2"),
+				),
+			},
+		},
+		"operation without coin": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(1, lifecycleOp("addr1", "-10", "", "")),
+			},
+			balanceCheck: true,
+		},
+		"balance mismatch of negative balance account": {
+			blocks: []*types.Block{
+				lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+				lifecycleBlock(
+					1,
+					lifecycleOp("addr1", "-150", "", ""),
+					lifecycleOp("addr1", "10", coinCreated, "coin2"),
+				),
+			},
+			balanceCheck: true,
+			negativeAccounts: []*reconciler.AccountCurrency{
+				{
+					Account: &types.AccountIdentifier{Address: "addr1"},
+					Currency: &types.Currency{
+						Symbol:   "BTC",
+						Decimals: 8,
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newDir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(newDir)
+
+			database, err := NewBadgerStorage(ctx, newDir)
+			assert.NoError(t, err)
+			defer database.Close(ctx)
+
+			helper := &MockBalanceStorageHelper{}
+			balanceStorage := NewBalanceStorage(database)
+			balanceStorage.Initialize(helper, nil)
+			balanceStorage.AllowNegativeBalances(test.negativeAccounts)
+
+			coinStorage := NewCoinStorage(database, helper.Asserter())
+			if test.balanceCheck {
+				coinStorage.EnableBalanceCheck(test.negativeAccounts)
+			}
+
+			for _, block := range test.blocks {
+				txn := database.NewDatabaseTransaction(ctx, true)
+				if test.balanceCheck {
+					_, err = balanceStorage.AddingBlock(ctx, block, txn)
+					assert.NoError(t, err)
+				}
+
+				_, err = coinStorage.AddingBlock(ctx, block, txn)
+				if err != nil {
+					txn.Discard(ctx)
+					break
+				}
+
+				assert.NoError(t, txn.Commit(ctx))
+			}
+
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCoinLifecycleReorg(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	helper := &MockBalanceStorageHelper{}
+	c := NewCoinStorage(database, helper.Asserter())
+
+	genesis := lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1"))
+	spend := lifecycleBlock(
+		1,
+		lifecycleOp("addr1", "-100", coinSpent, "coin1"),
+		lifecycleOp("addr2", "100", coinCreated, "coin2"),
+		lifecycleOp("addr2", "-100", coinSpent, "coin2"),
+	)
+
+	for _, block := range []*types.Block{genesis, spend} {
+		txn := database.NewDatabaseTransaction(ctx, true)
+		_, err := c.AddingBlock(ctx, block, txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+	}
+
+	coins, err := c.GetCoins(ctx, genesis.Transactions[0].Operations[0].Account)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Coin{}, coins)
+
+	txn := database.NewDatabaseTransaction(ctx, true)
+	_, err = c.RemovingBlock(ctx, spend, txn)
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(ctx))
+
+	// The original coin (not the operation that spent it)
+	// is restored.
+	coins, err = c.GetCoins(ctx, genesis.Transactions[0].Operations[0].Account)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Coin{
+		{
+			Identifier:  "coin1",
+			Transaction: genesis.Transactions[0],
+			Operation:   genesis.Transactions[0].Operations[0],
+		},
+	}, coins)
+
+	coins, err = c.GetCoins(ctx, spend.Transactions[0].Operations[1].Account)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Coin{}, coins)
+
+	// Spending the coin again after the re-org succeeds.
+	txn = database.NewDatabaseTransaction(ctx, true)
+	_, err = c.AddingBlock(ctx, spend, txn)
+	assert.NoError(t, err)
+	assert.NoError(t, txn.Commit(ctx))
+}
+
+func TestCoinPrune(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	helper := &MockBalanceStorageHelper{}
+	c := NewCoinStorage(database, helper.Asserter())
+
+	for _, block := range []*types.Block{
+		lifecycleBlock(0, lifecycleOp("addr1", "100", coinCreated, "coin1")),
+		lifecycleBlock(
+			1,
+			lifecycleOp("addr1", "-100", coinSpent, "coin1"),
+			lifecycleOp("addr2", "100", coinCreated, "coin2"),
+		),
+		lifecycleBlock(2, lifecycleOp("addr2", "-100", coinSpent, "coin2")),
+	} {
+		txn := database.NewDatabaseTransaction(ctx, true)
+		_, err := c.AddingBlock(ctx, block, txn)
+		assert.NoError(t, err)
+		assert.NoError(t, txn.Commit(ctx))
+	}
+
+	spent := func(identifier string) bool {
+		exists, _, err := database.Get(ctx, getCoinSpentKey(identifier))
+		assert.NoError(t, err)
+		return exists
+	}
+
+	t.Run("prune spent coins", func(t *testing.T) {
+		pruned, err := c.Prune(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+		assert.False(t, spent("coin1"))
+		assert.True(t, spent("coin2"))
+
+		spentBlocks, err := database.Scan(ctx, getCoinSpentBlockPrefix(1))
+		assert.NoError(t, err)
+		assert.Len(t, spentBlocks, 0)
+	})
+
+	t.Run("prune again", func(t *testing.T) {
+		pruned, err := c.Prune(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+
+		pruned, err = c.Prune(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, pruned)
+		assert.False(t, spent("coin2"))
+	})
+
+	t.Run("spend pruned coin", func(t *testing.T) {
+		txn := database.NewDatabaseTransaction(ctx, true)
+		defer txn.Discard(ctx)
+
+		_, err := c.AddingBlock(
+			ctx,
+			lifecycleBlock(3, lifecycleOp("addr1", "-100", coinSpent, "coin1")),
+			txn,
+		)
+		assert.True(t, errors.Is(err, ErrCoinSpentBeforeCreated))
+	})
+}
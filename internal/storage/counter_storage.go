@@ -27,12 +27,34 @@ const (
 	// OrphanCounter is the number of orphaned blocks.
 	OrphanCounter = "orphans"
 
+	// ReorgCounter is the number of re-orgs.
+	ReorgCounter = "reorgs"
+
+	// MaxReorgDepthCounter is the largest number of blocks
+	// orphaned in a single re-org.
+	MaxReorgDepthCounter = "max_reorg_depth"
+
 	// TransactionCounter is the number of processed transactions.
 	TransactionCounter = "transactions"
 
 	// OperationCounter is the number of processed operations.
 	OperationCounter = "operations"
 
+	// BalanceChangingOperationCounter is the number of operations
+	// in canonical blocks that change a balance (successful
+	// operations with an account and an amount). Unlike
+	// OperationCounter, it is decremented when a block is orphaned.
+	BalanceChangingOperationCounter = "balance_changing_operations"
+
+	// MempoolTransactionCounter is the number of
+	// checked mempool transactions.
+	MempoolTransactionCounter = "mempool_transactions"
+
+	// DroppedTransactionCounter is the number of mempool
+	// transactions that left the mempool without appearing
+	// in a synced block.
+	DroppedTransactionCounter = "dropped_transactions"
+
 	// ActiveReconciliationCounter is the number of active
 	// reconciliations performed.
 	ActiveReconciliationCounter = "active_reconciliations"
@@ -43,8 +65,28 @@ const (
 
 	// counterNamespace is preprended to any counter.
 	counterNamespace = "counter"
+
+	// operationTypeCounterPrefix is prepended to the
+	// counter of each operation type.
+	operationTypeCounterPrefix = "operation_type"
+
+	// operationStatusCounterPrefix is prepended to the
+	// counter of each operation status.
+	operationStatusCounterPrefix = "operation_status"
 )
 
+// OperationTypeCounter returns the name of the counter of
+// operations in canonical blocks with an operation type.
+func OperationTypeCounter(operationType string) string {
+	return fmt.Sprintf("%s/%s", operationTypeCounterPrefix, operationType)
+}
+
+// OperationStatusCounter returns the name of the counter of
+// operations in canonical blocks with an operation status.
+func OperationStatusCounter(operationStatus string) string {
+	return fmt.Sprintf("%s/%s", operationStatusCounterPrefix, operationStatus)
+}
+
 // CounterStorage implements counter-specific storage methods
 // on top of a Database and DatabaseTransaction interface.
 type CounterStorage struct {
@@ -81,23 +123,45 @@ func transactionalGet(
 	return new(big.Int).SetBytes(val), nil
 }
 
-// Update updates the value of a counter by amount and returns the new value.
-func (c *CounterStorage) Update(
+func transactionalUpdate(
 	ctx context.Context,
 	counter string,
 	amount *big.Int,
+	txn DatabaseTransaction,
 ) (*big.Int, error) {
-	transaction := c.db.NewDatabaseTransaction(ctx, true)
-	defer transaction.Discard(ctx)
-
-	val, err := transactionalGet(ctx, counter, transaction)
+	val, err := transactionalGet(ctx, counter, txn)
 	if err != nil {
 		return nil, err
 	}
 
+	// Counters are stored without a sign, so a counter
+	// decremented below zero (ex: an operation counter of a
+	// block synced before the counter existed is decremented
+	// when the block is orphaned) is stored as 0.
 	newVal := new(big.Int).Add(val, amount)
+	if newVal.Sign() < 0 {
+		newVal.SetInt64(0)
+	}
+
+	if err := txn.Set(ctx, getCounterKey(counter), newVal.Bytes()); err != nil {
+		return nil, err
+	}
 
-	if err := transaction.Set(ctx, getCounterKey(counter), newVal.Bytes()); err != nil {
+	return newVal, nil
+}
+
+// Update updates the value of a counter by amount and returns the new value
+// (which is never less than 0).
+func (c *CounterStorage) Update(
+	ctx context.Context,
+	counter string,
+	amount *big.Int,
+) (*big.Int, error) {
+	transaction := c.db.NewDatabaseTransaction(ctx, true)
+	defer transaction.Discard(ctx)
+
+	newVal, err := transactionalUpdate(ctx, counter, amount, transaction)
+	if err != nil {
 		return nil, err
 	}
 
@@ -108,6 +172,25 @@ func (c *CounterStorage) Update(
 	return newVal, nil
 }
 
+// UpdateBatch updates the value of each counter in updates
+// by its amount in a single database transaction (so either
+// all counters are updated or none are).
+func (c *CounterStorage) UpdateBatch(
+	ctx context.Context,
+	updates map[string]*big.Int,
+) error {
+	transaction := c.db.NewDatabaseTransaction(ctx, true)
+	defer transaction.Discard(ctx)
+
+	for counter, amount := range updates {
+		if _, err := transactionalUpdate(ctx, counter, amount, transaction); err != nil {
+			return fmt.Errorf("%w: unable to update %s counter", err, counter)
+		}
+	}
+
+	return transaction.Commit(ctx)
+}
+
 // Get returns the current value of a counter.
 func (c *CounterStorage) Get(ctx context.Context, counter string) (*big.Int, error) {
 	transaction := c.db.NewDatabaseTransaction(ctx, false)
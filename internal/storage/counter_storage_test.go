@@ -68,4 +68,51 @@ func TestCounterStorage(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, v, big.NewInt(0))
 	})
+
+	t.Run("operation type and status counters", func(t *testing.T) {
+		v, err := c.Update(ctx, OperationTypeCounter("transfer"), big.NewInt(2))
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(2))
+
+		// Types and statuses with the same name
+		// are counted separately.
+		v, err = c.Get(ctx, OperationStatusCounter("transfer"))
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(0))
+
+		v, err = c.Get(ctx, OperationTypeCounter("fee"))
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(0))
+	})
+
+	t.Run("update batch", func(t *testing.T) {
+		err := c.UpdateBatch(ctx, map[string]*big.Int{
+			"blah":  big.NewInt(-10),
+			"blah3": big.NewInt(5),
+		})
+		assert.NoError(t, err)
+
+		v, err := c.Get(ctx, "blah")
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(40))
+
+		v, err = c.Get(ctx, "blah3")
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(5))
+	})
+
+	t.Run("decrement unset counter", func(t *testing.T) {
+		err := c.UpdateBatch(ctx, map[string]*big.Int{
+			OperationTypeCounter("reward"): big.NewInt(-1),
+		})
+		assert.NoError(t, err)
+
+		v, err := c.Get(ctx, OperationTypeCounter("reward"))
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(0))
+
+		v, err = c.Update(ctx, OperationTypeCounter("reward"), big.NewInt(1))
+		assert.NoError(t, err)
+		assert.Equal(t, v, big.NewInt(1))
+	})
 }
@@ -0,0 +1,289 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+var _ BlockWorker = (*FeeStorage)(nil)
+
+const (
+	// feeNamespace is prepended to the total
+	// fees paid in each currency.
+	feeNamespace = "fee"
+)
+
+// getFeeKey returns the key of the total
+// fees paid in a currency.
+func getFeeKey(currency *types.Currency) []byte {
+	return []byte(
+		fmt.Sprintf("%s/%s", feeNamespace, types.Hash(currency)),
+	)
+}
+
+// TransactionFee is the fees paid in a transaction.
+type TransactionFee struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Fees                  []*types.Amount              `json:"fees"`
+}
+
+// FeeStorageHandler is invoked after the fees in a block
+// are committed to the database.
+type FeeStorageHandler interface {
+	FeesAdded(ctx context.Context, block *types.Block, fees []*TransactionFee) error
+	FeesRemoved(ctx context.Context, block *types.Block, fees []*TransactionFee) error
+}
+
+// FeeStorage implements block specific storage methods
+// on top of a Database and DatabaseTransaction interface
+// to track the fees paid in each transaction.
+type FeeStorage struct {
+	db       Database
+	parser   *parser.Parser
+	feeTypes map[string]struct{}
+	handler  FeeStorageHandler
+}
+
+// NewFeeStorage returns a new FeeStorage.
+func NewFeeStorage(
+	db Database,
+) *FeeStorage {
+	return &FeeStorage{
+		db: db,
+	}
+}
+
+// Initialize is called to provide FeeStorage with the operation
+// types used to pay fees and a handler. This is separated from
+// NewFeeStorage to match the initialization of BalanceStorage.
+func (f *FeeStorage) Initialize(
+	asserter *asserter.Asserter,
+	feeTypes []string,
+	handler FeeStorageHandler,
+) {
+	typeMap := map[string]struct{}{}
+	for _, opType := range feeTypes {
+		typeMap[opType] = struct{}{}
+	}
+
+	f.parser = parser.New(asserter, nil)
+	f.feeTypes = typeMap
+	f.handler = handler
+}
+
+// transactionFees returns the fees paid in each transaction in a
+// block that has fee operations. The fee paid in a currency is the
+// sum of all decreases in balance caused by fee operations (so fees
+// credited to another account in the same transaction, like a
+// validator, are not counted twice).
+func (f *FeeStorage) transactionFees(
+	ctx context.Context,
+	block *types.Block,
+) ([]*TransactionFee, error) {
+	fees := []*TransactionFee{}
+	for _, tx := range block.Transactions {
+		feeOps := []*types.Operation{}
+		for _, op := range tx.Operations {
+			if _, ok := f.feeTypes[op.Type]; ok {
+				feeOps = append(feeOps, op)
+			}
+		}
+
+		if len(feeOps) == 0 {
+			continue
+		}
+
+		changes, err := f.parser.BalanceChanges(ctx, &types.Block{
+			BlockIdentifier:       block.BlockIdentifier,
+			ParentBlockIdentifier: block.ParentBlockIdentifier,
+			Transactions: []*types.Transaction{
+				{
+					TransactionIdentifier: tx.TransactionIdentifier,
+					Operations:            feeOps,
+				},
+			},
+		}, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to calculate fee balance changes", err)
+		}
+
+		paid := map[string]*types.Amount{}
+		txFees := []*types.Amount{}
+		for _, change := range changes {
+			difference, ok := new(big.Int).SetString(change.Difference, 10)
+			if !ok {
+				return nil, fmt.Errorf("%s is not an integer", change.Difference)
+			}
+
+			if difference.Sign() >= 0 {
+				continue
+			}
+
+			key := types.Hash(change.Currency)
+			fee, ok := paid[key]
+			if !ok {
+				fee = &types.Amount{Value: "0", Currency: change.Currency}
+				paid[key] = fee
+				txFees = append(txFees, fee)
+			}
+
+			fee.Value, err = types.SubtractValues(fee.Value, change.Difference)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(txFees) == 0 {
+			continue
+		}
+
+		fees = append(fees, &TransactionFee{
+			TransactionIdentifier: tx.TransactionIdentifier,
+			Fees:                  txFees,
+		})
+	}
+
+	return fees, nil
+}
+
+// updateFees adds the fees in each TransactionFee
+// (negated if removed) to the total fees paid.
+func (f *FeeStorage) updateFees(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	fees []*TransactionFee,
+	removed bool,
+) error {
+	for _, txFee := range fees {
+		for _, fee := range txFee.Fees {
+			total, err := getFee(ctx, transaction, fee.Currency)
+			if err != nil {
+				return err
+			}
+
+			if removed {
+				total.Value, err = types.SubtractValues(total.Value, fee.Value)
+			} else {
+				total.Value, err = types.AddValues(total.Value, fee.Value)
+			}
+			if err != nil {
+				return err
+			}
+
+			serialFee, err := encode(total)
+			if err != nil {
+				return err
+			}
+
+			if err := transaction.Set(ctx, getFeeKey(fee.Currency), serialFee); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getFee returns the total fees paid in a currency
+// in a database transaction.
+func getFee(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	currency *types.Currency,
+) (*types.Amount, error) {
+	exists, rawFee, err := transaction.Get(ctx, getFeeKey(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return &types.Amount{Value: "0", Currency: currency}, nil
+	}
+
+	var fee types.Amount
+	if err := decode(rawFee, &fee); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse fee entry", err)
+	}
+
+	return &fee, nil
+}
+
+// GetAllFees returns the total fees paid in
+// each currency.
+func (f *FeeStorage) GetAllFees(ctx context.Context) ([]*types.Amount, error) {
+	rawFees, err := f.db.Scan(ctx, []byte(fmt.Sprintf("%s/", feeNamespace)))
+	if err != nil {
+		return nil, fmt.Errorf("%w database scan failed", err)
+	}
+
+	fees := make([]*types.Amount, len(rawFees))
+	for i, rawFee := range rawFees {
+		var fee types.Amount
+		if err := decode(rawFee, &fee); err != nil {
+			return nil, fmt.Errorf("%w unable to parse fee entry", err)
+		}
+
+		fees[i] = &fee
+	}
+
+	return fees, nil
+}
+
+// AddingBlock is called by BlockStorage when adding a block to storage.
+func (f *FeeStorage) AddingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction DatabaseTransaction,
+) (CommitWorker, error) {
+	fees, err := f.transactionFees(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.updateFees(ctx, transaction, fees, false); err != nil {
+		return nil, fmt.Errorf("%w: unable to update fees", err)
+	}
+
+	return func(ctx context.Context) error {
+		return f.handler.FeesAdded(ctx, block, fees)
+	}, nil
+}
+
+// RemovingBlock is called by BlockStorage when removing a block from storage.
+func (f *FeeStorage) RemovingBlock(
+	ctx context.Context,
+	block *types.Block,
+	transaction DatabaseTransaction,
+) (CommitWorker, error) {
+	fees, err := f.transactionFees(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.updateFees(ctx, transaction, fees, true); err != nil {
+		return nil, fmt.Errorf("%w: unable to update fees", err)
+	}
+
+	return func(ctx context.Context) error {
+		return f.handler.FeesRemoved(ctx, block, fees)
+	}, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFeeHandler struct {
+	added   map[string][]*TransactionFee
+	removed map[string][]*TransactionFee
+}
+
+func (h *mockFeeHandler) FeesAdded(
+	ctx context.Context,
+	block *types.Block,
+	fees []*TransactionFee,
+) error {
+	h.added[block.BlockIdentifier.Hash] = fees
+	return nil
+}
+
+func (h *mockFeeHandler) FeesRemoved(
+	ctx context.Context,
+	block *types.Block,
+	fees []*TransactionFee,
+) error {
+	h.removed[block.BlockIdentifier.Hash] = fees
+	return nil
+}
+
+func feeOp(opType string, status string, address string, value string) *types.Operation {
+	return &types.Operation{
+		Type:   opType,
+		Status: status,
+		Account: &types.AccountIdentifier{
+			Address: address,
+		},
+		Amount: &types.Amount{
+			Value:    value,
+			Currency: verifyCurrency,
+		},
+	}
+}
+
+func TestFeeStorage(t *testing.T) {
+	ctx := context.Background()
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(ctx)
+
+	handler := &mockFeeHandler{
+		added:   map[string][]*TransactionFee{},
+		removed: map[string][]*TransactionFee{},
+	}
+	feeStorage := NewFeeStorage(database)
+	feeStorage.Initialize((&MockBalanceStorageHelper{}).Asserter(), []string{"Fee"}, handler)
+
+	blockStorage := NewBlockStorage(database)
+	blockStorage.Initialize([]BlockWorker{feeStorage})
+
+	genesis := verifyBlock(0, feeOp("Transfer", "Success", "addr1", "100"))
+	block1 := verifyBlock(
+		1,
+		feeOp("Transfer", "Success", "addr1", "-40"),
+		feeOp("Transfer", "Success", "addr2", "40"),
+		feeOp("Fee", "Success", "addr1", "-2"),
+		feeOp("Fee", "Success", "validator", "1"),
+	)
+	block1.Transactions = append(block1.Transactions, &types.Transaction{
+		TransactionIdentifier: &types.TransactionIdentifier{Hash: "tx 2"},
+		Operations: []*types.Operation{
+			feeOp("Fee", "Success", "addr2", "-3"),
+		},
+	})
+	block1.Transactions[1].Operations[0].OperationIdentifier = &types.OperationIdentifier{
+		Index: 0,
+	}
+
+	t.Run("no fees", func(t *testing.T) {
+		assert.NoError(t, blockStorage.AddBlock(ctx, genesis))
+		assert.Len(t, handler.added[genesis.BlockIdentifier.Hash], 0)
+
+		fees, err := feeStorage.GetAllFees(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, fees, 0)
+	})
+
+	t.Run("add fees", func(t *testing.T) {
+		assert.NoError(t, blockStorage.AddBlock(ctx, block1))
+		assert.Equal(t, []*TransactionFee{
+			{
+				TransactionIdentifier: block1.Transactions[0].TransactionIdentifier,
+				Fees:                  []*types.Amount{{Value: "2", Currency: verifyCurrency}},
+			},
+			{
+				TransactionIdentifier: block1.Transactions[1].TransactionIdentifier,
+				Fees:                  []*types.Amount{{Value: "3", Currency: verifyCurrency}},
+			},
+		}, handler.added[block1.BlockIdentifier.Hash])
+
+		fees, err := feeStorage.GetAllFees(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Amount{{Value: "5", Currency: verifyCurrency}}, fees)
+	})
+
+	t.Run("remove fees", func(t *testing.T) {
+		assert.NoError(t, blockStorage.RemoveBlock(ctx, block1.BlockIdentifier))
+		assert.Len(t, handler.removed[block1.BlockIdentifier.Hash], 2)
+
+		fees, err := feeStorage.GetAllFees(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Amount{{Value: "0", Currency: verifyCurrency}}, fees)
+	})
+}
@@ -0,0 +1,543 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// ParentChainCheck ensures each stored block is
+	// stored under its own identifier and links to a
+	// stored parent.
+	ParentChainCheck = "parent_chain"
+
+	// BlockIndexCheck ensures the block hash index
+	// matches the stored blocks.
+	BlockIndexCheck = "block_index"
+
+	// TransactionIndexCheck ensures the transaction hash
+	// index matches the stored blocks.
+	TransactionIndexCheck = "transaction_index"
+
+	// CounterCheck ensures the block, transaction, and
+	// operation counters match the stored blocks.
+	CounterCheck = "counters"
+
+	// BalanceCheck ensures the balances in BalanceStorage
+	// match the balances computed from the stored blocks.
+	BalanceCheck = "balances"
+)
+
+// StorageProblem is an inconsistency found
+// by VerifyStorage.
+type StorageProblem struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// StorageVerification is the result of VerifyStorage.
+type StorageVerification struct {
+	HeadBlock   *types.BlockIdentifier `json:"head_block_identifier"`
+	OldestBlock *types.BlockIdentifier `json:"oldest_block_identifier"`
+
+	// ReachedGenesis is true if the oldest stored
+	// block is the genesis block.
+	ReachedGenesis bool  `json:"reached_genesis"`
+	PrunedIndex    int64 `json:"pruned_index"`
+
+	Blocks       int64 `json:"blocks"`
+	Transactions int64 `json:"transactions"`
+	Operations   int64 `json:"operations"`
+	Balances     int64 `json:"balances"`
+
+	// Skipped contains the reason each check
+	// that could not be performed was skipped.
+	Skipped  map[string]string `json:"skipped,omitempty"`
+	Problems []*StorageProblem `json:"problems"`
+}
+
+func (v *StorageVerification) addProblem(check string, format string, a ...interface{}) {
+	v.Problems = append(v.Problems, &StorageProblem{
+		Check:   check,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// storageVerifier walks BlockStorage from the head block to
+// the oldest stored block.
+type storageVerifier struct {
+	db     Database
+	parser *parser.Parser
+
+	result *StorageVerification
+
+	// blocks are the hashes and indexes of all
+	// blocks reachable from the head block.
+	blocks map[string]int64
+
+	// balances are the balances computed from
+	// the stored blocks.
+	balances   map[string]string
+	currencies map[string]*types.Currency
+	accounts   map[string]*types.AccountIdentifier
+}
+
+// VerifyStorage checks that the data in a database written by
+// `check:data` is consistent. The parent chain, block hash index,
+// transaction hash index, and counters are always checked. Balances
+// are recomputed with the provided parser (starting from
+// bootstrapBalances) if all blocks from genesis are stored and
+// none have been pruned. An error is only returned if storage
+// cannot be read (inconsistencies are returned as problems).
+func VerifyStorage(
+	ctx context.Context,
+	db Database,
+	balanceParser *parser.Parser,
+	bootstrapBalances []*BootstrapBalance,
+) (*StorageVerification, error) {
+	v := &storageVerifier{
+		db:     db,
+		parser: balanceParser,
+		result: &StorageVerification{
+			Skipped:  map[string]string{},
+			Problems: []*StorageProblem{},
+		},
+		blocks:     map[string]int64{},
+		balances:   map[string]string{},
+		currencies: map[string]*types.Currency{},
+		accounts:   map[string]*types.AccountIdentifier{},
+	}
+
+	for _, balance := range bootstrapBalances {
+		if err := v.addBalance(balance.Account, balance.Currency, balance.Value); err != nil {
+			return nil, fmt.Errorf("%w: unable to add bootstrap balance", err)
+		}
+	}
+
+	if err := v.walkBlocks(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkBlockIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkTransactionIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkCounters(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkBalances(ctx); err != nil {
+		return nil, err
+	}
+
+	return v.result, nil
+}
+
+func (v *storageVerifier) addBalance(
+	account *types.AccountIdentifier,
+	currency *types.Currency,
+	value string,
+) error {
+	key := string(GetBalanceKey(account, currency))
+	existing, ok := v.balances[key]
+	if !ok {
+		existing = "0"
+	}
+
+	newVal, err := types.AddValues(existing, value)
+	if err != nil {
+		return err
+	}
+
+	v.balances[key] = newVal
+	v.accounts[key] = account
+	v.currencies[key] = currency
+
+	return nil
+}
+
+// walkBlocks walks from the head block to the oldest stored
+// block, checking the parent chain and the hash indexes of
+// each block.
+func (v *storageVerifier) walkBlocks(ctx context.Context) error {
+	blockStorage := NewBlockStorage(v.db)
+	head, err := blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get head block", err)
+	}
+
+	prunedIndex, err := blockStorage.GetPrunedIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get pruned index", err)
+	}
+
+	v.result.HeadBlock = head
+	v.result.PrunedIndex = prunedIndex
+
+	transaction := v.db.NewDatabaseTransaction(ctx, false)
+	defer transaction.Discard(ctx)
+
+	currBlock := head
+	for {
+		exists, val, err := transaction.Get(ctx, getBlockKey(currBlock))
+		if err != nil {
+			return fmt.Errorf("%w: unable to get block %+v", err, currBlock)
+		}
+
+		// If the parent of the oldest block is not stored,
+		// syncing started after genesis.
+		if !exists {
+			if currBlock == head {
+				v.result.addProblem(ParentChainCheck, "head block %+v is not stored", head)
+			}
+
+			return nil
+		}
+
+		var block types.Block
+		if err := decode(val, &block); err != nil {
+			return fmt.Errorf("%w: unable to decode block %+v", err, currBlock)
+		}
+
+		if types.Hash(block.BlockIdentifier) != types.Hash(currBlock) {
+			v.result.addProblem(
+				ParentChainCheck,
+				"block stored at %+v has identifier %+v",
+				currBlock,
+				block.BlockIdentifier,
+			)
+
+			return nil
+		}
+
+		if err := v.verifyBlock(ctx, transaction, &block); err != nil {
+			return err
+		}
+
+		v.result.OldestBlock = block.BlockIdentifier
+
+		// Stop at the genesis block
+		if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+			v.result.ReachedGenesis = true
+			return nil
+		}
+
+		if block.ParentBlockIdentifier.Index > block.BlockIdentifier.Index {
+			v.result.addProblem(
+				ParentChainCheck,
+				"block %+v has parent with greater index %+v",
+				block.BlockIdentifier,
+				block.ParentBlockIdentifier,
+			)
+
+			return nil
+		}
+
+		currBlock = block.ParentBlockIdentifier
+	}
+}
+
+// verifyBlock checks the hash indexes of a block and
+// adds its balance changes to the computed balances.
+func (v *storageVerifier) verifyBlock(
+	ctx context.Context,
+	transaction DatabaseTransaction,
+	block *types.Block,
+) error {
+	v.blocks[block.BlockIdentifier.Hash] = block.BlockIdentifier.Index
+	v.result.Blocks++
+
+	exists, _, err := transaction.Get(ctx, getBlockHashKey(block.BlockIdentifier))
+	if err != nil {
+		return fmt.Errorf("%w: unable to get block hash %s", err, block.BlockIdentifier.Hash)
+	}
+
+	if !exists {
+		v.result.addProblem(
+			BlockIndexCheck,
+			"block hash of %+v is not indexed",
+			block.BlockIdentifier,
+		)
+	}
+
+	// Pruned blocks do not contain transactions.
+	if block.BlockIdentifier.Index <= v.result.PrunedIndex {
+		return nil
+	}
+
+	for _, txn := range block.Transactions {
+		v.result.Transactions++
+		v.result.Operations += int64(len(txn.Operations))
+
+		exists, val, err := transaction.Get(ctx, getTransactionHashKey(txn.TransactionIdentifier))
+		if err != nil {
+			return fmt.Errorf(
+				"%w: unable to get transaction hash %s",
+				err,
+				txn.TransactionIdentifier.Hash,
+			)
+		}
+
+		var blocks map[string]int64
+		if exists {
+			if err := decode(val, &blocks); err != nil {
+				return fmt.Errorf("%w: could not decode transaction hash contents", err)
+			}
+		}
+
+		if index, ok := blocks[block.BlockIdentifier.Hash]; !ok || index != block.BlockIdentifier.Index {
+			v.result.addProblem(
+				TransactionIndexCheck,
+				"transaction %s in block %+v is not indexed",
+				txn.TransactionIdentifier.Hash,
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	if v.parser == nil {
+		return nil
+	}
+
+	changes, err := v.parser.BalanceChanges(ctx, block, false)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: unable to calculate balance changes in block %+v",
+			err,
+			block.BlockIdentifier,
+		)
+	}
+
+	// Balances are only compared after all blocks
+	// are walked, so the order in which balance
+	// changes are added does not matter.
+	for _, change := range changes {
+		if err := v.addBalance(change.Account, change.Currency, change.Difference); err != nil {
+			return fmt.Errorf("%w: unable to add balance change", err)
+		}
+	}
+
+	return nil
+}
+
+// checkBlockIndex ensures there are no stored blocks or
+// indexed block hashes that are not reachable from the
+// head block.
+func (v *storageVerifier) checkBlockIndex(ctx context.Context) error {
+	rawBlocks, err := v.db.Scan(ctx, []byte(fmt.Sprintf("%s/", blockNamespace)))
+	if err != nil {
+		return fmt.Errorf("%w: unable to scan blocks", err)
+	}
+
+	for _, rawBlock := range rawBlocks {
+		var block types.Block
+		if err := decode(rawBlock, &block); err != nil {
+			return fmt.Errorf("%w: unable to decode block", err)
+		}
+
+		index, ok := v.blocks[block.BlockIdentifier.Hash]
+		if !ok || index != block.BlockIdentifier.Index {
+			v.result.addProblem(
+				BlockIndexCheck,
+				"block %+v is stored but not reachable from head block",
+				block.BlockIdentifier,
+			)
+		}
+	}
+
+	hashes, err := v.db.Scan(ctx, []byte(fmt.Sprintf("%s/", blockHashNamespace)))
+	if err != nil {
+		return fmt.Errorf("%w: unable to scan block hashes", err)
+	}
+
+	if int64(len(hashes)) != v.result.Blocks {
+		v.result.addProblem(
+			BlockIndexCheck,
+			"%d block hashes are indexed but %d blocks are reachable from head block",
+			len(hashes),
+			v.result.Blocks,
+		)
+	}
+
+	return nil
+}
+
+// checkTransactionIndex ensures every indexed transaction
+// hash refers to a block reachable from the head block.
+func (v *storageVerifier) checkTransactionIndex(ctx context.Context) error {
+	rawTransactions, err := v.db.Scan(ctx, []byte(fmt.Sprintf("%s/", transactionHashNamespace)))
+	if err != nil {
+		return fmt.Errorf("%w: unable to scan transaction hashes", err)
+	}
+
+	for _, rawTransaction := range rawTransactions {
+		var blocks map[string]int64
+		if err := decode(rawTransaction, &blocks); err != nil {
+			return fmt.Errorf("%w: could not decode transaction hash contents", err)
+		}
+
+		for hash, index := range blocks {
+			if storedIndex, ok := v.blocks[hash]; !ok || storedIndex != index {
+				v.result.addProblem(
+					TransactionIndexCheck,
+					"transaction hash is indexed in block %s:%d that is not reachable from head block",
+					hash,
+					index,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCounters ensures the counters updated by the syncer
+// match the stored blocks. Transactions and operations in
+// orphaned and pruned blocks are never subtracted from
+// their counters, so they are only checked when no blocks
+// have been orphaned or pruned.
+func (v *storageVerifier) checkCounters(ctx context.Context) error {
+	counterStorage := NewCounterStorage(v.db)
+	counters := map[string]*big.Int{}
+	for _, counter := range []string{
+		BlockCounter,
+		OrphanCounter,
+		TransactionCounter,
+		OperationCounter,
+	} {
+		val, err := counterStorage.Get(ctx, counter)
+		if err != nil {
+			return fmt.Errorf("%w: unable to get counter %s", err, counter)
+		}
+
+		counters[counter] = val
+	}
+
+	blocks := new(big.Int).Sub(counters[BlockCounter], counters[OrphanCounter])
+	if blocks.Cmp(big.NewInt(v.result.Blocks)) != 0 {
+		v.result.addProblem(
+			CounterCheck,
+			"%s blocks added and %s orphaned but %d blocks are stored",
+			counters[BlockCounter].String(),
+			counters[OrphanCounter].String(),
+			v.result.Blocks,
+		)
+	}
+
+	if counters[OrphanCounter].Sign() != 0 || v.result.PrunedIndex != -1 {
+		v.result.Skipped[CounterCheck] = "transaction and operation counters include orphaned or pruned blocks"
+		return nil
+	}
+
+	if counters[TransactionCounter].Cmp(big.NewInt(v.result.Transactions)) != 0 {
+		v.result.addProblem(
+			CounterCheck,
+			"transaction counter is %s but %d transactions are stored",
+			counters[TransactionCounter].String(),
+			v.result.Transactions,
+		)
+	}
+
+	if counters[OperationCounter].Cmp(big.NewInt(v.result.Operations)) != 0 {
+		v.result.addProblem(
+			CounterCheck,
+			"operation counter is %s but %d operations are stored",
+			counters[OperationCounter].String(),
+			v.result.Operations,
+		)
+	}
+
+	return nil
+}
+
+// checkBalances ensures the balances in BalanceStorage match the
+// balances computed from all stored blocks.
+func (v *storageVerifier) checkBalances(ctx context.Context) error {
+	switch {
+	case v.parser == nil:
+		v.result.Skipped[BalanceCheck] = "no parser provided"
+		return nil
+	case !v.result.ReachedGenesis:
+		v.result.Skipped[BalanceCheck] = "genesis block is not stored"
+		return nil
+	case v.result.PrunedIndex != -1:
+		v.result.Skipped[BalanceCheck] = "blocks have been pruned"
+		return nil
+	}
+
+	rawBalances, err := v.db.Scan(ctx, []byte(fmt.Sprintf("%s/", balanceNamespace)))
+	if err != nil {
+		return fmt.Errorf("%w: unable to scan balances", err)
+	}
+
+	if len(rawBalances) == 0 {
+		v.result.Skipped[BalanceCheck] = "no balances are stored"
+		return nil
+	}
+
+	stored := map[string]struct{}{}
+	for _, rawBalance := range rawBalances {
+		var entry balanceEntry
+		if err := decode(rawBalance, &entry); err != nil {
+			return fmt.Errorf("%w: unable to decode balance entry", err)
+		}
+
+		key := string(GetBalanceKey(entry.Account, entry.Amount.Currency))
+		stored[key] = struct{}{}
+		v.result.Balances++
+
+		computed, ok := v.balances[key]
+		if !ok {
+			computed = "0"
+		}
+
+		if computed != entry.Amount.Value {
+			v.result.addProblem(
+				BalanceCheck,
+				"balance of %s is %s %+v but %s was computed from stored blocks",
+				types.PrettyPrintStruct(entry.Account),
+				entry.Amount.Value,
+				entry.Amount.Currency,
+				computed,
+			)
+		}
+	}
+
+	for key, computed := range v.balances {
+		if _, ok := stored[key]; ok || computed == "0" {
+			continue
+		}
+
+		v.result.addProblem(
+			BalanceCheck,
+			"balance of %s is not stored but %s %+v was computed from stored blocks",
+			types.PrettyPrintStruct(v.accounts[key]),
+			computed,
+			v.currencies[key],
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	verifyCurrency = &types.Currency{
+		Symbol:   "BTC",
+		Decimals: 8,
+	}
+)
+
+type mockVerifyHandler struct{}
+
+func (h *mockVerifyHandler) BlockAdded(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func (h *mockVerifyHandler) BlockRemoved(
+	ctx context.Context,
+	block *types.Block,
+	changes []*parser.BalanceChange,
+) error {
+	return nil
+}
+
+func verifyBlock(index int64, ops ...*types.Operation) *types.Block {
+	for i, op := range ops {
+		op.OperationIdentifier = &types.OperationIdentifier{Index: int64(i)}
+	}
+
+	parentIndex := index - 1
+	if index == 0 {
+		parentIndex = 0
+	}
+
+	return &types.Block{
+		BlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(index),
+			Index: index,
+		},
+		ParentBlockIdentifier: &types.BlockIdentifier{
+			Hash:  types.Hash(parentIndex),
+			Index: parentIndex,
+		},
+		Transactions: []*types.Transaction{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: types.Hash(index),
+				},
+				Operations: ops,
+			},
+		},
+	}
+}
+
+func verifyOp(address string, value string) *types.Operation {
+	return &types.Operation{
+		Type:   "Transfer",
+		Status: "Success",
+		Account: &types.AccountIdentifier{
+			Address: address,
+		},
+		Amount: &types.Amount{
+			Value:    value,
+			Currency: verifyCurrency,
+		},
+	}
+}
+
+func TestVerifyStorage(t *testing.T) {
+	var (
+		blocks = []*types.Block{
+			verifyBlock(0, verifyOp("addr1", "100")),
+			verifyBlock(1, verifyOp("addr1", "-40"), verifyOp("addr2", "40")),
+			verifyBlock(2, verifyOp("addr2", "-10"), verifyOp("addr3", "10")),
+		}
+		addr1 = &types.AccountIdentifier{Address: "addr1"}
+	)
+
+	var tests = map[string]struct {
+		corrupt func(context.Context, *testing.T, Database)
+
+		problems       []string
+		skipped        []string
+		reachedGenesis bool
+	}{
+		"consistent": {
+			reachedGenesis: true,
+		},
+		"missing block hash": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, txn.Delete(ctx, getBlockHashKey(blocks[1].BlockIdentifier)))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems:       []string{BlockIndexCheck, BlockIndexCheck},
+			reachedGenesis: true,
+		},
+		"missing transaction hash": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, txn.Delete(
+					ctx,
+					getTransactionHashKey(blocks[2].Transactions[0].TransactionIdentifier),
+				))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems:       []string{TransactionIndexCheck},
+			reachedGenesis: true,
+		},
+		"unreachable block": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				orphan := verifyBlock(2)
+				orphan.BlockIdentifier.Hash = "orphan"
+
+				buf, err := encode(orphan)
+				assert.NoError(t, err)
+
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, txn.Set(ctx, getBlockKey(orphan.BlockIdentifier), buf))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems:       []string{BlockIndexCheck},
+			reachedGenesis: true,
+		},
+		"missing parent": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, txn.Delete(ctx, getBlockKey(blocks[0].BlockIdentifier)))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems: []string{
+				BlockIndexCheck,
+				TransactionIndexCheck,
+				CounterCheck,
+				CounterCheck,
+				CounterCheck,
+			},
+			skipped: []string{BalanceCheck},
+		},
+		"counter behind": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				_, err := NewCounterStorage(db).Update(ctx, BlockCounter, big.NewInt(-1))
+				assert.NoError(t, err)
+			},
+			problems:       []string{CounterCheck},
+			reachedGenesis: true,
+		},
+		"orphaned block": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				_, err := NewCounterStorage(db).Update(ctx, OrphanCounter, big.NewInt(1))
+				assert.NoError(t, err)
+
+				_, err = NewCounterStorage(db).Update(ctx, BlockCounter, big.NewInt(1))
+				assert.NoError(t, err)
+			},
+			skipped:        []string{CounterCheck},
+			reachedGenesis: true,
+		},
+		"balance mismatch": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, NewBalanceStorage(db).SetBalance(
+					ctx,
+					txn,
+					addr1,
+					&types.Amount{Value: "61", Currency: verifyCurrency},
+					blocks[2].BlockIdentifier,
+				))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems:       []string{BalanceCheck},
+			reachedGenesis: true,
+		},
+		"balance missing": {
+			corrupt: func(ctx context.Context, t *testing.T, db Database) {
+				txn := db.NewDatabaseTransaction(ctx, true)
+				assert.NoError(t, txn.Delete(ctx, GetBalanceKey(addr1, verifyCurrency)))
+				assert.NoError(t, txn.Commit(ctx))
+			},
+			problems:       []string{BalanceCheck},
+			reachedGenesis: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			newDir, err := utils.CreateTempDir()
+			assert.NoError(t, err)
+			defer utils.RemoveTempDir(newDir)
+
+			database, err := NewBadgerStorage(ctx, newDir)
+			assert.NoError(t, err)
+			defer database.Close(ctx)
+
+			helper := &MockBalanceStorageHelper{}
+			balanceStorage := NewBalanceStorage(database)
+			balanceStorage.Initialize(helper, &mockVerifyHandler{})
+
+			blockStorage := NewBlockStorage(database)
+			blockStorage.Initialize([]BlockWorker{balanceStorage})
+
+			counterStorage := NewCounterStorage(database)
+			for _, block := range blocks {
+				assert.NoError(t, blockStorage.AddBlock(ctx, block))
+
+				_, err = counterStorage.Update(ctx, BlockCounter, big.NewInt(1))
+				assert.NoError(t, err)
+				_, err = counterStorage.Update(ctx, TransactionCounter, big.NewInt(1))
+				assert.NoError(t, err)
+				_, err = counterStorage.Update(
+					ctx,
+					OperationCounter,
+					big.NewInt(int64(len(block.Transactions[0].Operations))),
+				)
+				assert.NoError(t, err)
+			}
+
+			if test.corrupt != nil {
+				test.corrupt(ctx, t, database)
+			}
+
+			result, err := VerifyStorage(ctx, database, parser.New(helper.Asserter(), nil), nil)
+			assert.NoError(t, err)
+
+			problems := []string{}
+			for _, problem := range result.Problems {
+				problems = append(problems, problem.Check)
+			}
+			if test.problems == nil {
+				test.problems = []string{}
+			}
+			assert.ElementsMatch(t, test.problems, problems)
+
+			skipped := []string{}
+			for check := range result.Skipped {
+				skipped = append(skipped, check)
+			}
+			if test.skipped == nil {
+				test.skipped = []string{}
+			}
+			assert.ElementsMatch(t, test.skipped, skipped)
+
+			assert.Equal(t, test.reachedGenesis, result.ReachedGenesis)
+			assert.Equal(t, blocks[2].BlockIdentifier, result.HeadBlock)
+		})
+	}
+}
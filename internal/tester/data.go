@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/concurrency"
 	"github.com/coinbase/rosetta-cli/internal/logger"
 	"github.com/coinbase/rosetta-cli/internal/processor"
 	"github.com/coinbase/rosetta-cli/internal/statefulsyncer"
@@ -54,21 +55,33 @@ const (
 	//
 	// TODO: make configurable
 	PeriodicLoggingFrequency = 10 * time.Second
+
+	// PruningFrequency is the frequency that old blocks
+	// are pruned from storage (if pruning is enabled).
+	PruningFrequency = 30 * time.Second
 )
 
 // DataTester coordinates the `check:data` test.
 type DataTester struct {
 	network           *types.NetworkIdentifier
 	database          storage.Database
+	dataPath          string
 	config            *configuration.Configuration
 	syncer            *statefulsyncer.StatefulSyncer
 	reconciler        *reconciler.Reconciler
 	logger            *logger.Logger
 	counterStorage    *storage.CounterStorage
+	blockStorage      *storage.BlockStorage
+	balanceStorage    *storage.BalanceStorage
+	coinStorage       *storage.CoinStorage
 	reconcilerHandler *processor.ReconcilerHandler
 	fetcher           *fetcher.Fetcher
+	exemptAccounts    []*reconciler.AccountCurrency
+	negativeAccounts  []*reconciler.AccountCurrency
 	signalReceived    *bool
 	genesisBlock      *types.BlockIdentifier
+	mempoolChecker    *processor.MempoolChecker
+	livenessChecker   *processor.LivenessChecker
 }
 
 func shouldReconcile(config *configuration.Configuration) bool {
@@ -97,6 +110,34 @@ func loadAccounts(filePath string) ([]*reconciler.AccountCurrency, error) {
 	return accounts, nil
 }
 
+// loadExpectedSupply is a utility function to parse the
+// []*processor.ExpectedSupply in a file.
+func loadExpectedSupply(filePath string) ([]*processor.ExpectedSupply, error) {
+	if len(filePath) == 0 {
+		return []*processor.ExpectedSupply{}, nil
+	}
+
+	expectedSupply := []*processor.ExpectedSupply{}
+	if err := utils.LoadAndParse(filePath, &expectedSupply); err != nil {
+		return nil, fmt.Errorf("%w: unable to open expected supply file", err)
+	}
+
+	log.Printf(
+		"Found expected supply at %d blocks in %s\n",
+		len(expectedSupply),
+		filePath,
+	)
+
+	return expectedSupply, nil
+}
+
+// DataPath returns the path in dataDirectory where `check:data`
+// stores data for a network. If the path does not exist, it is
+// created.
+func DataPath(dataDirectory string, network *types.NetworkIdentifier) (string, error) {
+	return utils.CreateCommandPath(dataDirectory, dataCmdName, network)
+}
+
 // CloseDatabase closes the database used by DataTester.
 func (t *DataTester) CloseDatabase(ctx context.Context) {
 	if err := t.database.Close(ctx); err != nil {
@@ -114,8 +155,9 @@ func InitializeData(
 	genesisBlock *types.BlockIdentifier,
 	interestingAccount *reconciler.AccountCurrency,
 	signalReceived *bool,
+	concurrencyLimits []*concurrency.Limit,
 ) *DataTester {
-	dataPath, err := utils.CreateCommandPath(config.DataDirectory, dataCmdName, network)
+	dataPath, err := DataPath(config.DataDirectory, network)
 	if err != nil {
 		log.Fatalf("%s: cannot create command path", err.Error())
 	}
@@ -130,6 +172,11 @@ func InitializeData(
 		log.Fatalf("%s: unable to load exempt accounts", err.Error())
 	}
 
+	negativeAccounts, err := loadAccounts(config.Data.NegativeBalanceAccounts)
+	if err != nil {
+		log.Fatalf("%s: unable to load negative balance accounts", err.Error())
+	}
+
 	interestingAccounts, err := loadAccounts(config.Data.InterestingAccounts)
 	if err != nil {
 		log.Fatalf("%s: unable to load interesting accounts", err.Error())
@@ -146,8 +193,26 @@ func InitializeData(
 		config.Data.LogTransactions,
 		config.Data.LogBalanceChanges,
 		config.Data.LogReconciliations,
+		config.Data.LogReorgs,
 	)
 
+	asserterConfiguration, err := fetcher.Asserter.ClientConfiguration()
+	if err != nil {
+		log.Fatalf("%s: unable to load asserter configuration", err.Error())
+	}
+
+	operationStatuses := make([]string, len(asserterConfiguration.AllowedOperationStatuses))
+	for i, status := range asserterConfiguration.AllowedOperationStatuses {
+		operationStatuses[i] = status.Status
+	}
+
+	logger.TrackOperations(
+		asserterConfiguration.AllowedOperationTypes,
+		operationStatuses,
+		config.Data.OperationCoverageDepth,
+	)
+	logger.TrackConcurrency(concurrencyLimits)
+
 	reconcilerHelper := processor.NewReconcilerHelper(
 		blockStorage,
 		balanceStorage,
@@ -178,13 +243,73 @@ func InitializeData(
 		reconciler.WithInactiveFrequency(int64(config.Data.InactiveReconciliationFrequency)),
 	)
 
-	blockWorkers := []storage.BlockWorker{}
+	// Validators only validate blocks (without accessing storage),
+	// so they are also used to validate mempool transactions
+	// (except for block metadata, as mempool transactions are
+	// not in a block).
+	validators := []storage.BlockWorker{
+		processor.NewRelatedOperationsChecker(config.Data.PairedOperationTypes),
+	}
+
+	if config.Data.ConservationCheckEnabled {
+		validators = append(validators, processor.NewConservationChecker(
+			fetcher.Asserter,
+			config.Data.FeeOperationTypes,
+			config.Data.MintBurnOperationTypes,
+		))
+	}
+
+	if config.Data.AddressFormat != nil {
+		validators = append(
+			validators,
+			processor.NewAddressChecker(config.Data.AddressFormat),
+		)
+	}
+
+	mempoolValidators := append([]storage.BlockWorker{}, validators...)
+	if config.Data.MetadataSchemas != nil {
+		metadataChecker, err := processor.NewMetadataChecker(
+			config.Data.MetadataSchemas.Block,
+			config.Data.MetadataSchemas.Transaction,
+			config.Data.MetadataSchemas.Operations,
+		)
+		if err != nil {
+			log.Fatalf("%s: unable to load metadata schemas", err.Error())
+		}
+
+		validators = append(validators, metadataChecker)
+		mempoolValidators = append(mempoolValidators, metadataChecker.TransactionChecker())
+	}
+
+	blockWorkers := []storage.BlockWorker{
+		processor.NewTimestampChecker(
+			blockStorage,
+			config.Data.TimestampDecreaseAllowed,
+			config.Data.MaxTimestampSkew,
+			config.Data.MinBlockInterval,
+			config.Data.MaxBlockInterval,
+		),
+	}
+	blockWorkers = append(blockWorkers, validators...)
+
+	if len(config.Data.FeeOperationTypes) > 0 {
+		feeStorage := storage.NewFeeStorage(localStore)
+		feeStorage.Initialize(
+			fetcher.Asserter,
+			config.Data.FeeOperationTypes,
+			processor.NewFeeStorageHandler(logger),
+		)
+		logger.TrackFees(feeStorage)
+		blockWorkers = append(blockWorkers, feeStorage)
+	}
+
 	if !config.Data.BalanceTrackingDisabled {
 		balanceStorageHelper := processor.NewBalanceStorageHelper(
 			network,
 			fetcher,
 			!config.Data.HistoricalBalanceDisabled,
 			exemptAccounts,
+			config.Data.ExemptionRules,
 		)
 
 		balanceStorageHandler := processor.NewBalanceStorageHandler(
@@ -195,6 +320,14 @@ func InitializeData(
 		)
 
 		balanceStorage.Initialize(balanceStorageHelper, balanceStorageHandler)
+		balanceStorage.AllowNegativeBalances(negativeAccounts)
+		if config.Data.BalanceHistoryEnabled {
+			balanceStorage.EnableHistory()
+		}
+
+		if config.Data.SupplyCheck != nil {
+			balanceStorage.EnableSupply()
+		}
 
 		// Bootstrap balances if provided
 		if len(config.Data.BootstrapBalances) > 0 {
@@ -215,6 +348,43 @@ func InitializeData(
 		}
 
 		blockWorkers = append(blockWorkers, balanceStorage)
+
+		// The supply checker must run after balance storage so
+		// that it observes the balances of the block being added.
+		if config.Data.SupplyCheck != nil {
+			expectedSupplyByBlock, err := loadExpectedSupply(config.Data.SupplyCheck.ExpectedSupplyFile)
+			if err != nil {
+				log.Fatalf("%s: unable to load expected supply", err.Error())
+			}
+
+			blockWorkers = append(blockWorkers, processor.NewSupplyChecker(
+				balanceStorage,
+				balanceStorageHelper,
+				config.Data.SupplyCheck.ExpectedSupply,
+				expectedSupplyByBlock,
+				config.Data.MintBurnOperationTypes,
+			))
+		}
+	}
+
+	// Coins are only tracked for UTXO-based blockchains. Coin storage
+	// must run after balance storage so that it can compare unspent
+	// coins with the balances of the block being added.
+	var coinStorage *storage.CoinStorage
+	if config.Construction.AccountingModel == configuration.UtxoModel {
+		coinStorage = storage.NewCoinStorage(localStore, fetcher.Asserter)
+
+		// Exempt accounts, bootstrapped balances, and accounts
+		// allowed to go negative are not expected to match the
+		// sum of their unspent coins.
+		if !config.Data.BalanceTrackingDisabled &&
+			len(config.Data.BootstrapBalances) == 0 &&
+			len(exemptAccounts) == 0 &&
+			len(config.Data.ExemptionRules) == 0 {
+			coinStorage.EnableBalanceCheck(negativeAccounts)
+		}
+
+		blockWorkers = append(blockWorkers, coinStorage)
 	}
 
 	syncer := statefulsyncer.New(
@@ -226,20 +396,55 @@ func InitializeData(
 		logger,
 		cancel,
 		blockWorkers,
+		config.Data.MaxReorgDepth,
 	)
 
+	var mempoolChecker *processor.MempoolChecker
+	if config.Data.MempoolCheck != nil {
+		mempoolChecker = processor.NewMempoolChecker(
+			network,
+			fetcher,
+			fetcher.Asserter,
+			blockStorage,
+			counterStorage,
+			mempoolValidators,
+			time.Duration(config.Data.MempoolCheck.PollingFrequency)*time.Second,
+			config.Data.MempoolCheck.InclusionWindow,
+		)
+	}
+
+	var livenessChecker *processor.LivenessChecker
+	if config.Data.LivenessCheck != nil {
+		livenessChecker = processor.NewLivenessChecker(
+			network,
+			fetcher,
+			time.Duration(config.Data.LivenessCheck.PollingFrequency)*time.Second,
+			time.Duration(config.Data.LivenessCheck.StallTimeout)*time.Second,
+			config.Data.MaxReorgDepth,
+			config.Data.LivenessCheck.HaltOnFailure,
+		)
+	}
+
 	return &DataTester{
 		network:           network,
 		database:          localStore,
+		dataPath:          dataPath,
 		config:            config,
 		syncer:            syncer,
 		reconciler:        r,
 		logger:            logger,
 		counterStorage:    counterStorage,
+		blockStorage:      blockStorage,
+		balanceStorage:    balanceStorage,
+		coinStorage:       coinStorage,
 		reconcilerHandler: reconcilerHandler,
 		fetcher:           fetcher,
+		exemptAccounts:    exemptAccounts,
+		negativeAccounts:  negativeAccounts,
 		signalReceived:    signalReceived,
 		genesisBlock:      genesisBlock,
+		mempoolChecker:    mempoolChecker,
+		livenessChecker:   livenessChecker,
 	}
 }
 
@@ -260,29 +465,150 @@ func (t *DataTester) StartSyncing(
 func (t *DataTester) StartReconciler(
 	ctx context.Context,
 ) error {
-	if shouldReconcile(t.config) {
+	if !shouldReconcile(t.config) {
 		return nil
 	}
 
 	return t.reconciler.Reconcile(ctx)
 }
 
+// StartMempoolChecker starts the mempool checker
+// if the mempool is checked.
+func (t *DataTester) StartMempoolChecker(
+	ctx context.Context,
+) error {
+	if t.mempoolChecker == nil {
+		return nil
+	}
+
+	return t.mempoolChecker.Start(ctx)
+}
+
+// StartLivenessChecker starts the liveness checker
+// if liveness is checked.
+func (t *DataTester) StartLivenessChecker(
+	ctx context.Context,
+) error {
+	if t.livenessChecker == nil {
+		return nil
+	}
+
+	return t.livenessChecker.Start(ctx)
+}
+
 // StartPeriodicLogger prints out periodic
 // stats about a run of `check:data`.
 func (t *DataTester) StartPeriodicLogger(
 	ctx context.Context,
 ) error {
 	for ctx.Err() == nil {
-		_ = t.logger.LogDataStats(ctx)
+		t.logStats(ctx)
 		time.Sleep(PeriodicLoggingFrequency)
 	}
 
 	// Print stats one last time before exiting
-	_ = t.logger.LogDataStats(ctx)
+	t.logStats(ctx)
 
 	return ctx.Err()
 }
 
+// logStats prints out the stats in counter storage,
+// the supply of each currency (if supply is checked),
+// and the sync lag (if liveness is checked).
+func (t *DataTester) logStats(ctx context.Context) {
+	_ = t.logger.LogDataStats(ctx)
+
+	if t.config.Data.SupplyCheck != nil {
+		supply, err := t.balanceStorage.GetAllSupply(ctx)
+		if err == nil {
+			_ = t.logger.LogSupplyStats(ctx, supply)
+		}
+	}
+
+	if t.livenessChecker == nil {
+		return
+	}
+
+	tip := t.livenessChecker.Tip()
+	if tip == nil {
+		return
+	}
+
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil {
+		return
+	}
+
+	_ = t.logger.LogSyncStats(ctx, head, tip)
+}
+
+// StartPruning periodically prunes all blocks older than
+// the configured pruning depth. Pruning is performed in the
+// background so that it does not block syncing.
+func (t *DataTester) StartPruning(
+	ctx context.Context,
+) error {
+	if t.config.Data.PruningDepth == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(PruningFrequency)
+	defer ticker.Stop()
+
+	for {
+		t.prune(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune prunes all blocks older than the
+// configured pruning depth (if any).
+func (t *DataTester) prune(ctx context.Context) {
+	head, err := t.blockStorage.GetHeadBlockIdentifier(ctx)
+	if err != nil || head.Index-t.config.Data.PruningDepth < 0 {
+		return
+	}
+
+	pruned, err := t.blockStorage.Prune(
+		ctx,
+		head.Index-t.config.Data.PruningDepth,
+		t.config.Data.PruneTransactionHashes,
+	)
+	// Pruning may conflict with a concurrent database transaction
+	// created while syncing, so we just try again later.
+	if err != nil {
+		color.Yellow("%s: unable to prune blocks", err.Error())
+	}
+
+	if pruned > 0 {
+		log.Printf(
+			"Pruned %d blocks (pruned index: %d)\n",
+			pruned,
+			head.Index-t.config.Data.PruningDepth,
+		)
+	}
+
+	// Spent coins are only needed to restore coins in a
+	// reorg, so they are pruned with blocks.
+	if t.coinStorage == nil {
+		return
+	}
+
+	prunedCoins, err := t.coinStorage.Prune(ctx, head.Index-t.config.Data.PruningDepth)
+	if err != nil {
+		color.Yellow("%s: unable to prune spent coins", err.Error())
+	}
+
+	if prunedCoins > 0 {
+		log.Printf("Pruned %d spent coins\n", prunedCoins)
+	}
+}
+
 // HandleErr is called when `check:data` returns an error.
 // If historical balance lookups are enabled, HandleErr will attempt to
 // automatically find any missing balance-changing operations.
@@ -313,6 +639,15 @@ func (t *DataTester) HandleErr(ctx context.Context, err error, sigListeners []co
 	}
 
 	color.Red("Check failed: %s", err.Error())
+	if t.reconcilerHandler.Failure != nil {
+		artifactPath, err := t.WriteFailureArtifacts(ctx, t.reconcilerHandler.Failure)
+		if err != nil {
+			color.Red("%s: unable to write failure artifacts", err.Error())
+		} else {
+			color.Red("Failure artifacts written to %s", artifactPath)
+		}
+	}
+
 	if t.reconcilerHandler.InactiveFailure == nil {
 		os.Exit(1)
 	}
@@ -392,6 +727,7 @@ func (t *DataTester) recursiveOpSearch(
 		false,
 		false,
 		false,
+		false,
 	)
 
 	reconcilerHelper := processor.NewReconcilerHelper(
@@ -427,6 +763,7 @@ func (t *DataTester) recursiveOpSearch(
 		t.fetcher,
 		!t.config.Data.HistoricalBalanceDisabled,
 		nil,
+		nil,
 	)
 
 	balanceStorageHandler := processor.NewBalanceStorageHandler(
@@ -437,6 +774,7 @@ func (t *DataTester) recursiveOpSearch(
 	)
 
 	balanceStorage.Initialize(balanceStorageHelper, balanceStorageHandler)
+	balanceStorage.AllowNegativeBalances(t.negativeAccounts)
 
 	syncer := statefulsyncer.New(
 		ctx,
@@ -447,6 +785,7 @@ func (t *DataTester) recursiveOpSearch(
 		logger,
 		cancel,
 		[]storage.BlockWorker{balanceStorage},
+		t.config.Data.MaxReorgDepth,
 	)
 
 	g, ctx := errgroup.WithContext(ctx)
@@ -0,0 +1,228 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/mockserver"
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		reconciliationDisabled  bool
+		balanceTrackingDisabled bool
+
+		reconcile bool
+	}{
+		"enabled": {
+			reconcile: true,
+		},
+		"reconciliation disabled": {
+			reconciliationDisabled: true,
+		},
+		"balance tracking disabled": {
+			balanceTrackingDisabled: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := configuration.DefaultConfiguration()
+			config.Data.ReconciliationDisabled = test.reconciliationDisabled
+			config.Data.BalanceTrackingDisabled = test.balanceTrackingDisabled
+
+			assert.Equal(t, test.reconcile, shouldReconcile(config))
+
+			if !test.reconcile {
+				// The reconciler is never initialized when
+				// reconciliation is disabled, so StartReconciler
+				// must return before using it.
+				dataTester := &DataTester{config: config}
+				assert.NoError(t, dataTester.StartReconciler(context.Background()))
+			}
+		})
+	}
+}
+
+func TestStartPruning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+	defer utils.RemoveTempDir(newDir)
+
+	database, err := storage.NewBadgerStorage(ctx, newDir)
+	assert.NoError(t, err)
+	defer database.Close(context.Background())
+
+	config := configuration.DefaultConfiguration()
+	config.Data.PruningDepth = 10
+	dataTester := &DataTester{
+		config:       config,
+		blockStorage: storage.NewBlockStorage(database),
+	}
+
+	pruningErr := make(chan error, 1)
+	go func() {
+		pruningErr <- dataTester.StartPruning(ctx)
+	}()
+
+	// Pruning stops as soon as the context is
+	// canceled (instead of after PruningFrequency).
+	cancel()
+	select {
+	case err := <-pruningErr:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "pruning did not stop")
+	}
+}
+
+// initializeMockData returns a *DataTester (using config) that syncs
+// from a mock server serving fixture and a function to clean up all
+// resources. The returned context is canceled once the end index
+// is synced.
+func initializeMockData(
+	t *testing.T,
+	fixture *mockserver.Fixture,
+	config *configuration.Configuration,
+) (context.Context, *DataTester, func()) {
+	server, err := mockserver.NewServer(fixture)
+	assert.NoError(t, err)
+
+	handler, err := server.Handler()
+	assert.NoError(t, err)
+
+	httpServer := httptest.NewServer(handler)
+
+	newDir, err := utils.CreateTempDir()
+	assert.NoError(t, err)
+
+	config.Network = fixture.Network
+	config.OnlineURL = httpServer.URL
+	config.DataDirectory = newDir
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f := fetcher.New(config.OnlineURL, fetcher.WithRetryElapsedTime(10*time.Second))
+	_, _, fetchErr := f.InitializeAsserter(ctx)
+	assert.Nil(t, fetchErr)
+
+	networkStatus, err := utils.CheckNetworkSupported(ctx, config.Network, f)
+	assert.NoError(t, err)
+
+	signalReceived := false
+	dataTester := InitializeData(
+		ctx,
+		config,
+		config.Network,
+		f,
+		cancel,
+		networkStatus.GenesisBlockIdentifier,
+		nil,
+		&signalReceived,
+		nil,
+	)
+
+	return ctx, dataTester, func() {
+		cancel()
+		dataTester.CloseDatabase(context.Background())
+		utils.RemoveTempDir(newDir)
+		httpServer.Close()
+	}
+}
+
+func TestCheckDataMockServer(t *testing.T) {
+	fixture, err := mockserver.LoadFixture("../../examples/mock_server_fixture.json")
+	assert.NoError(t, err)
+
+	// Reconciliation is disabled so that syncing
+	// does not wait for the reconciler.
+	config := configuration.DefaultConfiguration()
+	config.Data.ReconciliationDisabled = true
+
+	ctx, dataTester, cleanup := initializeMockData(t, fixture, config)
+	defer cleanup()
+
+	// The syncer cancels ctx once the end index is synced.
+	endIndex := int64(len(fixture.Blocks) - 1)
+	err = dataTester.StartSyncing(ctx, -1, endIndex)
+	assert.True(t, err == nil || err == context.Canceled)
+
+	counter := func(name string) *big.Int {
+		value, err := dataTester.counterStorage.Get(context.Background(), name)
+		assert.NoError(t, err)
+		return value
+	}
+
+	head, err := dataTester.blockStorage.GetHeadBlockIdentifier(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, endIndex, head.Index)
+
+	// Every block is synced once and each block
+	// orphaned by the reorg is synced again.
+	orphans := counter(storage.OrphanCounter)
+	assert.Equal(t, fixture.Reorgs[0].Depth, orphans.Int64())
+	assert.Equal(t, endIndex+1+orphans.Int64(), counter(storage.BlockCounter).Int64())
+}
+
+func TestStartReconciler(t *testing.T) {
+	fixture, err := mockserver.LoadFixture("../../examples/mock_server_fixture.json")
+	assert.NoError(t, err)
+
+	ctx, dataTester, cleanup := initializeMockData(
+		t,
+		fixture,
+		configuration.DefaultConfiguration(),
+	)
+	defer cleanup()
+
+	reconcilerCtx, reconcilerCancel := context.WithCancel(context.Background())
+	reconcilerErr := make(chan error, 1)
+	go func() {
+		reconcilerErr <- dataTester.StartReconciler(reconcilerCtx)
+	}()
+
+	// The syncer cancels ctx once the end index is synced.
+	endIndex := int64(len(fixture.Blocks) - 1)
+	err = dataTester.StartSyncing(ctx, -1, endIndex)
+	assert.True(t, err == nil || err == context.Canceled)
+
+	// Balances of blocks orphaned by the reorg in the
+	// fixture are skipped instead of failing reconciliation.
+	assert.Eventually(t, func() bool {
+		active, err := dataTester.counterStorage.Get(
+			context.Background(),
+			storage.ActiveReconciliationCounter,
+		)
+		assert.NoError(t, err)
+		return active.Sign() > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	reconcilerCancel()
+	assert.Equal(t, context.Canceled, <-reconcilerErr)
+	assert.Nil(t, dataTester.reconcilerHandler.Failure)
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/diff"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// DiffSleep is the amount of time to wait for
+	// both implementations to reach the next block
+	// when caught up.
+	DiffSleep = 5 * time.Second
+
+	// DiffLoggingFrequency is the number of blocks compared
+	// between each progress log.
+	DiffLoggingFrequency = 1000
+)
+
+var (
+	// ErrDivergence is returned when a block (or the balance
+	// changes in a block) differ between implementations.
+	ErrDivergence = errors.New("implementations diverged")
+)
+
+// Divergence is the first difference found by a
+// DiffTester.
+type Divergence struct {
+	// Type is "genesis_block", "block", or "balance_changes".
+	Type        string                 `json:"type"`
+	Block       *types.BlockIdentifier `json:"block_identifier"`
+	Differences []*diff.Difference     `json:"differences"`
+}
+
+// DiffTester coordinates the `check:diff` test.
+type DiffTester struct {
+	network *types.NetworkIdentifier
+
+	// fetcher is the implementation under test and
+	// referenceFetcher is the implementation it is
+	// compared against.
+	fetcher          *fetcher.Fetcher
+	referenceFetcher *fetcher.Fetcher
+
+	// confirmationDepth is the number of blocks
+	// to stay behind the lowest tip of both
+	// implementations (so that blocks that may
+	// still be orphaned are not compared).
+	confirmationDepth int64
+
+	// Divergence is populated when ErrDivergence
+	// is returned.
+	Divergence *Divergence
+}
+
+// InitializeDiff returns a new *DiffTester. Both
+// fetchers must have initialized asserters.
+func InitializeDiff(
+	network *types.NetworkIdentifier,
+	fetcher *fetcher.Fetcher,
+	referenceFetcher *fetcher.Fetcher,
+	confirmationDepth int64,
+) *DiffTester {
+	return &DiffTester{
+		network:           network,
+		fetcher:           fetcher,
+		referenceFetcher:  referenceFetcher,
+		confirmationDepth: confirmationDepth,
+	}
+}
+
+// tip returns the lowest current block index
+// of both implementations.
+func (t *DiffTester) tip(ctx context.Context) (int64, *types.BlockIdentifier, error) {
+	status, err := t.fetcher.NetworkStatusRetry(ctx, t.network, nil)
+	if err != nil {
+		return -1, nil, fmt.Errorf("%w: unable to get network status", err)
+	}
+
+	referenceStatus, err := t.referenceFetcher.NetworkStatusRetry(ctx, t.network, nil)
+	if err != nil {
+		return -1, nil, fmt.Errorf("%w: unable to get reference network status", err)
+	}
+
+	if types.Hash(status.GenesisBlockIdentifier) != types.Hash(referenceStatus.GenesisBlockIdentifier) {
+		t.Divergence = &Divergence{
+			Type:  "genesis_block",
+			Block: referenceStatus.GenesisBlockIdentifier,
+			Differences: []*diff.Difference{
+				{
+					Path:     "genesis_block_identifier",
+					Expected: referenceStatus.GenesisBlockIdentifier,
+					Actual:   status.GenesisBlockIdentifier,
+				},
+			},
+		}
+
+		return -1, nil, ErrDivergence
+	}
+
+	tip := status.CurrentBlockIdentifier.Index
+	if referenceStatus.CurrentBlockIdentifier.Index < tip {
+		tip = referenceStatus.CurrentBlockIdentifier.Index
+	}
+
+	return tip, referenceStatus.GenesisBlockIdentifier, nil
+}
+
+// Start compares each block in [startIndex, endIndex] in both
+// implementations once both implementations are confirmationDepth
+// blocks past it. If startIndex is -1, comparison starts at
+// genesis. If endIndex is -1, comparison continues until
+// ctx is cancelled.
+func (t *DiffTester) Start(ctx context.Context, startIndex int64, endIndex int64) error {
+	// The tip is only fetched again once all
+	// blocks before it have been compared.
+	tip, genesisBlock, err := t.tip(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := startIndex
+	if index == -1 {
+		index = genesisBlock.Index
+	}
+
+	lastLogged := index
+	for endIndex == -1 || index <= endIndex {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if index > tip-t.confirmationDepth {
+			tip, _, err = t.tip(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		if index > tip-t.confirmationDepth {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(DiffSleep):
+			}
+
+			continue
+		}
+
+		if err := t.compareBlock(ctx, index); err != nil {
+			return err
+		}
+
+		if index-lastLogged >= DiffLoggingFrequency {
+			log.Printf("Compared blocks %d-%d\n", lastLogged, index)
+			lastLogged = index + 1
+		}
+
+		index++
+	}
+
+	log.Printf("Compared blocks %d-%d\n", lastLogged, index-1)
+	return nil
+}
+
+// compareBlock compares the block (and its balance changes)
+// at an index in both implementations.
+func (t *DiffTester) compareBlock(ctx context.Context, index int64) error {
+	block, err := t.fetcher.BlockRetry(
+		ctx,
+		t.network,
+		&types.PartialBlockIdentifier{Index: &index},
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch block %d", err, index)
+	}
+
+	referenceBlock, err := t.referenceFetcher.BlockRetry(
+		ctx,
+		t.network,
+		&types.PartialBlockIdentifier{Index: &index},
+	)
+	if err != nil {
+		return fmt.Errorf("%w: unable to fetch reference block %d", err, index)
+	}
+
+	differences, err := diff.Compare(referenceBlock, block)
+	if err != nil {
+		return fmt.Errorf("%w: unable to compare block %d", err, index)
+	}
+
+	if len(differences) > 0 {
+		t.Divergence = &Divergence{
+			Type:        "block",
+			Block:       referenceBlock.BlockIdentifier,
+			Differences: differences,
+		}
+
+		return ErrDivergence
+	}
+
+	// Balance changes could differ even when blocks are
+	// identical if the implementations consider different
+	// operation statuses successful.
+	changes, err := balanceChanges(ctx, t.fetcher, block)
+	if err != nil {
+		return err
+	}
+
+	referenceChanges, err := balanceChanges(ctx, t.referenceFetcher, referenceBlock)
+	if err != nil {
+		return err
+	}
+
+	differences, err = diff.Compare(referenceChanges, changes)
+	if err != nil {
+		return fmt.Errorf("%w: unable to compare balance changes in block %d", err, index)
+	}
+
+	if len(differences) > 0 {
+		t.Divergence = &Divergence{
+			Type:        "balance_changes",
+			Block:       referenceBlock.BlockIdentifier,
+			Differences: differences,
+		}
+
+		return ErrDivergence
+	}
+
+	return nil
+}
+
+// balanceChanges returns the balance changes in a block
+// sorted by account and currency.
+func balanceChanges(
+	ctx context.Context,
+	f *fetcher.Fetcher,
+	block *types.Block,
+) ([]*parser.BalanceChange, error) {
+	p := parser.New(f.Asserter, nil)
+	changes, err := p.BalanceChanges(ctx, block, false)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w: unable to calculate balance changes in block %d",
+			err,
+			block.BlockIdentifier.Index,
+		)
+	}
+
+	keys := make(map[*parser.BalanceChange]string, len(changes))
+	for _, change := range changes {
+		keys[change] = types.Hash(&reconciler.AccountCurrency{
+			Account:  change.Account,
+			Currency: change.Currency,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return keys[changes[i]] < keys[changes[j]]
+	})
+
+	return changes, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/mockserver"
+
+	"github.com/coinbase/rosetta-sdk-go/fetcher"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDiffServer returns a fetcher for a mock server (with the
+// tip advanced to the last block of the fixture) and a counter
+// of /network/status requests.
+func newDiffServer(t *testing.T, fixture *mockserver.Fixture) (*fetcher.Fetcher, *int64, func()) {
+	server, err := mockserver.NewServer(fixture)
+	assert.NoError(t, err)
+
+	for range fixture.Blocks {
+		_, rosettaErr := server.NetworkStatus(context.Background(), nil)
+		assert.Nil(t, rosettaErr)
+	}
+
+	handler, err := server.Handler()
+	assert.NoError(t, err)
+
+	var statusRequests int64
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/network/status" {
+			atomic.AddInt64(&statusRequests, 1)
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+
+	f := fetcher.New(httpServer.URL)
+	_, _, fetchErr := f.InitializeAsserter(context.Background())
+	assert.Nil(t, fetchErr)
+	atomic.StoreInt64(&statusRequests, 0)
+
+	return f, &statusRequests, httpServer.Close
+}
+
+func TestDiffTester(t *testing.T) {
+	fixture, err := mockserver.LoadFixture("../../examples/mock_server_fixture.json")
+	assert.NoError(t, err)
+
+	f, statusRequests, closer := newDiffServer(t, fixture)
+	defer closer()
+
+	referenceFetcher, referenceStatusRequests, referenceCloser := newDiffServer(t, fixture)
+	defer referenceCloser()
+
+	tip := fixture.Blocks[len(fixture.Blocks)-1].BlockIdentifier.Index
+	confirmationDepth := int64(2)
+	diffTester := InitializeDiff(fixture.Network, f, referenceFetcher, confirmationDepth)
+
+	t.Run("compare confirmed blocks", func(t *testing.T) {
+		// The tip is only fetched once because every
+		// block is confirmed.
+		assert.NoError(t, diffTester.Start(context.Background(), -1, tip-confirmationDepth))
+		assert.Equal(t, int64(1), atomic.LoadInt64(statusRequests))
+		assert.Equal(t, int64(1), atomic.LoadInt64(referenceStatusRequests))
+		assert.Nil(t, diffTester.Divergence)
+	})
+
+	t.Run("wait for confirmations", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		diffErr := make(chan error, 1)
+		go func() {
+			diffErr <- diffTester.Start(ctx, tip-confirmationDepth, tip)
+		}()
+
+		// The block after the last confirmed block is
+		// never compared and waiting for it stops as
+		// soon as the context is canceled.
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt64(statusRequests) >= 3
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-diffErr:
+			assert.Equal(t, context.Canceled, err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "comparison did not stop")
+		}
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/coinbase/rosetta-cli/internal/processor"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+const (
+	// failureArtifactsDirectory is the directory in the data path
+	// where failure artifact bundles are written.
+	failureArtifactsDirectory = "failures"
+
+	// FailureArtifactBalanceChanges is the maximum number of
+	// balance changes for the failing account to include in a
+	// failure artifact bundle.
+	FailureArtifactBalanceChanges = 25
+
+	// FailureArtifactLookbackWindow is the maximum number of blocks
+	// to search (starting from the failing block) for balance changes
+	// of the failing account.
+	FailureArtifactLookbackWindow = 1000
+)
+
+// failureBalances contains the balances of the failing account
+// at the time of a reconciliation failure.
+type failureBalances struct {
+	ComputedBalance string                 `json:"computed_balance"`
+	NodeBalance     string                 `json:"node_balance"`
+	StoredBalance   *types.Amount          `json:"stored_balance,omitempty"`
+	StoredBlock     *types.BlockIdentifier `json:"stored_block_identifier,omitempty"`
+}
+
+// WriteFailureArtifacts writes all data needed to reproduce a
+// reconciliation failure to a new directory in the data path
+// and returns the path to that directory.
+func (t *DataTester) WriteFailureArtifacts(
+	ctx context.Context,
+	failure *processor.ReconciliationFailure,
+) (string, error) {
+	artifactPath := path.Join(
+		t.dataPath,
+		failureArtifactsDirectory,
+		fmt.Sprintf("%d-%d", failure.Block.Index, time.Now().Unix()),
+	)
+	if err := utils.EnsurePathExists(artifactPath); err != nil {
+		return "", fmt.Errorf("%w: unable to create failure artifacts directory", err)
+	}
+
+	if err := utils.SerializeAndWrite(path.Join(artifactPath, "failure.json"), failure); err != nil {
+		return "", err
+	}
+
+	// Secrets (like connection headers) are redacted so
+	// that the bundle can be shared.
+	if err := utils.SerializeAndWrite(
+		path.Join(artifactPath, "configuration.json"),
+		t.config.Redacted(),
+	); err != nil {
+		return "", err
+	}
+
+	// The failing block may have been orphaned before the run halted,
+	// so we write whatever blocks we can find.
+	block, err := t.blockStorage.GetBlock(ctx, failure.Block)
+	if err == nil {
+		if err := utils.SerializeAndWrite(path.Join(artifactPath, "block.json"), block); err != nil {
+			return "", err
+		}
+
+		parentBlock, err := t.blockStorage.GetBlock(ctx, block.ParentBlockIdentifier)
+		if err == nil {
+			if err := utils.SerializeAndWrite(
+				path.Join(artifactPath, "parent_block.json"),
+				parentBlock,
+			); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	balances := &failureBalances{
+		ComputedBalance: failure.ComputedBalance,
+		NodeBalance:     failure.NodeBalance,
+	}
+	// The stored balance is read without querying the node
+	// (which may be the cause of the failure).
+	storedBalance, storedBlock, err := t.balanceStorage.GetStoredBalance(
+		ctx,
+		failure.Account,
+		failure.Currency,
+	)
+	if err == nil {
+		balances.StoredBalance = storedBalance
+		balances.StoredBlock = storedBlock
+	}
+	if err := utils.SerializeAndWrite(path.Join(artifactPath, "balances.json"), balances); err != nil {
+		return "", err
+	}
+
+	changes, err := t.recentBalanceChanges(ctx, failure)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to find recent balance changes", err)
+	}
+	if err := utils.SerializeAndWrite(path.Join(artifactPath, "balance_changes.json"), changes); err != nil {
+		return "", err
+	}
+
+	streamLines, err := t.logger.StreamLines([]string{
+		failure.Account.Address,
+		failure.Block.Hash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to read stream logs", err)
+	}
+	if err := utils.SerializeAndWrite(path.Join(artifactPath, "logs.json"), streamLines); err != nil {
+		return "", err
+	}
+
+	return artifactPath, nil
+}
+
+// recentBalanceChanges walks backwards from the failing block and
+// returns the most recent balance changes of the failing account
+// (newest first).
+func (t *DataTester) recentBalanceChanges(
+	ctx context.Context,
+	failure *processor.ReconciliationFailure,
+) ([]*parser.BalanceChange, error) {
+	helper := processor.NewBalanceStorageHelper(
+		t.network,
+		t.fetcher,
+		!t.config.Data.HistoricalBalanceDisabled,
+		t.exemptAccounts,
+		t.config.Data.ExemptionRules,
+	)
+	p := parser.New(t.fetcher.Asserter, helper.ExemptFunc())
+
+	failureKey := types.Hash(&reconciler.AccountCurrency{
+		Account:  failure.Account,
+		Currency: failure.Currency,
+	})
+
+	changes := []*parser.BalanceChange{}
+	currBlock := failure.Block
+	for i := 0; i < FailureArtifactLookbackWindow; i++ {
+		if len(changes) >= FailureArtifactBalanceChanges {
+			break
+		}
+
+		block, err := t.blockStorage.GetBlock(ctx, currBlock)
+		if err != nil {
+			// We have reached the oldest block in storage (or the
+			// failing block was orphaned).
+			break
+		}
+
+		blockChanges, err := p.BalanceChanges(ctx, block, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range blockChanges {
+			if types.Hash(&reconciler.AccountCurrency{
+				Account:  change.Account,
+				Currency: change.Currency,
+			}) == failureKey {
+				changes = append(changes, change)
+			}
+		}
+
+		if block.BlockIdentifier.Index == block.ParentBlockIdentifier.Index {
+			break
+		}
+
+		currBlock = block.ParentBlockIdentifier
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/mockserver"
+	"github.com/coinbase/rosetta-cli/internal/processor"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/reconciler"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFailureArtifacts(t *testing.T) {
+	fixture, err := mockserver.LoadFixture("../../examples/mock_server_fixture.json")
+	assert.NoError(t, err)
+
+	// Reconciliation is disabled so that syncing
+	// does not wait for the reconciler.
+	config := configuration.DefaultConfiguration()
+	config.Data.ReconciliationDisabled = true
+	config.Connection = &configuration.ConnectionConfiguration{
+		Headers: map[string]string{"Authorization": "secret"},
+	}
+
+	ctx, dataTester, cleanup := initializeMockData(t, fixture, config)
+	defer cleanup()
+
+	endIndex := int64(len(fixture.Blocks) - 1)
+	err = dataTester.StartSyncing(ctx, -1, endIndex)
+	assert.True(t, err == nil || err == context.Canceled)
+
+	head, err := dataTester.blockStorage.GetHeadBlockIdentifier(context.Background())
+	assert.NoError(t, err)
+
+	currency := &types.Currency{Symbol: "MOCK", Decimals: 8}
+
+	t.Run("stored account", func(t *testing.T) {
+		failure := &processor.ReconciliationFailure{
+			Type:            reconciler.ActiveReconciliation,
+			Account:         &types.AccountIdentifier{Address: "addr3"},
+			Currency:        currency,
+			ComputedBalance: "100",
+			NodeBalance:     "90",
+			Block:           head,
+		}
+
+		artifactPath, err := dataTester.WriteFailureArtifacts(context.Background(), failure)
+		assert.NoError(t, err)
+
+		for _, file := range []string{
+			"failure.json",
+			"configuration.json",
+			"block.json",
+			"parent_block.json",
+			"balances.json",
+			"balance_changes.json",
+			"logs.json",
+		} {
+			_, err := os.Stat(path.Join(artifactPath, file))
+			assert.NoError(t, err, file)
+		}
+
+		var written configuration.Configuration
+		assert.NoError(t, utils.LoadAndParse(path.Join(artifactPath, "configuration.json"), &written))
+		assert.Equal(t, configuration.RedactedValue, written.Connection.Headers["Authorization"])
+
+		var block types.Block
+		assert.NoError(t, utils.LoadAndParse(path.Join(artifactPath, "block.json"), &block))
+		assert.Equal(t, head, block.BlockIdentifier)
+
+		storedBalance, storedBlock, err := dataTester.balanceStorage.GetStoredBalance(
+			context.Background(),
+			failure.Account,
+			failure.Currency,
+		)
+		assert.NoError(t, err)
+
+		var balances failureBalances
+		assert.NoError(t, utils.LoadAndParse(path.Join(artifactPath, "balances.json"), &balances))
+		assert.Equal(t, failureBalances{
+			ComputedBalance: failure.ComputedBalance,
+			NodeBalance:     failure.NodeBalance,
+			StoredBalance:   storedBalance,
+			StoredBlock:     storedBlock,
+		}, balances)
+
+		var changes []*parser.BalanceChange
+		assert.NoError(t, utils.LoadAndParse(
+			path.Join(artifactPath, "balance_changes.json"),
+			&changes,
+		))
+		assert.NotEmpty(t, changes)
+		for _, change := range changes {
+			assert.Equal(t, failure.Account, change.Account)
+		}
+	})
+
+	t.Run("missing account", func(t *testing.T) {
+		accounts, err := dataTester.balanceStorage.GetAllAccountCurrency(context.Background())
+		assert.NoError(t, err)
+
+		failure := &processor.ReconciliationFailure{
+			Type:            reconciler.ActiveReconciliation,
+			Account:         &types.AccountIdentifier{Address: "missing"},
+			Currency:        currency,
+			ComputedBalance: "0",
+			NodeBalance:     "10",
+			Block:           head,
+		}
+
+		artifactPath, err := dataTester.WriteFailureArtifacts(context.Background(), failure)
+		assert.NoError(t, err)
+
+		var balances failureBalances
+		assert.NoError(t, utils.LoadAndParse(path.Join(artifactPath, "balances.json"), &balances))
+		assert.Nil(t, balances.StoredBalance)
+		assert.Nil(t, balances.StoredBlock)
+
+		// Writing artifacts must not store a balance
+		// for the missing account.
+		newAccounts, err := dataTester.balanceStorage.GetAllAccountCurrency(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, accounts, newAccounts)
+	})
+}
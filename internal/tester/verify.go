@@ -0,0 +1,85 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/coinbase/rosetta-cli/configuration"
+	"github.com/coinbase/rosetta-cli/internal/processor"
+	"github.com/coinbase/rosetta-cli/internal/storage"
+	"github.com/coinbase/rosetta-cli/internal/utils"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/parser"
+	"github.com/coinbase/rosetta-sdk-go/types"
+)
+
+// VerifyData checks that the data stored by `check:data` for a network
+// is consistent. Balances are recomputed using the same exempt
+// accounts, exemption rules, and bootstrap balances as `check:data`.
+func VerifyData(
+	ctx context.Context,
+	config *configuration.Configuration,
+	network *types.NetworkIdentifier,
+	asserter *asserter.Asserter,
+) (*storage.StorageVerification, error) {
+	dataPath, err := DataPath(config.DataDirectory, network)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot create command path", err)
+	}
+
+	localStore, err := storage.NewBadgerStorage(ctx, dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to initialize database", err)
+	}
+	defer func() {
+		if err := localStore.Close(ctx); err != nil {
+			log.Printf("%s: error closing database\n", err.Error())
+		}
+	}()
+
+	exemptAccounts, err := loadAccounts(config.Data.ExemptAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to load exempt accounts", err)
+	}
+
+	bootstrapBalances := []*storage.BootstrapBalance{}
+	if len(config.Data.BootstrapBalances) > 0 {
+		err := utils.LoadAndParse(config.Data.BootstrapBalances, &bootstrapBalances)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load bootstrap balances", err)
+		}
+	}
+
+	// Balances are never fetched from the node,
+	// so no fetcher is required.
+	helper := processor.NewBalanceStorageHelper(
+		network,
+		nil,
+		false,
+		exemptAccounts,
+		config.Data.ExemptionRules,
+	)
+
+	return storage.VerifyStorage(
+		ctx,
+		localStore,
+		parser.New(asserter, helper.ExemptFunc()),
+		bootstrapBalances,
+	)
+}